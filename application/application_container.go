@@ -7,6 +7,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForApplicationContainerRunningPollInterval = time.Second * 10
@@ -92,13 +93,13 @@ type Container struct {
 	// URL of the created application
 	AppURL string `json:"appURL"`
 	// Creation time of the application
-	CreatedTime string `json:"createdTime"`
+	CreatedTime opc.Time `json:"createdTime"`
 	// Identity Domain of the application
 	IdentityDomain string `json:"identityDomain"`
 	// Shows details of all instances currently running.
 	Instances []Instance `json:"instances"`
 	// Modification time of the application
-	LastModifiedTime string `json:"lastModifiedTime"`
+	LastModifiedTime opc.Time `json:"lastModifiedTime"`
 	// Shows all deployments currently in progress.
 	LatestDeployment Deployment `json:"latestDeployment"`
 	// Name of the application
@@ -118,7 +119,7 @@ type Instance struct {
 	// Instance URL. Use this url to get a description of the application instance.
 	InstanceURL string `json:"instanceURL"`
 	// Memory of the instance
-	Memory string `json:"memory"`
+	Memory opc.ByteSize `json:"memory"`
 	// Instance Name. Use this name to manage a specific instance.
 	Name string `json:"name"`
 	// Status of the instance
@@ -325,3 +325,12 @@ func WasNotFoundError(e error) bool {
 	}
 	return false
 }
+
+// WasConflictError Used to determine if the request was rejected because of a conflicting change to the resource.
+func WasConflictError(e error) bool {
+	err, ok := e.(*opc.OracleError)
+	if ok {
+		return err.StatusCode == http.StatusConflict
+	}
+	return false
+}
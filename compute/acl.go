@@ -132,6 +132,26 @@ func (c *ACLsClient) DeleteACL(deleteInput *DeleteACLInput) error {
 	return c.deleteResource(deleteInput.Name)
 }
 
+// ACLList represents the list of ACLs returned by the service
+type ACLList struct {
+	Result []ACLInfo `json:"result"`
+}
+
+// ListACLs retrieves the ACLs of the client's owner.
+func (c *ACLsClient) ListACLs() ([]ACLInfo, error) {
+	var list ACLList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *ACLsClient) success(aclInfo *ACLInfo) (*ACLInfo, error) {
 	aclInfo.Name = c.getUnqualifiedName(aclInfo.Name)
 	return aclInfo, nil
@@ -2,6 +2,7 @@ package compute
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -14,7 +15,7 @@ type AuthenticationReq struct {
 // Get a new auth cookie for the compute client
 func (c *Client) getAuthenticationCookie() error {
 	req := AuthenticationReq{
-		User:     c.getUserName(),
+		User:     fmt.Sprintf(cmpUsername, *c.client.IdentityDomain, *c.client.UserName),
 		Password: *c.client.Password,
 	}
 
@@ -28,7 +29,24 @@ func (c *Client) getAuthenticationCookie() error {
 	}
 
 	c.client.DebugLogString("Successfully authenticated to OPC")
-	c.authCookie = rsp.Cookies()[0]
-	c.cookieIssued = time.Now()
+	c.auth.cookie = rsp.Cookies()[0]
+	c.auth.issued = time.Now()
 	return nil
 }
+
+// Returns the current auth cookie, refreshing it if it is about to expire
+func (c *Client) currentAuthenticationCookie() (*http.Cookie, error) {
+	c.auth.lock.Lock()
+	defer c.auth.lock.Unlock()
+
+	if c.auth.cookie == nil {
+		return nil, nil
+	}
+	if time.Since(c.auth.issued).Minutes() > 25 {
+		c.auth.cookie = nil
+		if err := c.getAuthenticationCookie(); err != nil {
+			return nil, err
+		}
+	}
+	return c.auth.cookie, nil
+}
@@ -65,7 +65,7 @@ func TestAccObtainAuthenticationCookie(t *testing.T) {
 		t.Fatalf("Authentication failed: %s", err)
 	}
 
-	if client.authCookie == nil {
+	if client.auth.cookie == nil {
 		t.Fatal("Authentication cookie not set")
 	}
 }
@@ -0,0 +1,241 @@
+package compute
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// The length of the IP address prefix of the largest IP network, a /16
+const largestIPNetworkPrefixLength = 16
+
+// ParseIPAddressPrefix parses an IPv4 address prefix in CIDR notation, such as 192.168.1.0/24,
+// as used by IP networks, IP address prefix sets and routes.
+func ParseIPAddressPrefix(prefix string) (*net.IPNet, error) {
+	ip, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%q isn't an IP address prefix in CIDR notation, such as 192.168.1.0/24", prefix)
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%q isn't an IPv4 address prefix", prefix)
+	}
+	return network, nil
+}
+
+// ParseIPNetworkPrefix parses the IP address prefix of an IP network, which can be a /16 at most
+func ParseIPNetworkPrefix(prefix string) (*net.IPNet, error) {
+	network, err := ParseIPAddressPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if ones, _ := network.Mask.Size(); ones < largestIPNetworkPrefixLength {
+		return nil, fmt.Errorf("IP address prefix %q is larger than the largest IP network, a /%d", prefix, largestIPNetworkPrefixLength)
+	}
+	return network, nil
+}
+
+// Returns the first and last addresses of an IPv4 network
+func ipv4Range(network *net.IPNet) (uint64, uint64) {
+	first := uint64(binary.BigEndian.Uint32(network.IP.To4()))
+	ones, bits := network.Mask.Size()
+	return first, first + (1 << uint(bits-ones)) - 1
+}
+
+func ipv4FromUint(address uint64) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, uint32(address))
+	return ip
+}
+
+func prefixesOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// IPNetworkOverlap is a pair of IP networks joined to the same IP network exchange whose IP address prefixes overlap.
+// Packets to and from such IP networks are dropped.
+type IPNetworkOverlap struct {
+	// The IP network exchange both IP networks are joined to
+	IPNetworkExchange string
+	// The name of the first IP network
+	IPNetwork string
+	// The IP address prefix of the first IP network
+	IPAddressPrefix string
+	// The name of the other IP network
+	OtherIPNetwork string
+	// The IP address prefix of the other IP network
+	OtherIPAddressPrefix string
+}
+
+func (o IPNetworkOverlap) String() string {
+	return fmt.Sprintf("IP networks %s (%s) and %s (%s) of IP network exchange %s overlap",
+		o.IPNetwork, o.IPAddressPrefix, o.OtherIPNetwork, o.OtherIPAddressPrefix, o.IPNetworkExchange)
+}
+
+// FindIPNetworkOverlaps returns the pairs of IP networks joined to the same IP network exchange whose IP address prefixes overlap.
+// IP networks that aren't joined to an IP network exchange can't overlap.
+func FindIPNetworkOverlaps(networks []IPNetworkInfo) ([]IPNetworkOverlap, error) {
+	sorted := make([]IPNetworkInfo, len(networks))
+	copy(sorted, networks)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].IPNetworkExchange != sorted[j].IPNetworkExchange {
+			return sorted[i].IPNetworkExchange < sorted[j].IPNetworkExchange
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	prefixes := make([]*net.IPNet, len(sorted))
+	for i, network := range sorted {
+		prefix, err := ParseIPAddressPrefix(network.IPAddressPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid IP address prefix of IP network %s: %s", network.Name, err)
+		}
+		prefixes[i] = prefix
+	}
+
+	overlaps := []IPNetworkOverlap{}
+	for i, network := range sorted {
+		if network.IPNetworkExchange == "" {
+			continue
+		}
+		for j := i + 1; j < len(sorted) && sorted[j].IPNetworkExchange == network.IPNetworkExchange; j++ {
+			if prefixesOverlap(prefixes[i], prefixes[j]) {
+				overlaps = append(overlaps, IPNetworkOverlap{
+					IPNetworkExchange:    network.IPNetworkExchange,
+					IPNetwork:            network.Name,
+					IPAddressPrefix:      network.IPAddressPrefix,
+					OtherIPNetwork:       sorted[j].Name,
+					OtherIPAddressPrefix: sorted[j].IPAddressPrefix,
+				})
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+// CheckIPNetworkOverlaps returns the pairs of IP networks of the owner of the client that are joined to the same
+// IP network exchange, and whose IP address prefixes overlap
+func (c *IPNetworksClient) CheckIPNetworkOverlaps() ([]IPNetworkOverlap, error) {
+	networks, err := c.ListIPNetworks()
+	if err != nil {
+		return nil, err
+	}
+	return FindIPNetworkOverlaps(networks)
+}
+
+// ValidateIPNetworkIPAddress checks that a static IP address of an interface on an IP network can be used:
+// it needs to be in the IP address prefix of the IP network, and can't be its network or broadcast address,
+// nor its first address, which is reserved for the default gateway, the DHCP server and the DNS server.
+func ValidateIPNetworkIPAddress(ipAddress string, network *IPNetworkInfo) error {
+	ip := net.ParseIP(ipAddress).To4()
+	if ip == nil {
+		return fmt.Errorf("%q isn't an IPv4 address", ipAddress)
+	}
+	prefix, err := ParseIPAddressPrefix(network.IPAddressPrefix)
+	if err != nil {
+		return fmt.Errorf("Invalid IP address prefix of IP network %s: %s", network.Name, err)
+	}
+	if !prefix.Contains(ip) {
+		return fmt.Errorf("IP address %s isn't in IP network %s (%s)", ipAddress, network.Name, network.IPAddressPrefix)
+	}
+	first, last := ipv4Range(prefix)
+	switch uint64(binary.BigEndian.Uint32(ip)) {
+	case first:
+		return fmt.Errorf("IP address %s is the network address of IP network %s (%s)", ipAddress, network.Name, network.IPAddressPrefix)
+	case first + 1:
+		return fmt.Errorf("IP address %s is reserved for the default gateway of IP network %s (%s)", ipAddress, network.Name, network.IPAddressPrefix)
+	case last:
+		return fmt.Errorf("IP address %s is the broadcast address of IP network %s (%s)", ipAddress, network.Name, network.IPAddressPrefix)
+	}
+	return nil
+}
+
+// ValidateInstanceNetworking checks that the static IP addresses of the interfaces of an instance on IP networks
+// can be used, as per ValidateIPNetworkIPAddress
+func (c *IPNetworksClient) ValidateInstanceNetworking(networking map[string]NetworkingInfo) error {
+	interfaces := make([]string, 0, len(networking))
+	for name := range networking {
+		interfaces = append(interfaces, name)
+	}
+	sort.Strings(interfaces)
+
+	networks := map[string]*IPNetworkInfo{}
+	for _, name := range interfaces {
+		info := networking[name]
+		if info.IPNetwork == "" || info.IPAddress == "" {
+			continue
+		}
+		network, ok := networks[info.IPNetwork]
+		if !ok {
+			var err error
+			if network, err = c.GetIPNetwork(&GetIPNetworkInput{Name: info.IPNetwork}); err != nil {
+				return fmt.Errorf("Error getting IP network %s of interface %s: %s", info.IPNetwork, name, err)
+			}
+			networks[info.IPNetwork] = network
+		}
+		if err := ValidateIPNetworkIPAddress(info.IPAddress, network); err != nil {
+			return fmt.Errorf("Invalid IP address of interface %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// NextFreeIPAddressPrefix returns the first IP address prefix of the given length in the supernet that doesn't
+// overlap any of the used IP address prefixes. For instance, the next free /24 in 10.0.0.0/16 with 10.0.0.0/23
+// in use is 10.0.2.0/24.
+func NextFreeIPAddressPrefix(supernet string, prefixLength int, used []string) (string, error) {
+	super, err := ParseIPAddressPrefix(supernet)
+	if err != nil {
+		return "", err
+	}
+	superOnes, bits := super.Mask.Size()
+	if prefixLength < superOnes || prefixLength > bits {
+		return "", fmt.Errorf("A /%d doesn't fit in supernet %s", prefixLength, supernet)
+	}
+
+	type addressRange struct{ first, last uint64 }
+	usedRanges := []addressRange{}
+	for _, prefix := range used {
+		network, err := ParseIPAddressPrefix(prefix)
+		if err != nil {
+			return "", err
+		}
+		first, last := ipv4Range(network)
+		usedRanges = append(usedRanges, addressRange{first, last})
+	}
+
+	superFirst, superLast := ipv4Range(super)
+	size := uint64(1) << uint(bits-prefixLength)
+	for first := superFirst; first+size-1 <= superLast; {
+		last := first + size - 1
+		next := first
+		for _, r := range usedRanges {
+			if r.first <= last && first <= r.last && r.last+1 > next {
+				next = r.last + 1
+			}
+		}
+		if next == first {
+			return fmt.Sprintf("%s/%d", ipv4FromUint(first), prefixLength), nil
+		}
+		// Align the next candidate to the prefix length
+		first = (next + size - 1) / size * size
+	}
+	return "", fmt.Errorf("No /%d is free in supernet %s", prefixLength, supernet)
+}
+
+// NextFreeIPNetworkPrefix returns the first IP address prefix of the given length in the supernet that doesn't overlap
+// the IP address prefix of any of the IP networks of the owner of the client, to create a new IP network with
+func (c *IPNetworksClient) NextFreeIPNetworkPrefix(supernet string, prefixLength int) (string, error) {
+	if prefixLength < largestIPNetworkPrefixLength {
+		return "", fmt.Errorf("A /%d is larger than the largest IP network, a /%d", prefixLength, largestIPNetworkPrefixLength)
+	}
+	networks, err := c.ListIPNetworks()
+	if err != nil {
+		return "", err
+	}
+	used := make([]string, 0, len(networks))
+	for _, network := range networks {
+		used = append(used, network.IPAddressPrefix)
+	}
+	return NextFreeIPAddressPrefix(supernet, prefixLength, used)
+}
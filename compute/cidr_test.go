@@ -0,0 +1,190 @@
+package compute
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseIPAddressPrefix(t *testing.T) {
+	valid := map[string]string{
+		"192.168.1.0/24":  "192.168.1.0/24",
+		"192.0.0.168/16":  "192.0.0.0/16",
+		"0.0.0.0/0":       "0.0.0.0/0",
+		"203.0.113.10/32": "203.0.113.10/32",
+	}
+	for prefix, expected := range valid {
+		network, err := ParseIPAddressPrefix(prefix)
+		if err != nil {
+			t.Errorf("Expected %q to parse: %s", prefix, err)
+			continue
+		}
+		if network.String() != expected {
+			t.Errorf("Expected %q to parse as %s, got %s", prefix, expected, network)
+		}
+	}
+	for _, prefix := range []string{"", "192.168.1.0", "192.168.1.0/33", "192.168.1/24", "2001:db8::/32"} {
+		if _, err := ParseIPAddressPrefix(prefix); err == nil {
+			t.Errorf("Expected %q not to parse", prefix)
+		}
+	}
+
+	if _, err := ParseIPNetworkPrefix("10.0.0.0/16"); err != nil {
+		t.Errorf("Expected a /16 IP network prefix to parse: %s", err)
+	}
+	if _, err := ParseIPNetworkPrefix("10.0.0.0/15"); err == nil {
+		t.Errorf("Expected a /15 IP network prefix not to parse")
+	}
+}
+
+// Test that invalid IP address prefixes are rejected without calling the API
+func TestIPAddressPrefixValidation(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.IPNetworks().CreateIPNetwork(&CreateIPNetworkInput{Name: "private", IPAddressPrefix: "10.0.0.0/8"}); err == nil {
+		t.Errorf("Expected an error creating an IP network larger than a /16")
+	}
+	if _, err := client.IPNetworks().UpdateIPNetwork(&UpdateIPNetworkInput{Name: "private", IPAddressPrefix: "10.0.0.0"}); err == nil {
+		t.Errorf("Expected an error updating an IP network without a prefix length")
+	}
+	if _, err := client.IPAddressPrefixSets().CreateIPAddressPrefixSet(&CreateIPAddressPrefixSetInput{Name: "office", IPAddressPrefixes: []string{"203.0.113.0/24", "office"}}); err == nil {
+		t.Errorf("Expected an error creating an IP address prefix set with an invalid prefix")
+	}
+	if _, err := client.Routes().CreateRoute(&CreateRouteInput{Name: "default", IPAddressPrefix: "0.0.0.0/-1", NextHopVnicSet: "nat"}); err == nil {
+		t.Errorf("Expected an error creating a route with an invalid prefix")
+	}
+}
+
+func TestFindIPNetworkOverlaps(t *testing.T) {
+	networks := []IPNetworkInfo{
+		{Name: "web", IPAddressPrefix: "10.0.1.0/24", IPNetworkExchange: "production"},
+		{Name: "all", IPAddressPrefix: "10.0.0.0/16", IPNetworkExchange: "production"},
+		{Name: "db", IPAddressPrefix: "10.0.2.0/24", IPNetworkExchange: "production"},
+		{Name: "staging-web", IPAddressPrefix: "10.0.1.0/24", IPNetworkExchange: "staging"},
+		{Name: "isolated", IPAddressPrefix: "10.0.1.0/24"},
+	}
+	overlaps, err := FindIPNetworkOverlaps(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []IPNetworkOverlap{
+		{IPNetworkExchange: "production", IPNetwork: "all", IPAddressPrefix: "10.0.0.0/16", OtherIPNetwork: "db", OtherIPAddressPrefix: "10.0.2.0/24"},
+		{IPNetworkExchange: "production", IPNetwork: "all", IPAddressPrefix: "10.0.0.0/16", OtherIPNetwork: "web", OtherIPAddressPrefix: "10.0.1.0/24"},
+	}
+	if diff := pretty.Compare(overlaps, expected); diff != "" {
+		t.Errorf("Overlaps Diff: (-got +want)\n%s", diff)
+	}
+
+	if _, err := FindIPNetworkOverlaps([]IPNetworkInfo{{Name: "broken", IPAddressPrefix: "10.0.0.0"}}); err == nil {
+		t.Errorf("Expected an error for an invalid IP address prefix")
+	}
+}
+
+func TestValidateIPNetworkIPAddress(t *testing.T) {
+	network := &IPNetworkInfo{Name: "private", IPAddressPrefix: "192.168.1.0/24"}
+	for _, ip := range []string{"192.168.1.2", "192.168.1.254"} {
+		if err := ValidateIPNetworkIPAddress(ip, network); err != nil {
+			t.Errorf("Expected %s to be valid: %s", ip, err)
+		}
+	}
+	for _, ip := range []string{"192.168.2.2", "192.168.1.0", "192.168.1.1", "192.168.1.255", "not an address"} {
+		if err := ValidateIPNetworkIPAddress(ip, network); err == nil {
+			t.Errorf("Expected %s to be invalid", ip)
+		}
+	}
+}
+
+func TestIPNetworksClient_ValidateInstanceNetworking(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/network/v1/ipnetwork/Compute-test/test/private" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name": "/Compute-test/test/private", "ipAddressPrefix": "192.168.1.0/24"}`))
+	})
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := map[string]NetworkingInfo{
+		"eth0": {SecLists: []string{"default"}},
+		"eth1": {IPNetwork: "private", IPAddress: "192.168.1.10"},
+		"eth2": {IPNetwork: "private"},
+	}
+	if err := client.IPNetworks().ValidateInstanceNetworking(valid); err != nil {
+		t.Errorf("Expected valid networking: %s", err)
+	}
+	invalid := map[string]NetworkingInfo{
+		"eth0": {IPNetwork: "private", IPAddress: "192.168.2.10"},
+	}
+	if err := client.IPNetworks().ValidateInstanceNetworking(invalid); err == nil {
+		t.Errorf("Expected an error for an IP address outside of its IP network")
+	}
+}
+
+func TestNextFreeIPAddressPrefix(t *testing.T) {
+	cases := []struct {
+		supernet     string
+		prefixLength int
+		used         []string
+		expected     string
+	}{
+		{"10.0.0.0/16", 24, nil, "10.0.0.0/24"},
+		{"10.0.0.0/16", 24, []string{"10.0.0.0/23"}, "10.0.2.0/24"},
+		{"10.0.0.0/16", 24, []string{"10.0.0.0/24", "10.0.1.128/25", "10.0.3.0/24"}, "10.0.2.0/24"},
+		{"10.0.0.0/16", 20, []string{"10.0.0.5/32"}, "10.0.16.0/20"},
+		{"10.0.0.0/16", 24, []string{"192.168.0.0/16"}, "10.0.0.0/24"},
+		{"10.0.0.0/30", 30, nil, "10.0.0.0/30"},
+		{"255.255.255.0/24", 25, []string{"255.255.255.0/25"}, "255.255.255.128/25"},
+	}
+	for _, c := range cases {
+		prefix, err := NextFreeIPAddressPrefix(c.supernet, c.prefixLength, c.used)
+		if err != nil {
+			t.Errorf("Next free /%d in %s with %v: %s", c.prefixLength, c.supernet, c.used, err)
+			continue
+		}
+		if prefix != c.expected {
+			t.Errorf("Expected the next free /%d in %s with %v to be %s, got %s", c.prefixLength, c.supernet, c.used, c.expected, prefix)
+		}
+	}
+
+	full := []struct {
+		supernet     string
+		prefixLength int
+		used         []string
+	}{
+		{"10.0.0.0/24", 25, []string{"10.0.0.0/25", "10.0.0.200/32"}},
+		{"10.0.0.0/24", 25, []string{"10.0.0.0/8"}},
+		{"255.255.255.0/24", 24, []string{"255.255.255.255/32"}},
+		{"10.0.0.0/24", 16, nil},
+		{"10.0.0.0/24", 33, nil},
+	}
+	for _, c := range full {
+		if prefix, err := NextFreeIPAddressPrefix(c.supernet, c.prefixLength, c.used); err == nil {
+			t.Errorf("Expected no free /%d in %s with %v, got %s", c.prefixLength, c.supernet, c.used, prefix)
+		}
+	}
+}
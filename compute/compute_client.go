@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
@@ -15,16 +15,31 @@ const cmpACME = "/Compute-%s"
 const cmpUsername = "/Compute-%s/%s"
 const cmpQualifiedName = "%s/%s"
 
+// AllOwners is the owner of a client that lists the objects of all users in the identity domain
+const AllOwners = "*"
+
 // Client represents an authenticated compute client, with compute credentials and an api client.
 type Client struct {
-	client       *client.Client
-	authCookie   *http.Cookie
-	cookieIssued time.Time
+	client *client.Client
+	// The user that owns the objects with unqualified names, if not the authenticated user
+	owner string
+	// Shared by the clients of all owners, as they authenticate as the same user
+	auth *authentication
+}
+
+// The authentication cookie of a client
+type authentication struct {
+	cookie *http.Cookie
+	issued time.Time
+	// Guards the authentication cookie, as a client may be used from several goroutines
+	lock sync.Mutex
 }
 
 // NewComputeClient returns a compute client to interact with the Oracle Compute Infrastructure - Classic APIs
 func NewComputeClient(c *opc.Config) (*Client, error) {
-	computeClient := &Client{}
+	computeClient := &Client{
+		auth: &authentication{},
+	}
 	client, err := client.NewClient(c)
 	if err != nil {
 		return nil, err
@@ -60,14 +75,14 @@ func (c *Client) executeRequest(method, path string, body interface{}) (*http.Re
 	// Log the request before the authentication cookie, so as not to leak credentials
 	c.client.DebugLogString(debugReqString)
 	// If we have an authentication cookie, let's authenticate, refreshing cookie if need be
-	if c.authCookie != nil {
-		if time.Since(c.cookieIssued).Minutes() > 25 {
-			c.authCookie = nil
-			if err = c.getAuthenticationCookie(); err != nil {
-				return nil, err
-			}
+	if path != "/authenticate/" {
+		authCookie, err := c.currentAuthenticationCookie()
+		if err != nil {
+			return nil, err
+		}
+		if authCookie != nil {
+			req.AddCookie(authCookie)
 		}
-		req.AddCookie(c.authCookie)
 	}
 
 	resp, err := c.client.ExecuteRequest(req)
@@ -81,50 +96,72 @@ func (c *Client) getACME() string {
 	return fmt.Sprintf(cmpACME, *c.client.IdentityDomain)
 }
 
+// WithOwner returns a client that acts on the objects of another user in the identity domain.
+// Unqualified names are qualified with the owner, rather than the authenticated user, and names
+// of the owner's objects are returned unqualified. The List functions of the resource clients,
+// such as ListInstances, return the objects of the owner.
+// With AllOwners, unqualified names are those of the authenticated user, and the List functions
+// return the objects of all users in the identity domain, as they list the container of the domain.
+// The returned client shares the authentication of this client.
+func (c *Client) WithOwner(owner string) *Client {
+	return &Client{
+		client: c.client,
+		owner:  owner,
+		auth:   c.auth,
+	}
+}
+
+// Owner returns the user that owns the objects with unqualified names
+func (c *Client) Owner() string {
+	if c.owner == "" || c.owner == AllOwners {
+		return *c.client.UserName
+	}
+	return c.owner
+}
+
+// Returns the container of the owner, such as /Compute-mydomain/jane@example.com
 func (c *Client) getUserName() string {
-	return fmt.Sprintf(cmpUsername, *c.client.IdentityDomain, *c.client.UserName)
+	return fmt.Sprintf(cmpUsername, *c.client.IdentityDomain, c.Owner())
 }
 
-func (c *Client) getQualifiedACMEName(name string) string {
-	if name == "" {
-		return ""
+// Returns the container the List functions list the objects of, see WithOwner
+func (c *Client) getListContainer() string {
+	if c.owner == AllOwners {
+		return fmt.Sprintf("%s/", c.getACME())
 	}
-	if strings.HasPrefix(name, "/Compute-") && len(strings.Split(name, "/")) == 1 {
+	return fmt.Sprintf("%s/", c.getUserName())
+}
+
+func (c *Client) getQualifiedACMEName(name string) string {
+	objectName, err := ParseObjectName(name)
+	if err != nil || objectName.IsQualified() {
 		return name
 	}
-	return fmt.Sprintf(cmpQualifiedName, c.getACME(), name)
+	return objectName.Qualify(*c.client.IdentityDomain, "").String()
 }
 
 // From compute_client
 // GetObjectName returns the fully-qualified name of an OPC object, e.g. /identity-domain/user@email/{name}
 func (c *Client) getQualifiedName(name string) string {
-	if name == "" {
-		return ""
-	}
-	if strings.HasPrefix(name, "/oracle") || strings.HasPrefix(name, "/Compute-") {
+	objectName, err := ParseObjectName(name)
+	if err != nil {
 		return name
 	}
-	return fmt.Sprintf(cmpQualifiedName, c.getUserName(), name)
+	return objectName.Qualify(*c.client.IdentityDomain, c.Owner()).String()
 }
 
 func (c *Client) getObjectPath(root, name string) string {
 	return fmt.Sprintf("%s%s", root, c.getQualifiedName(name))
 }
 
-// GetUnqualifiedName returns the unqualified name of an OPC object, e.g. the {name} part of /identity-domain/user@email/{name}
+// GetUnqualifiedName returns the unqualified name of an OPC object, e.g. the {name} part of /identity-domain/user@email/{name}.
+// The names of objects owned by users other than the owner of the client stay qualified.
 func (c *Client) getUnqualifiedName(name string) string {
-	if name == "" {
-		return name
-	}
-	if strings.HasPrefix(name, "/oracle") {
-		return name
-	}
-	if !strings.Contains(name, "/") {
+	objectName, err := ParseObjectName(name)
+	if err != nil {
 		return name
 	}
-
-	nameParts := strings.Split(name, "/")
-	return strings.Join(nameParts[3:], "/")
+	return objectName.Unqualify(*c.client.IdentityDomain, c.Owner()).String()
 }
 
 func (c *Client) unqualify(names ...*string) {
@@ -133,9 +170,11 @@ func (c *Client) unqualify(names ...*string) {
 	}
 }
 
+// Matches the qualified name of the object in a URL, such as /Compute-mydomain/jane@example.com/web in https://api.compute.example.com/imagelist/Compute-mydomain/jane@example.com/web/entry/1
+var objectNameInURL = regexp.MustCompile(`(\/(Compute[^\/\s]+))(\/[^\/\s]+)(\/[^\/\s]+)`)
+
 func (c *Client) unqualifyURL(url *string) {
-	var validID = regexp.MustCompile(`(\/(Compute[^\/\s]+))(\/[^\/\s]+)(\/[^\/\s]+)`)
-	name := validID.FindString(*url)
+	name := objectNameInURL.FindString(*url)
 	*url = c.getUnqualifiedName(name)
 }
 
@@ -153,16 +192,12 @@ func (c *Client) getUnqualifiedList(list []string) []string {
 	return list
 }
 
+// Qualifies a list name prefixed with its type, such as seclist:name
 func (c *Client) getQualifiedListName(name string) string {
-	nameParts := strings.Split(name, ":")
-	listType := nameParts[0]
-	listName := nameParts[1]
-	return fmt.Sprintf("%s:%s", listType, c.getQualifiedName(listName))
+	return c.getQualifiedName(name)
 }
 
+// Unqualifies a list name prefixed with its type, such as seclist:/Compute-mydomain/jane@example.com/name
 func (c *Client) unqualifyListName(qualifiedName string) string {
-	nameParts := strings.Split(qualifiedName, ":")
-	listType := nameParts[0]
-	listName := nameParts[1]
-	return fmt.Sprintf("%s:%s", listType, c.getUnqualifiedName(listName))
+	return c.getUnqualifiedName(qualifiedName)
 }
@@ -34,3 +34,75 @@ func TestClient_qualifyList(t *testing.T) {
 		t.Fatalf("Qualified List Diff: (-got +want)\n%s", diff)
 	}
 }
+
+func TestClient_nameHelpers(t *testing.T) {
+	client, server, err := getBlankTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	qualified := map[string]string{
+		"":                                      "",
+		"web":                                   "/Compute-test-domain/test-user/web",
+		"web/id":                                "/Compute-test-domain/test-user/web/id",
+		"/Compute-test-domain/other-user/web":   "/Compute-test-domain/other-user/web",
+		"/oracle/public/OL_7.2_UEKR4_x86_64":    "/oracle/public/OL_7.2_UEKR4_x86_64",
+		"/Compute-test-domain/test-user/web/id": "/Compute-test-domain/test-user/web/id",
+	}
+	for name, expected := range qualified {
+		if result := client.getQualifiedName(name); result != expected {
+			t.Errorf("Expected %q to qualify as %q, got %q", name, expected, result)
+		}
+	}
+
+	unqualified := map[string]string{
+		"":                                      "",
+		"web":                                   "web",
+		"web/id":                                "web/id",
+		"/Compute-test-domain/test-user/web":    "web",
+		"/Compute-test-domain/test-user/web/id": "web/id",
+		"/Compute-test-domain/other-user/web":   "/Compute-test-domain/other-user/web",
+		"/Compute-test-domain/default":          "/Compute-test-domain/default",
+		"/oracle/public/OL_7.2_UEKR4_x86_64":    "/oracle/public/OL_7.2_UEKR4_x86_64",
+	}
+	for name, expected := range unqualified {
+		if result := client.getUnqualifiedName(name); result != expected {
+			t.Errorf("Expected %q to unqualify as %q, got %q", name, expected, result)
+		}
+	}
+
+	acme := map[string]string{
+		"":                              "",
+		"default":                       "/Compute-test-domain/default",
+		"/Compute-test-domain/default":  "/Compute-test-domain/default",
+		"/Compute-other-domain/default": "/Compute-other-domain/default",
+		"/oracle/public/cloud_storage":  "/oracle/public/cloud_storage",
+	}
+	for name, expected := range acme {
+		if result := client.getQualifiedACMEName(name); result != expected {
+			t.Errorf("Expected %q to qualify for the domain as %q, got %q", name, expected, result)
+		}
+	}
+
+	lists := map[string]string{
+		"seclist:web": "seclist:/Compute-test-domain/test-user/web",
+		"seciplist:/oracle/public/public-internet":    "seciplist:/oracle/public/public-internet",
+		"seclist:/Compute-test-domain/other-user/web": "seclist:/Compute-test-domain/other-user/web",
+	}
+	for name, expected := range lists {
+		result := client.getQualifiedListName(name)
+		if result != expected {
+			t.Errorf("Expected %q to qualify as %q, got %q", name, expected, result)
+		}
+		if unqualified := client.unqualifyListName(result); unqualified != name {
+			t.Errorf("Expected %q to unqualify as %q, got %q", result, name, unqualified)
+		}
+	}
+
+	uri := "https://api.compute.example.com/imagelist/Compute-test-domain/test-user/web-image/entry/1"
+	client.unqualifyURL(&uri)
+	if uri != "web-image" {
+		t.Errorf("Expected the image list name web-image, got %q", uri)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/hashicorp/go-oracle-terraform/opc"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -94,6 +95,7 @@ func (c *ResourceClient) unmarshalResponseBody(resp *http.Response, iface interf
 		WeaklyTypedInput: true,
 		Result:           iface,
 		TagName:          "json",
+		DecodeHook:       opc.DecodeHook,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,912 @@
+package compute
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Firewall rules declare the sec rules of the shared network, or the security rules of IP networks, one rule per line:
+//
+//	allow tcp/443 from seciplist:office to seclist:web
+//	allow tcp/22,icmp/echo from 203.0.113.0/24 to seclist:admin disabled
+//	allow tcp/8000-8100 from vnicset:web to 10.0.1.0/24 egress acl:backend
+//
+// Services are a protocol, optionally followed by a port or port range, or by an ICMP type, which is a name with an
+// optional code, such as icmp/unreachable/port, on the shared network and a number on IP networks. Services can also
+// be existing security applications or security protocols, such as secapplication:/oracle/public/ssh.
+// Sources and destinations are security lists and security IP lists on the shared network, and vNIC sets and
+// IP address prefix sets on IP networks, or IP addresses and IP address prefixes, from which security IP lists
+// and IP address prefix sets are compiled. On IP networks, any is any vNIC and IP address.
+// Rules are IP network rules if they refer to vNIC sets, IP address prefix sets or security protocols, or have
+// a flow direction or an ACL. The flow direction defaults to ingress into a destination vNIC set, or else egress
+// out of a source vNIC set. Text after # is a comment.
+
+// Types of the objects firewall rules refer to, which prefix their names, such as vnicset:web
+const (
+	FirewallReferenceSecurityApplication = "secapplication"
+	FirewallReferenceSecurityProtocol    = "secprotocol"
+	FirewallReferenceVirtualNICSet       = "vnicset"
+	FirewallReferenceIPAddressPrefixSet  = "ipprefixset"
+)
+
+// DefaultFirewallPrefix prefixes the names of the objects firewall rules compile to, unless another prefix is given
+const DefaultFirewallPrefix = "fw"
+
+const (
+	firewallActionAllow = "allow"
+	firewallAny         = "any"
+	firewallDisabled    = "disabled"
+	firewallACLPrefix   = "acl:"
+	secRuleActionPermit = "PERMIT"
+)
+
+var firewallReferenceTypes = []string{
+	SecurityListType,
+	SecurityIPListType,
+	FirewallReferenceSecurityApplication,
+	FirewallReferenceSecurityProtocol,
+	FirewallReferenceVirtualNICSet,
+	FirewallReferenceIPAddressPrefixSet,
+}
+
+var firewallProtocols = []SecurityApplicationProtocol{All, AH, ESP, ICMP, ICMPV6, IGMP, IPIP, GRE, MPLSIP, OSPF, PIM, RDP, SCTP, TCP, UDP}
+
+var firewallICMPTypes = []SecurityApplicationICMPType{Echo, Reply, TTL, TraceRoute, Unreachable}
+
+var firewallICMPCodes = []SecurityApplicationICMPCode{Admin, Df, Host, Network, Port, Protocol}
+
+// FirewallReference refers to an existing object in a firewall rule, such as seclist:web
+type FirewallReference struct {
+	// The type of the object, such as seclist or vnicset
+	Type string
+	// The name of the object
+	Name string
+}
+
+func (r FirewallReference) String() string {
+	return fmt.Sprintf("%s:%s", r.Type, r.Name)
+}
+
+func parseFirewallReference(s string) (FirewallReference, bool) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return FirewallReference{}, false
+	}
+	for _, referenceType := range firewallReferenceTypes {
+		if strings.ToLower(parts[0]) == referenceType {
+			return FirewallReference{Type: referenceType, Name: parts[1]}, true
+		}
+	}
+	return FirewallReference{}, false
+}
+
+// FirewallService is the protocol and ports a firewall rule allows
+type FirewallService struct {
+	// An existing security application or security protocol. If set, the protocol and ports are empty.
+	Reference FirewallReference
+	// The protocol, such as tcp, or all
+	Protocol string
+	// The port or port range for tcp, udp and sctp, or the ICMP type for icmp and icmpv6. All if empty.
+	Ports string
+}
+
+func (s FirewallService) String() string {
+	switch {
+	case s.Reference.Type != "":
+		return s.Reference.String()
+	case s.Ports != "":
+		return fmt.Sprintf("%s/%s", s.Protocol, s.Ports)
+	}
+	return s.Protocol
+}
+
+func parseFirewallService(s string) (FirewallService, error) {
+	if reference, ok := parseFirewallReference(s); ok {
+		if reference.Type != FirewallReferenceSecurityApplication && reference.Type != FirewallReferenceSecurityProtocol {
+			return FirewallService{}, fmt.Errorf("%s isn't a security application or security protocol", s)
+		}
+		return FirewallService{Reference: reference}, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	service := FirewallService{Protocol: strings.ToLower(parts[0])}
+	if len(parts) == 2 {
+		if parts[1] == "" {
+			return FirewallService{}, fmt.Errorf("Service %s has no ports after the /", s)
+		}
+		service.Ports = strings.ToLower(parts[1])
+	}
+	for _, protocol := range firewallProtocols {
+		if service.Protocol == string(protocol) {
+			return service, nil
+		}
+	}
+	return FirewallService{}, fmt.Errorf("Unknown protocol %q", parts[0])
+}
+
+// FirewallEndpoint is the source or destination of a firewall rule
+type FirewallEndpoint struct {
+	// Existing security lists, security IP lists, vNIC sets or IP address prefix sets
+	References []FirewallReference
+	// IP addresses and IP address prefixes, such as 203.0.113.0/24
+	IPAddressPrefixes []string
+}
+
+// IsAny returns whether the endpoint is any vNIC and IP address
+func (e FirewallEndpoint) IsAny() bool {
+	return len(e.References) == 0 && len(e.IPAddressPrefixes) == 0
+}
+
+func (e FirewallEndpoint) String() string {
+	if e.IsAny() {
+		return firewallAny
+	}
+	items := []string{}
+	for _, reference := range e.References {
+		items = append(items, reference.String())
+	}
+	return strings.Join(append(items, e.IPAddressPrefixes...), ",")
+}
+
+// Returns the names of the objects of the type the endpoint refers to
+func (e FirewallEndpoint) references(referenceType string) []string {
+	names := []string{}
+	for _, reference := range e.References {
+		if reference.Type == referenceType {
+			names = append(names, reference.Name)
+		}
+	}
+	return names
+}
+
+func parseFirewallEndpoint(s string) (FirewallEndpoint, error) {
+	endpoint := FirewallEndpoint{}
+	if strings.ToLower(s) == firewallAny {
+		return endpoint, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		if reference, ok := parseFirewallReference(item); ok {
+			if reference.Type == FirewallReferenceSecurityApplication || reference.Type == FirewallReferenceSecurityProtocol {
+				return endpoint, fmt.Errorf("%s can't be a source or destination", item)
+			}
+			endpoint.References = append(endpoint.References, reference)
+			continue
+		}
+		prefix, err := normalizeFirewallIPAddressPrefix(item)
+		if err != nil {
+			return endpoint, err
+		}
+		endpoint.IPAddressPrefixes = append(endpoint.IPAddressPrefixes, prefix)
+	}
+	sort.Slice(endpoint.References, func(i, j int) bool {
+		return endpoint.References[i].String() < endpoint.References[j].String()
+	})
+	endpoint.IPAddressPrefixes = uniqueSortedStrings(endpoint.IPAddressPrefixes)
+	return endpoint, nil
+}
+
+// Returns an IP address, or an IP address prefix without host bits
+func normalizeFirewallIPAddressPrefix(s string) (string, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := ParseIPAddressPrefix(s)
+		if err != nil {
+			return "", err
+		}
+		return prefix.String(), nil
+	}
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return "", fmt.Errorf("%q isn't a list, an IPv4 address or an IP address prefix", s)
+	}
+	return ip.String(), nil
+}
+
+// FirewallRule is a rule that allows services from a source to a destination
+type FirewallRule struct {
+	// The services the rule allows
+	Services []FirewallService
+	// The source of the packets
+	Source FirewallEndpoint
+	// The destination of the packets
+	Destination FirewallEndpoint
+	// The flow direction of a rule of IP networks, ingress or egress. Empty for the default.
+	FlowDirection string
+	// The ACL of a rule of IP networks
+	ACL string
+	// Whether the rule is disabled
+	Disabled bool
+}
+
+// String formats the rule in the firewall rule DSL
+func (r FirewallRule) String() string {
+	services := []string{}
+	for _, service := range r.Services {
+		services = append(services, service.String())
+	}
+	parts := []string{firewallActionAllow, strings.Join(services, ","), "from", r.Source.String(), "to", r.Destination.String()}
+	if r.FlowDirection != "" {
+		parts = append(parts, r.FlowDirection)
+	}
+	if r.ACL != "" {
+		parts = append(parts, firewallACLPrefix+r.ACL)
+	}
+	if r.Disabled {
+		parts = append(parts, firewallDisabled)
+	}
+	return strings.Join(parts, " ")
+}
+
+// IPNetwork returns whether the rule is a rule of IP networks, rather than of the shared network
+func (r FirewallRule) IPNetwork() bool {
+	if r.FlowDirection != "" || r.ACL != "" {
+		return true
+	}
+	for _, service := range r.Services {
+		if service.Reference.Type == FirewallReferenceSecurityProtocol {
+			return true
+		}
+	}
+	for _, reference := range append(append([]FirewallReference{}, r.Source.References...), r.Destination.References...) {
+		if reference.Type == FirewallReferenceVirtualNICSet || reference.Type == FirewallReferenceIPAddressPrefixSet {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the flow direction of a rule of IP networks: the given one, or else ingress into a destination vNIC set,
+// or else egress out of a source vNIC set
+func (r FirewallRule) flowDirection() string {
+	switch {
+	case r.FlowDirection != "":
+		return r.FlowDirection
+	case len(r.Destination.references(FirewallReferenceVirtualNICSet)) > 0:
+		return SecurityRuleFlowDirectionIngress
+	case len(r.Source.references(FirewallReferenceVirtualNICSet)) > 0:
+		return SecurityRuleFlowDirectionEgress
+	}
+	return ""
+}
+
+// Separators of lists, which can have spaces around them
+var firewallListSeparator = regexp.MustCompile(`\s*,\s*`)
+
+// ParseFirewallRule parses a rule in the firewall rule DSL, such as allow tcp/443 from seciplist:office to seclist:web
+func ParseFirewallRule(s string) (FirewallRule, error) {
+	var rule FirewallRule
+	fields := strings.Fields(firewallListSeparator.ReplaceAllString(strings.TrimSpace(s), ","))
+	if len(fields) < 6 || strings.ToLower(fields[0]) != firewallActionAllow ||
+		strings.ToLower(fields[2]) != "from" || strings.ToLower(fields[4]) != "to" {
+		return rule, fmt.Errorf("Expected allow <services> from <source> to <destination> [options], got %q", s)
+	}
+
+	for _, item := range strings.Split(fields[1], ",") {
+		service, err := parseFirewallService(item)
+		if err != nil {
+			return rule, err
+		}
+		rule.Services = append(rule.Services, service)
+	}
+	sort.SliceStable(rule.Services, func(i, j int) bool {
+		return rule.Services[i].String() < rule.Services[j].String()
+	})
+
+	var err error
+	if rule.Source, err = parseFirewallEndpoint(fields[3]); err != nil {
+		return rule, err
+	}
+	if rule.Destination, err = parseFirewallEndpoint(fields[5]); err != nil {
+		return rule, err
+	}
+
+	for _, option := range fields[6:] {
+		switch lower := strings.ToLower(option); {
+		case lower == firewallDisabled:
+			rule.Disabled = true
+		case lower == SecurityRuleFlowDirectionIngress, lower == SecurityRuleFlowDirectionEgress:
+			rule.FlowDirection = lower
+		case strings.HasPrefix(lower, firewallACLPrefix) && len(option) > len(firewallACLPrefix):
+			rule.ACL = option[len(firewallACLPrefix):]
+		default:
+			return rule, fmt.Errorf("Unknown option %q, expected disabled, ingress, egress or acl:<name>", option)
+		}
+	}
+	return rule, nil
+}
+
+// ParseFirewallRules parses firewall rules, one per line. Blank lines and text after # are ignored.
+func ParseFirewallRules(text string) ([]FirewallRule, error) {
+	rules := []FirewallRule{}
+	for i, line := range strings.Split(text, "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rule, err := ParseFirewallRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid firewall rule on line %d: %s", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// FormatFirewallRules formats firewall rules in the firewall rule DSL, one per line
+func FormatFirewallRules(rules []FirewallRule) string {
+	var buf strings.Builder
+	for _, rule := range rules {
+		buf.WriteString(rule.String())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// FirewallObjects are the objects firewall rules compile to, sorted by name. Security applications, security IP lists,
+// security protocols and IP address prefix sets are named after their contents, and rules after what they allow,
+// so the same rules always compile to the same names, and objects are shared between the rules that need them.
+type FirewallObjects struct {
+	// The security applications of the services of the shared network rules
+	SecurityApplications []CreateSecurityApplicationInput
+	// The security IP lists of the IP addresses of the shared network rules
+	SecurityIPLists []CreateSecurityIPListInput
+	// The sec rules of the shared network rules, one per security application
+	SecRules []CreateSecRuleInput
+	// The security protocols of the services of the IP network rules
+	SecurityProtocols []CreateSecurityProtocolInput
+	// The IP address prefix sets of the IP addresses of the IP network rules
+	IPAddressPrefixSets []CreateIPAddressPrefixSetInput
+	// The security rules of the IP network rules
+	SecurityRules []CreateSecurityRuleInput
+}
+
+// Collects the compiled objects by name, to share them between rules
+type firewallCompiler struct {
+	prefix        string
+	applications  map[string]CreateSecurityApplicationInput
+	ipLists       map[string]CreateSecurityIPListInput
+	secRules      map[string]CreateSecRuleInput
+	protocols     map[string]CreateSecurityProtocolInput
+	prefixSets    map[string]CreateIPAddressPrefixSetInput
+	securityRules map[string]CreateSecurityRuleInput
+}
+
+func newFirewallCompiler(prefix string) *firewallCompiler {
+	if prefix == "" {
+		prefix = DefaultFirewallPrefix
+	}
+	return &firewallCompiler{
+		prefix:        prefix,
+		applications:  map[string]CreateSecurityApplicationInput{},
+		ipLists:       map[string]CreateSecurityIPListInput{},
+		secRules:      map[string]CreateSecRuleInput{},
+		protocols:     map[string]CreateSecurityProtocolInput{},
+		prefixSets:    map[string]CreateIPAddressPrefixSetInput{},
+		securityRules: map[string]CreateSecurityRuleInput{},
+	}
+}
+
+// CompileFirewallRules compiles firewall rules to the objects that implement them, with names starting with
+// the prefix and a dash, or with DefaultFirewallPrefix if the prefix is empty
+func CompileFirewallRules(rules []FirewallRule, prefix string) (*FirewallObjects, error) {
+	c := newFirewallCompiler(prefix)
+	for _, rule := range rules {
+		var err error
+		if rule.IPNetwork() {
+			err = c.compileSecurityRule(rule)
+		} else {
+			err = c.compileSecRules(rule)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling firewall rule %q: %s", rule, err)
+		}
+	}
+	return c.objects(), nil
+}
+
+func (c *firewallCompiler) objects() *FirewallObjects {
+	objects := &FirewallObjects{}
+	for _, name := range sortedKeys(c.applications) {
+		objects.SecurityApplications = append(objects.SecurityApplications, c.applications[name])
+	}
+	for _, name := range sortedKeys(c.ipLists) {
+		objects.SecurityIPLists = append(objects.SecurityIPLists, c.ipLists[name])
+	}
+	for _, name := range sortedKeys(c.secRules) {
+		objects.SecRules = append(objects.SecRules, c.secRules[name])
+	}
+	for _, name := range sortedKeys(c.protocols) {
+		objects.SecurityProtocols = append(objects.SecurityProtocols, c.protocols[name])
+	}
+	for _, name := range sortedKeys(c.prefixSets) {
+		objects.IPAddressPrefixSets = append(objects.IPAddressPrefixSets, c.prefixSets[name])
+	}
+	for _, name := range sortedKeys(c.securityRules) {
+		objects.SecurityRules = append(objects.SecurityRules, c.securityRules[name])
+	}
+	return objects
+}
+
+// Returns the sorted keys of a map with string keys
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the name of an object, from the prefix and the given parts
+func (c *firewallCompiler) name(parts ...string) string {
+	return strings.Join(append([]string{c.prefix}, parts...), "-")
+}
+
+// Returns the name of an object named after its contents, which don't fit in a name
+func (c *firewallCompiler) hashedName(kind string, contents ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(contents, "\n")))
+	return c.name(kind, hex.EncodeToString(sum[:])[:8])
+}
+
+// Compiles the services of a rule of the shared network to the names of security applications. The ports of the
+// tcp and udp services are merged, so tcp/80,tcp/81 needs a single security application of ports 80-81.
+func (c *firewallCompiler) compileSecurityApplications(services []FirewallService) ([]string, error) {
+	names := []string{}
+	ports := map[SecurityApplicationProtocol]PortSet{}
+	for _, service := range services {
+		if service.Reference.Type != "" {
+			if service.Reference.Type != FirewallReferenceSecurityApplication {
+				return nil, fmt.Errorf("Rules of the shared network can't use %s", service.Reference)
+			}
+			names = append(names, service.Reference.Name)
+			continue
+		}
+
+		protocol := SecurityApplicationProtocol(service.Protocol)
+		application := CreateSecurityApplicationInput{Protocol: protocol}
+		switch protocol {
+		case TCP, UDP:
+			set := AllPorts
+			if service.Ports != "" {
+				r, err := ParsePortRange(service.Ports)
+				if err != nil {
+					return nil, err
+				}
+				set = NewPortSet(r)
+			}
+			ports[protocol] = ports[protocol].Union(set)
+			continue
+		case ICMP:
+			application.Name = c.name(string(ICMP))
+			if service.Ports != "" {
+				icmpType, icmpCode, err := parseFirewallICMPType(service.Ports)
+				if err != nil {
+					return nil, err
+				}
+				application.ICMPType, application.ICMPCode = icmpType, icmpCode
+				application.Name = c.name(strings.Split(service.String(), "/")...)
+			}
+		default:
+			if service.Ports != "" {
+				return nil, fmt.Errorf("Service %s can't have ports", service)
+			}
+			application.Name = c.name(service.Protocol)
+		}
+		c.applications[application.Name] = application
+		names = append(names, application.Name)
+	}
+
+	for _, protocol := range []SecurityApplicationProtocol{TCP, UDP} {
+		for _, r := range ports[protocol] {
+			application := CreateSecurityApplicationInput{Name: c.name(string(protocol), r.String()), Protocol: protocol, DPort: r.String()}
+			c.applications[application.Name] = application
+			names = append(names, application.Name)
+		}
+	}
+	return uniqueSortedStrings(names), nil
+}
+
+// Parses the ICMP type and optional code of a service of the shared network, such as unreachable/port
+func parseFirewallICMPType(s string) (SecurityApplicationICMPType, SecurityApplicationICMPCode, error) {
+	parts := strings.SplitN(s, "/", 2)
+	var icmpType SecurityApplicationICMPType
+	for _, t := range firewallICMPTypes {
+		if parts[0] == string(t) {
+			icmpType = t
+		}
+	}
+	if icmpType == "" {
+		return "", "", fmt.Errorf("Unknown ICMP type %q", parts[0])
+	}
+	if len(parts) == 1 {
+		return icmpType, "", nil
+	}
+	for _, code := range firewallICMPCodes {
+		if parts[1] == string(code) {
+			return icmpType, code, nil
+		}
+	}
+	return "", "", fmt.Errorf("Unknown ICMP code %q", parts[1])
+}
+
+// Compiles the source or destination of a rule of the shared network to a security list or security IP list
+func (c *firewallCompiler) compileSecurityList(endpoint FirewallEndpoint) (string, error) {
+	switch {
+	case len(endpoint.References) == 1 && len(endpoint.IPAddressPrefixes) == 0:
+		reference := endpoint.References[0]
+		if reference.Type != SecurityListType && reference.Type != SecurityIPListType {
+			return "", fmt.Errorf("Rules of the shared network can't use %s", reference)
+		}
+		return reference.String(), nil
+	case len(endpoint.References) == 0 && len(endpoint.IPAddressPrefixes) > 0:
+		list := CreateSecurityIPListInput{
+			Name:         c.hashedName("ips", endpoint.IPAddressPrefixes...),
+			SecIPEntries: endpoint.IPAddressPrefixes,
+		}
+		c.ipLists[list.Name] = list
+		return fmt.Sprintf("%s:%s", SecurityIPListType, list.Name), nil
+	case endpoint.IsAny():
+		return "", fmt.Errorf("Rules of the shared network can't be from or to any, use seciplist:/oracle/public/public-internet instead")
+	}
+	return "", fmt.Errorf("Rules of the shared network need a single security list or security IP list, or only IP addresses, got %s", endpoint)
+}
+
+// Compiles a rule of the shared network to a sec rule per security application
+func (c *firewallCompiler) compileSecRules(rule FirewallRule) error {
+	applications, err := c.compileSecurityApplications(rule.Services)
+	if err != nil {
+		return err
+	}
+	source, err := c.compileSecurityList(rule.Source)
+	if err != nil {
+		return err
+	}
+	destination, err := c.compileSecurityList(rule.Destination)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(source, SecurityListType+":") && !strings.HasPrefix(destination, SecurityListType+":") {
+		return fmt.Errorf("Rules of the shared network need a security list as the source or the destination")
+	}
+
+	for _, application := range applications {
+		secRule := CreateSecRuleInput{
+			Name:            c.hashedName("rule", application, source, destination),
+			Action:          secRuleActionPermit,
+			Application:     application,
+			SourceList:      source,
+			DestinationList: destination,
+			Disabled:        rule.Disabled,
+			Description:     rule.String(),
+		}
+		if existing, ok := c.secRules[secRule.Name]; ok {
+			if existing.Disabled != secRule.Disabled {
+				return fmt.Errorf("Rule %q allows the same, but isn't disabled the same way", existing.Description)
+			}
+			continue
+		}
+		c.secRules[secRule.Name] = secRule
+	}
+	return nil
+}
+
+// Compiles the services of a rule of IP networks to the names of security protocols. The ports of each protocol are
+// merged, so tcp/80,tcp/443 needs a single security protocol of ports 80 and 443. No names is all protocols.
+func (c *firewallCompiler) compileSecurityProtocols(services []FirewallService) ([]string, error) {
+	for _, service := range services {
+		if service.Protocol == string(All) {
+			if service.Ports != "" {
+				return nil, fmt.Errorf("Service %s can't have ports", service)
+			}
+			return []string{}, nil
+		}
+	}
+
+	names := []string{}
+	ports := map[string]PortSet{}
+	protocols := []string{}
+	for _, service := range services {
+		if service.Reference.Type != "" {
+			if service.Reference.Type != FirewallReferenceSecurityProtocol {
+				return nil, fmt.Errorf("Rules of IP networks can't use %s", service.Reference)
+			}
+			names = append(names, service.Reference.Name)
+			continue
+		}
+
+		var entries []string
+		if service.Ports != "" {
+			entries = []string{service.Ports}
+		}
+		set, err := ParseProtocolPorts(service.Protocol, entries)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ports[service.Protocol]; !ok {
+			protocols = append(protocols, service.Protocol)
+		}
+		ports[service.Protocol] = ports[service.Protocol].Union(set)
+	}
+
+	for _, protocol := range protocols {
+		set := ports[protocol]
+		all, _ := ParseProtocolPorts(protocol, nil)
+		input := CreateSecurityProtocolInput{Name: c.name(protocol), IPProtocol: protocol}
+		if !set.ContainsSet(all) {
+			input.Name = c.name(protocol, strings.Join(set.Strings(), "_"))
+			input.DstPortSet = set.Strings()
+		}
+		c.protocols[input.Name] = input
+		names = append(names, input.Name)
+	}
+	return uniqueSortedStrings(names), nil
+}
+
+// Compiles the source or destination of a rule of IP networks to a vNIC set and IP address prefix sets
+func (c *firewallCompiler) compileIPNetworkEndpoint(endpoint FirewallEndpoint) (string, []string, error) {
+	vnicSet := ""
+	prefixSets := []string{}
+	for _, reference := range endpoint.References {
+		switch reference.Type {
+		case FirewallReferenceVirtualNICSet:
+			if vnicSet != "" {
+				return "", nil, fmt.Errorf("Rules of IP networks can have a single vNIC set as the source or the destination, got %s", endpoint)
+			}
+			vnicSet = reference.Name
+		case FirewallReferenceIPAddressPrefixSet:
+			prefixSets = append(prefixSets, reference.Name)
+		default:
+			return "", nil, fmt.Errorf("Rules of IP networks can't use %s", reference)
+		}
+	}
+	if len(endpoint.IPAddressPrefixes) > 0 {
+		prefixes := []string{}
+		for _, prefix := range endpoint.IPAddressPrefixes {
+			if !strings.Contains(prefix, "/") {
+				prefix += "/32"
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		prefixes = uniqueSortedStrings(prefixes)
+		prefixSet := CreateIPAddressPrefixSetInput{Name: c.hashedName("ips", prefixes...), IPAddressPrefixes: prefixes}
+		c.prefixSets[prefixSet.Name] = prefixSet
+		prefixSets = append(prefixSets, prefixSet.Name)
+	}
+	return vnicSet, uniqueSortedStrings(prefixSets), nil
+}
+
+// Compiles a rule of IP networks to a security rule
+func (c *firewallCompiler) compileSecurityRule(rule FirewallRule) error {
+	protocols, err := c.compileSecurityProtocols(rule.Services)
+	if err != nil {
+		return err
+	}
+	srcVnicSet, srcPrefixSets, err := c.compileIPNetworkEndpoint(rule.Source)
+	if err != nil {
+		return err
+	}
+	dstVnicSet, dstPrefixSets, err := c.compileIPNetworkEndpoint(rule.Destination)
+	if err != nil {
+		return err
+	}
+	direction := rule.flowDirection()
+	if direction == "" {
+		return fmt.Errorf("Rules of IP networks without a vNIC set need a flow direction, ingress or egress")
+	}
+
+	securityRule := CreateSecurityRuleInput{
+		Name: c.hashedName("rule", direction, strings.Join(protocols, ","),
+			srcVnicSet, strings.Join(srcPrefixSets, ","), dstVnicSet, strings.Join(dstPrefixSets, ",")),
+		ACL:                    rule.ACL,
+		Description:            rule.String(),
+		FlowDirection:          direction,
+		Enabled:                !rule.Disabled,
+		SecProtocols:           protocols,
+		SrcVnicSet:             srcVnicSet,
+		SrcIPAddressPrefixSets: srcPrefixSets,
+		DstVnicSet:             dstVnicSet,
+		DstIPAddressPrefixSets: dstPrefixSets,
+	}
+	if existing, ok := c.securityRules[securityRule.Name]; ok {
+		if existing.Enabled != securityRule.Enabled || existing.ACL != securityRule.ACL {
+			return fmt.Errorf("Rule %q allows the same, but has a different ACL or isn't disabled the same way", existing.Description)
+		}
+		return nil
+	}
+	c.securityRules[securityRule.Name] = securityRule
+	return nil
+}
+
+// FirewallRules decompiles the sec rules of the model to firewall rules. Security applications and security IP lists
+// that firewall rules compile to with the prefix, or DefaultFirewallPrefix if it's empty, are shown as the services and
+// IP addresses they were compiled from, and the others as references, so the rules compile to the same objects again.
+func (m *SharedNetworkModel) FirewallRules(prefix string) []FirewallRule {
+	c := newFirewallCompiler(prefix)
+	rules := []FirewallRule{}
+	for _, secRule := range m.SecRules {
+		rule := FirewallRule{Disabled: secRule.Disabled}
+		reference := FirewallService{Reference: FirewallReference{Type: FirewallReferenceSecurityApplication, Name: secRule.Application}}
+		rule.Services = []FirewallService{reference}
+		if application, ok := m.SecurityApplications[secRule.Application]; ok {
+			if service, ok := c.decompileSecurityApplication(&application); ok {
+				rule.Services = []FirewallService{service}
+			}
+		}
+		rule.Source = m.decompileSecurityList(c, secRule.SourceList)
+		rule.Destination = m.decompileSecurityList(c, secRule.DestinationList)
+		rules = append(rules, rule)
+	}
+	sortFirewallRules(rules)
+	return rules
+}
+
+// Returns the service the security application was compiled from, if it was compiled
+func (c *firewallCompiler) decompileSecurityApplication(application *SecurityApplicationInfo) (FirewallService, bool) {
+	service := FirewallService{Protocol: string(application.Protocol)}
+	switch {
+	case application.Protocol == TCP || application.Protocol == UDP:
+		service.Ports = application.DPort
+	case application.Protocol == ICMP && application.ICMPType != "":
+		service.Ports = string(application.ICMPType)
+		if application.ICMPCode != "" {
+			service.Ports += "/" + string(application.ICMPCode)
+		}
+	}
+	names, err := c.compileSecurityApplications([]FirewallService{service})
+	if err != nil || len(names) != 1 || names[0] != application.Name {
+		return FirewallService{}, false
+	}
+	compiled := c.applications[application.Name]
+	if compiled.Protocol != application.Protocol || compiled.DPort != application.DPort ||
+		compiled.ICMPType != application.ICMPType || compiled.ICMPCode != application.ICMPCode {
+		return FirewallService{}, false
+	}
+	return service, true
+}
+
+// Returns the source or destination of a sec rule, with the entries of the security IP list if it was compiled
+func (m *SharedNetworkModel) decompileSecurityList(c *firewallCompiler, name string) FirewallEndpoint {
+	reference, ok := parseFirewallReference(name)
+	if !ok {
+		return FirewallEndpoint{References: []FirewallReference{{Type: SecurityListType, Name: name}}}
+	}
+	endpoint := FirewallEndpoint{References: []FirewallReference{reference}}
+	list, ok := m.SecurityIPLists[reference.Name]
+	if reference.Type != SecurityIPListType || !ok {
+		return endpoint
+	}
+	prefixes := []string{}
+	for _, entry := range list.SecIPEntries {
+		prefix, err := normalizeFirewallIPAddressPrefix(entry)
+		if err != nil {
+			return endpoint
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	compiled := FirewallEndpoint{IPAddressPrefixes: uniqueSortedStrings(prefixes)}
+	if compiledName, err := c.compileSecurityList(compiled); err != nil || compiledName != name {
+		return endpoint
+	}
+	return compiled
+}
+
+// FirewallRules decompiles the security rules of the model to firewall rules. Security protocols and IP address prefix
+// sets that firewall rules compile to with the prefix, or DefaultFirewallPrefix if it's empty, are shown as the services
+// and IP addresses they were compiled from, and the others as references, so the rules compile to the same objects again.
+func (m *IPNetworkModel) FirewallRules(prefix string) []FirewallRule {
+	c := newFirewallCompiler(prefix)
+	rules := []FirewallRule{}
+	for _, securityRule := range m.SecurityRules {
+		rule := FirewallRule{ACL: securityRule.ACL, Disabled: !securityRule.Enabled}
+		for _, name := range securityRule.SecProtocols {
+			protocol, ok := m.SecurityProtocols[name]
+			services, compiled := []FirewallService{}, false
+			if ok {
+				services, compiled = c.decompileSecurityProtocol(&protocol)
+			}
+			if !compiled {
+				services = []FirewallService{{Reference: FirewallReference{Type: FirewallReferenceSecurityProtocol, Name: name}}}
+			}
+			rule.Services = append(rule.Services, services...)
+		}
+		if len(rule.Services) == 0 {
+			rule.Services = []FirewallService{{Protocol: string(All)}}
+		}
+		sort.SliceStable(rule.Services, func(i, j int) bool {
+			return rule.Services[i].String() < rule.Services[j].String()
+		})
+
+		rule.Source = m.decompileIPNetworkEndpoint(c, securityRule.SrcVnicSet, securityRule.SrcIPAddressPrefixSets)
+		rule.Destination = m.decompileIPNetworkEndpoint(c, securityRule.DstVnicSet, securityRule.DstIPAddressPrefixSets)
+		// Show the flow direction only if it isn't the default one
+		if direction := strings.ToLower(securityRule.FlowDirection); rule.flowDirection() != direction {
+			rule.FlowDirection = direction
+		}
+		rules = append(rules, rule)
+	}
+	sortFirewallRules(rules)
+	return rules
+}
+
+// Returns the services the security protocol was compiled from, if it was compiled
+func (c *firewallCompiler) decompileSecurityProtocol(protocol *SecurityProtocolInfo) ([]FirewallService, bool) {
+	if len(protocol.SrcPortSet) > 0 {
+		return nil, false
+	}
+	ports, err := protocol.DstPorts()
+	if err != nil {
+		return nil, false
+	}
+	name := strings.ToLower(protocol.IPProtocol)
+	services := []FirewallService{{Protocol: name}}
+	if len(protocol.DstPortSet) > 0 {
+		services = []FirewallService{}
+		for _, r := range ports {
+			services = append(services, FirewallService{Protocol: name, Ports: r.String()})
+		}
+	}
+	names, err := c.compileSecurityProtocols(services)
+	if err != nil || len(names) != 1 || names[0] != protocol.Name {
+		return nil, false
+	}
+	compiled := c.protocols[protocol.Name]
+	actualPorts := ""
+	if len(protocol.DstPortSet) > 0 {
+		actualPorts = ports.String()
+	}
+	if compiled.IPProtocol != protocol.IPProtocol || strings.Join(compiled.DstPortSet, ",") != actualPorts {
+		return nil, false
+	}
+	return services, true
+}
+
+// Returns the source or destination of a security rule, with the IP address prefixes of an IP address prefix set
+// if it was compiled
+func (m *IPNetworkModel) decompileIPNetworkEndpoint(c *firewallCompiler, vnicSet string, prefixSets []string) FirewallEndpoint {
+	endpoint := FirewallEndpoint{}
+	if vnicSet != "" {
+		endpoint.References = append(endpoint.References, FirewallReference{Type: FirewallReferenceVirtualNICSet, Name: vnicSet})
+	}
+	inlined := false
+	for _, name := range prefixSets {
+		if prefixSet, ok := m.IPAddressPrefixSets[name]; ok && !inlined {
+			compiled := FirewallEndpoint{IPAddressPrefixes: uniqueSortedStrings(prefixSet.IPAddressPrefixes)}
+			if _, compiledSets, err := c.compileIPNetworkEndpoint(compiled); err == nil && len(compiledSets) == 1 && compiledSets[0] == name {
+				endpoint.IPAddressPrefixes = compiled.IPAddressPrefixes
+				inlined = true
+				continue
+			}
+		}
+		endpoint.References = append(endpoint.References, FirewallReference{Type: FirewallReferenceIPAddressPrefixSet, Name: name})
+	}
+	sort.Slice(endpoint.References, func(i, j int) bool {
+		return endpoint.References[i].String() < endpoint.References[j].String()
+	})
+	return endpoint
+}
+
+func sortFirewallRules(rules []FirewallRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].String() < rules[j].String()
+	})
+}
+
+// DecompileFirewallRules decompiles the sec rules and security rules of the owner of the client to firewall rules,
+// as per SharedNetworkModel.FirewallRules and IPNetworkModel.FirewallRules
+func (c *Client) DecompileFirewallRules(prefix string) ([]FirewallRule, error) {
+	sharedNetwork, err := c.LoadSharedNetworkModel()
+	if err != nil {
+		return nil, err
+	}
+	ipNetworks, err := c.LoadIPNetworkModel()
+	if err != nil {
+		return nil, err
+	}
+	return append(sharedNetwork.FirewallRules(prefix), ipNetworks.FirewallRules(prefix)...), nil
+}
@@ -0,0 +1,491 @@
+package compute
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FirewallChangeAction is what reconciling firewall rules does to an object
+type FirewallChangeAction string
+
+const (
+	// FirewallChangeCreate - the object is created
+	FirewallChangeCreate FirewallChangeAction = "create"
+	// FirewallChangeUpdate - the object is updated
+	FirewallChangeUpdate FirewallChangeAction = "update"
+	// FirewallChangeDelete - the object is deleted
+	FirewallChangeDelete FirewallChangeAction = "delete"
+)
+
+// FirewallChange is a change to an object, to make the account match the firewall rules
+type FirewallChange struct {
+	// What is done to the object
+	Action FirewallChangeAction
+	// The type of the object, such as sec rule
+	Type string
+	// The name of the object
+	Name string
+}
+
+func (c FirewallChange) String() string {
+	return fmt.Sprintf("%s %s %s", c.Action, c.Type, c.Name)
+}
+
+// A change with the function that makes it
+type firewallStep struct {
+	change FirewallChange
+	apply  func() error
+}
+
+// ReconcileFirewallRulesInput describes the firewall rules to reconcile the account with
+type ReconcileFirewallRulesInput struct {
+	// The firewall rules
+	// Required
+	Rules []FirewallRule
+	// The prefix of the names of the objects the rules compile to. Objects of the owner of the client whose names
+	// start with the prefix and a dash, and that the rules don't compile to, are deleted. Defaults to DefaultFirewallPrefix.
+	// Optional
+	Prefix string
+	// Whether to only return the changes, without making them
+	// Optional
+	DryRun bool
+}
+
+// ReconcileFirewallRules compiles the firewall rules, and creates, updates and deletes the objects of the owner of the
+// client with names starting with the prefix, so they match the compiled objects. Objects the rules depend on are
+// created before the rules, and deleted after them. Returns the changes, which on error are the ones made before it.
+func (c *Client) ReconcileFirewallRules(input *ReconcileFirewallRulesInput) ([]FirewallChange, error) {
+	prefix := input.Prefix
+	if prefix == "" {
+		prefix = DefaultFirewallPrefix
+	}
+	objects, err := CompileFirewallRules(input.Rules, prefix)
+	if err != nil {
+		return nil, err
+	}
+	r := &firewallReconciler{Client: c, prefix: prefix + "-"}
+	r.referenced = r.referencedNames(objects)
+
+	var creates, deletes []firewallStep
+	for _, step := range []func(*FirewallObjects) ([]firewallStep, []firewallStep, error){
+		r.securityApplications,
+		r.securityIPLists,
+		r.securityProtocols,
+		r.ipAddressPrefixSets,
+		r.secRules,
+		r.securityRules,
+	} {
+		stepCreates, stepDeletes, err := step(objects)
+		if err != nil {
+			return nil, err
+		}
+		creates = append(creates, stepCreates...)
+		// Delete the rules before the objects they depend on
+		deletes = append(stepDeletes, deletes...)
+	}
+
+	changes := []FirewallChange{}
+	for _, step := range append(creates, deletes...) {
+		if !input.DryRun {
+			if err := step.apply(); err != nil {
+				return changes, fmt.Errorf("Error reconciling firewall rules, failed to %s: %s", step.change, err)
+			}
+		}
+		changes = append(changes, step.change)
+	}
+	return changes, nil
+}
+
+type firewallReconciler struct {
+	*Client
+	prefix string
+	// The unqualified names of the objects the compiled rules use, by type
+	referenced map[string]map[string]bool
+}
+
+// Returns the names of the objects the compiled rules use, by type. Rules can refer to managed objects by name, such as
+// the ones decompiled from objects that were edited since they were created, which mustn't be deleted.
+func (r *firewallReconciler) referencedNames(objects *FirewallObjects) map[string]map[string]bool {
+	referenced := map[string]map[string]bool{}
+	add := func(objectType string, names ...string) {
+		if referenced[objectType] == nil {
+			referenced[objectType] = map[string]bool{}
+		}
+		for _, name := range names {
+			referenced[objectType][r.getUnqualifiedName(name)] = true
+		}
+	}
+	for _, secRule := range objects.SecRules {
+		add("security application", secRule.Application)
+		for _, list := range []string{secRule.SourceList, secRule.DestinationList} {
+			if strings.HasPrefix(list, SecurityIPListType+":") {
+				add("security IP list", strings.TrimPrefix(list, SecurityIPListType+":"))
+			}
+		}
+	}
+	for _, securityRule := range objects.SecurityRules {
+		add("security protocol", securityRule.SecProtocols...)
+		add("IP address prefix set", securityRule.SrcIPAddressPrefixSets...)
+		add("IP address prefix set", securityRule.DstIPAddressPrefixSets...)
+	}
+	return referenced
+}
+
+// Returns whether the object is managed by the firewall rules
+func (r *firewallReconciler) managed(name string) bool {
+	return strings.HasPrefix(name, r.prefix)
+}
+
+// Returns the steps that delete the managed objects that are neither wanted nor used by the rules
+func (r *firewallReconciler) deleteUnwanted(objectType string, existing []string, wanted map[string]bool, remove func(name string) error) []firewallStep {
+	steps := []firewallStep{}
+	sort.Strings(existing)
+	for _, name := range existing {
+		if !r.managed(name) || wanted[name] || r.referenced[objectType][r.getUnqualifiedName(name)] {
+			continue
+		}
+		name := name
+		steps = append(steps, firewallStep{
+			change: FirewallChange{Action: FirewallChangeDelete, Type: objectType, Name: name},
+			apply:  func() error { return remove(name) },
+		})
+	}
+	return steps
+}
+
+// Returns whether the names are the same, whether they're qualified or not
+func (r *firewallReconciler) sameName(a, b string) bool {
+	return r.getUnqualifiedName(a) == r.getUnqualifiedName(b)
+}
+
+// Returns whether the lists of names have the same names, in any order, whether they're qualified or not
+func (r *firewallReconciler) sameNames(a, b []string) bool {
+	unqualified := func(names []string) []string {
+		result := []string{}
+		for _, name := range names {
+			result = append(result, r.getUnqualifiedName(name))
+		}
+		return result
+	}
+	return sameStrings(unqualified(a), unqualified(b))
+}
+
+// Returns whether the lists have the same strings, in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *firewallReconciler) securityApplications(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	applications, err := r.SecurityApplications().ListSecurityApplications()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]SecurityApplicationInfo{}
+	names := []string{}
+	for _, application := range applications {
+		existing[application.Name] = application
+		names = append(names, application.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, application := range objects.SecurityApplications {
+		application := application
+		wanted[application.Name] = true
+		current, ok := existing[application.Name]
+		if !ok {
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "security application", Name: application.Name},
+				apply: func() error {
+					_, err := r.SecurityApplications().CreateSecurityApplication(&application)
+					return err
+				},
+			})
+			continue
+		}
+		if current.Protocol != application.Protocol || current.DPort != application.DPort ||
+			current.ICMPType != application.ICMPType || current.ICMPCode != application.ICMPCode {
+			// Security applications can't be updated
+			return nil, nil, fmt.Errorf("Security application %s was changed since it was created, delete it to create it again", application.Name)
+		}
+	}
+	deletes := r.deleteUnwanted("security application", names, wanted, func(name string) error {
+		return r.SecurityApplications().DeleteSecurityApplication(&DeleteSecurityApplicationInput{Name: name})
+	})
+	return creates, deletes, nil
+}
+
+func (r *firewallReconciler) securityIPLists(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	lists, err := r.SecurityIPLists().ListSecurityIPLists()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]SecurityIPListInfo{}
+	names := []string{}
+	for _, list := range lists {
+		existing[list.Name] = list
+		names = append(names, list.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, list := range objects.SecurityIPLists {
+		list := list
+		wanted[list.Name] = true
+		current, ok := existing[list.Name]
+		switch {
+		case !ok:
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "security IP list", Name: list.Name},
+				apply: func() error {
+					_, err := r.SecurityIPLists().CreateSecurityIPList(&list)
+					return err
+				},
+			})
+		case !sameStrings(current.SecIPEntries, list.SecIPEntries):
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeUpdate, Type: "security IP list", Name: list.Name},
+				apply: func() error {
+					_, err := r.SecurityIPLists().UpdateSecurityIPList(&UpdateSecurityIPListInput{
+						Name:         list.Name,
+						Description:  current.Description,
+						SecIPEntries: list.SecIPEntries,
+					})
+					return err
+				},
+			})
+		}
+	}
+	deletes := r.deleteUnwanted("security IP list", names, wanted, func(name string) error {
+		return r.SecurityIPLists().DeleteSecurityIPList(&DeleteSecurityIPListInput{Name: name})
+	})
+	return creates, deletes, nil
+}
+
+func (r *firewallReconciler) securityProtocols(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	protocols, err := r.SecurityProtocols().ListSecurityProtocols()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]SecurityProtocolInfo{}
+	names := []string{}
+	for _, protocol := range protocols {
+		existing[protocol.Name] = protocol
+		names = append(names, protocol.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, protocol := range objects.SecurityProtocols {
+		protocol := protocol
+		wanted[protocol.Name] = true
+		current, ok := existing[protocol.Name]
+		switch {
+		case !ok:
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "security protocol", Name: protocol.Name},
+				apply: func() error {
+					_, err := r.SecurityProtocols().CreateSecurityProtocol(&protocol)
+					return err
+				},
+			})
+		case !strings.EqualFold(current.IPProtocol, protocol.IPProtocol) || len(current.SrcPortSet) > 0 ||
+			!sameStrings(current.DstPortSet, protocol.DstPortSet):
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeUpdate, Type: "security protocol", Name: protocol.Name},
+				apply: func() error {
+					_, err := r.SecurityProtocols().UpdateSecurityProtocol(&UpdateSecurityProtocolInput{
+						Name:        protocol.Name,
+						Description: current.Description,
+						IPProtocol:  protocol.IPProtocol,
+						DstPortSet:  protocol.DstPortSet,
+						Tags:        current.Tags,
+					})
+					return err
+				},
+			})
+		}
+	}
+	deletes := r.deleteUnwanted("security protocol", names, wanted, func(name string) error {
+		return r.SecurityProtocols().DeleteSecurityProtocol(&DeleteSecurityProtocolInput{Name: name})
+	})
+	return creates, deletes, nil
+}
+
+func (r *firewallReconciler) ipAddressPrefixSets(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	prefixSets, err := r.IPAddressPrefixSets().ListIPAddressPrefixSets()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]IPAddressPrefixSetInfo{}
+	names := []string{}
+	for _, prefixSet := range prefixSets {
+		existing[prefixSet.Name] = prefixSet
+		names = append(names, prefixSet.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, prefixSet := range objects.IPAddressPrefixSets {
+		prefixSet := prefixSet
+		wanted[prefixSet.Name] = true
+		current, ok := existing[prefixSet.Name]
+		switch {
+		case !ok:
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "IP address prefix set", Name: prefixSet.Name},
+				apply: func() error {
+					_, err := r.IPAddressPrefixSets().CreateIPAddressPrefixSet(&prefixSet)
+					return err
+				},
+			})
+		case !sameStrings(current.IPAddressPrefixes, prefixSet.IPAddressPrefixes):
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeUpdate, Type: "IP address prefix set", Name: prefixSet.Name},
+				apply: func() error {
+					_, err := r.IPAddressPrefixSets().UpdateIPAddressPrefixSet(&UpdateIPAddressPrefixSetInput{
+						Name:              prefixSet.Name,
+						Description:       current.Description,
+						IPAddressPrefixes: prefixSet.IPAddressPrefixes,
+						Tags:              current.Tags,
+					})
+					return err
+				},
+			})
+		}
+	}
+	deletes := r.deleteUnwanted("IP address prefix set", names, wanted, func(name string) error {
+		return r.IPAddressPrefixSets().DeleteIPAddressPrefixSet(&DeleteIPAddressPrefixSetInput{Name: name})
+	})
+	return creates, deletes, nil
+}
+
+func (r *firewallReconciler) secRules(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	secRules, err := r.SecRules().ListSecRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]SecRuleInfo{}
+	names := []string{}
+	for _, secRule := range secRules {
+		existing[secRule.Name] = secRule
+		names = append(names, secRule.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, secRule := range objects.SecRules {
+		secRule := secRule
+		wanted[secRule.Name] = true
+		current, ok := existing[secRule.Name]
+		switch {
+		case !ok:
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "sec rule", Name: secRule.Name},
+				apply: func() error {
+					_, err := r.SecRules().CreateSecRule(&secRule)
+					return err
+				},
+			})
+		case !strings.EqualFold(current.Action, secRule.Action) || current.Disabled != secRule.Disabled ||
+			current.Description != secRule.Description ||
+			!r.sameName(current.Application, secRule.Application) ||
+			!r.sameName(current.SourceList, secRule.SourceList) ||
+			!r.sameName(current.DestinationList, secRule.DestinationList):
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeUpdate, Type: "sec rule", Name: secRule.Name},
+				apply: func() error {
+					_, err := r.SecRules().UpdateSecRule(&UpdateSecRuleInput{
+						Name:            secRule.Name,
+						Action:          secRule.Action,
+						Application:     secRule.Application,
+						Description:     secRule.Description,
+						Disabled:        secRule.Disabled,
+						SourceList:      secRule.SourceList,
+						DestinationList: secRule.DestinationList,
+					})
+					return err
+				},
+			})
+		}
+	}
+	deletes := r.deleteUnwanted("sec rule", names, wanted, func(name string) error {
+		return r.SecRules().DeleteSecRule(&DeleteSecRuleInput{Name: name})
+	})
+	return creates, deletes, nil
+}
+
+func (r *firewallReconciler) securityRules(objects *FirewallObjects) ([]firewallStep, []firewallStep, error) {
+	securityRules, err := r.SecurityRules().ListSecurityRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]SecurityRuleInfo{}
+	names := []string{}
+	for _, securityRule := range securityRules {
+		existing[securityRule.Name] = securityRule
+		names = append(names, securityRule.Name)
+	}
+
+	creates := []firewallStep{}
+	wanted := map[string]bool{}
+	for _, securityRule := range objects.SecurityRules {
+		securityRule := securityRule
+		wanted[securityRule.Name] = true
+		current, ok := existing[securityRule.Name]
+		switch {
+		case !ok:
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeCreate, Type: "security rule", Name: securityRule.Name},
+				apply: func() error {
+					_, err := r.SecurityRules().CreateSecurityRule(&securityRule)
+					return err
+				},
+			})
+		case !strings.EqualFold(current.FlowDirection, securityRule.FlowDirection) || current.Enabled != securityRule.Enabled ||
+			current.Description != securityRule.Description ||
+			!r.sameName(current.ACL, securityRule.ACL) ||
+			!r.sameName(current.SrcVnicSet, securityRule.SrcVnicSet) ||
+			!r.sameName(current.DstVnicSet, securityRule.DstVnicSet) ||
+			!r.sameNames(current.SecProtocols, securityRule.SecProtocols) ||
+			!r.sameNames(current.SrcIPAddressPrefixSets, securityRule.SrcIPAddressPrefixSets) ||
+			!r.sameNames(current.DstIPAddressPrefixSets, securityRule.DstIPAddressPrefixSets):
+			creates = append(creates, firewallStep{
+				change: FirewallChange{Action: FirewallChangeUpdate, Type: "security rule", Name: securityRule.Name},
+				apply: func() error {
+					_, err := r.SecurityRules().UpdateSecurityRule(&UpdateSecurityRuleInput{
+						Name:                   securityRule.Name,
+						ACL:                    securityRule.ACL,
+						Description:            securityRule.Description,
+						FlowDirection:          securityRule.FlowDirection,
+						Enabled:                securityRule.Enabled,
+						SecProtocols:           securityRule.SecProtocols,
+						SrcVnicSet:             securityRule.SrcVnicSet,
+						SrcIPAddressPrefixSets: securityRule.SrcIPAddressPrefixSets,
+						DstVnicSet:             securityRule.DstVnicSet,
+						DstIPAddressPrefixSets: securityRule.DstIPAddressPrefixSets,
+						Tags:                   current.Tags,
+					})
+					return err
+				},
+			})
+		}
+	}
+	deletes := r.deleteUnwanted("security rule", names, wanted, func(name string) error {
+		return r.SecurityRules().DeleteSecurityRule(&DeleteSecurityRuleInput{Name: name})
+	})
+	return creates, deletes, nil
+}
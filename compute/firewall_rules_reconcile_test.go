@@ -0,0 +1,205 @@
+package compute
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestClient_ReconcileFirewallRules(t *testing.T) {
+	rules, err := ParseFirewallRules(`
+allow tcp/443 from seciplist:office to seclist:web
+allow tcp/80 from 203.0.113.0/24 to seclist:web
+allow tcp/5432 from vnicset:web to vnicset:db disabled
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, requests, closeServer := newStubFirewallServer(t, nil)
+	defer closeServer()
+
+	changes, err := client.ReconcileFirewallRules(&ReconcileFirewallRulesInput{Rules: rules, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"create security application fw-tcp-80",
+		"create security IP list fw-ips-aa1f77ab",
+		"create security protocol fw-tcp-5432",
+		"create sec rule fw-rule-0dfd4b6b",
+		"update security rule fw-rule-39422c0f",
+		"delete sec rule fw-rule-00000000",
+		"delete security application fw-tcp-22",
+	}
+	got := []string{}
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	if diff := pretty.Compare(got, expected); diff != "" {
+		t.Errorf("Changes Diff: (-got +want)\n%s", diff)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no changes in a dry run, got %v", *requests)
+	}
+
+	if _, err := client.ReconcileFirewallRules(&ReconcileFirewallRulesInput{Rules: rules}); err != nil {
+		t.Fatal(err)
+	}
+	expectedRequests := []string{
+		"POST /secapplication/",
+		"POST /seciplist/",
+		"POST /network/v1/secprotocol/",
+		"POST /secrule/",
+		"PUT /network/v1/secrule/Compute-test/test/fw-rule-39422c0f",
+		"DELETE /secrule/Compute-test/test/fw-rule-00000000",
+		"DELETE /secapplication/Compute-test/test/fw-tcp-22",
+	}
+	if diff := pretty.Compare(*requests, expectedRequests); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient_ReconcileFirewallRules_Decompiled(t *testing.T) {
+	// A security application and a security IP list with the prefix that were edited since they were created
+	// are decompiled as references
+	client, requests, closeServer := newStubFirewallServer(t, map[string]string{
+		"/secapplication/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-tcp-443", "protocol": "tcp", "dport": "8443"}]}`,
+		"/seciplist/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-ips-00000000", "secipentries": ["198.51.100.0/24"]}]}`,
+		"/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/https", "action": "PERMIT", "application": "/Compute-test/test/fw-tcp-443",
+			 "src_list": "seciplist:/Compute-test/test/fw-ips-00000000", "dst_list": "seclist:/Compute-test/test/web"}]}`,
+		"/network/v1/secrule/Compute-test/test/": `{"result": []}`,
+		"/seclist/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web", "policy": "DENY", "outbound_cidr_policy": "PERMIT"}]}`,
+		"/secassociation/Compute-test/test/":     `{"result": []}`,
+		"/instance/Compute-test/test/":           `{"result": []}`,
+		"/network/v1/acl/Compute-test/test/":     `{"result": []}`,
+		"/network/v1/vnicset/Compute-test/test/": `{"result": []}`,
+	})
+	defer closeServer()
+
+	rules, err := client.DecompileFirewallRules("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(firewallRuleStrings(rules), []string{
+		"allow secapplication:fw-tcp-443 from seciplist:fw-ips-00000000 to seclist:web",
+	}); diff != "" {
+		t.Errorf("Firewall Rules Diff: (-got +want)\n%s", diff)
+	}
+
+	changes, err := client.ReconcileFirewallRules(&ReconcileFirewallRulesInput{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	if diff := pretty.Compare(got, []string{"create sec rule fw-rule-8d3d5499"}); diff != "" {
+		t.Errorf("Changes Diff: (-got +want)\n%s", diff)
+	}
+	if diff := pretty.Compare(*requests, []string{"POST /secrule/"}); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient_ReconcileFirewallRules_SwappedVnicSets(t *testing.T) {
+	rules, err := ParseFirewallRules("allow tcp/5432 from vnicset:web to vnicset:db disabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _, closeServer := newStubFirewallServer(t, map[string]string{
+		"/network/v1/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-rule-39422c0f", "flowDirection": "ingress", "enabledFlag": false,
+			 "srcVnicSet": "/Compute-test/test/db", "dstVnicSet": "/Compute-test/test/web", "secProtocols": ["/Compute-test/test/fw-tcp-5432"],
+			 "description": "allow tcp/5432 from vnicset:web to vnicset:db disabled"}]}`,
+	})
+	defer closeServer()
+
+	changes, err := client.ReconcileFirewallRules(&ReconcileFirewallRulesInput{Rules: rules, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.String() == "update security rule fw-rule-39422c0f" {
+			return
+		}
+	}
+	t.Errorf("Expected the security rule with swapped vNIC sets to be updated, got %v", changes)
+}
+
+// Returns a client of a stub server with firewall objects, which records the requests that change them.
+// The responses replace the default responses with the same paths.
+func newStubFirewallServer(t *testing.T, overrides map[string]string) (*Client, *[]string, func()) {
+	responses := map[string]string{
+		"/secapplication/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-tcp-443", "protocol": "tcp", "dport": "443"},
+			{"name": "/Compute-test/test/fw-tcp-22", "protocol": "tcp", "dport": "22"},
+			{"name": "/Compute-test/test/rdp", "protocol": "tcp", "dport": "3389"}]}`,
+		"/seciplist/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/office", "secipentries": ["192.0.2.0/24"]}]}`,
+		"/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-rule-33f5826b", "action": "PERMIT", "application": "/Compute-test/test/fw-tcp-443",
+			 "src_list": "seciplist:/Compute-test/test/office", "dst_list": "seclist:/Compute-test/test/web",
+			 "description": "allow tcp/443 from seciplist:office to seclist:web"},
+			{"name": "/Compute-test/test/fw-rule-00000000", "action": "PERMIT", "application": "/Compute-test/test/fw-tcp-22",
+			 "src_list": "seciplist:/Compute-test/test/office", "dst_list": "seclist:/Compute-test/test/web"},
+			{"name": "/Compute-test/test/rdp", "action": "PERMIT", "application": "/Compute-test/test/rdp",
+			 "src_list": "seciplist:/Compute-test/test/office", "dst_list": "seclist:/Compute-test/test/desktops"}]}`,
+		"/network/v1/secprotocol/Compute-test/test/":        `{"result": []}`,
+		"/network/v1/ipaddressprefixset/Compute-test/test/": `{"result": []}`,
+		"/network/v1/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/fw-rule-39422c0f", "flowDirection": "ingress", "enabledFlag": true,
+			 "srcVnicSet": "/Compute-test/test/web", "dstVnicSet": "/Compute-test/test/db", "secProtocols": ["/Compute-test/test/fw-tcp-5432"],
+			 "description": "allow tcp/5432 from vnicset:web to vnicset:db disabled"}]}`,
+	}
+	for path, response := range overrides {
+		responses[path] = response
+	}
+	requests := []string{}
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			response, ok := responses[r.URL.Path]
+			if !ok {
+				t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(response))
+		case "POST", "PUT":
+			// Echo the object, as the API does
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+		if r.Method != "GET" {
+			requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		}
+	})
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return client, &requests, server.Close
+}
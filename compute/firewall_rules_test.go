@@ -0,0 +1,272 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+const testFirewallRules = `
+# Web servers
+allow tcp/443 from seciplist:office to seclist:web
+allow tcp/80, tcp/81,icmp/echo from 203.0.113.0/24,198.51.100.7 to seclist:web disabled
+
+allow tcp/8000-8100,tcp/9000 from vnicset:web to 10.0.1.0/24 acl:backend
+allow all from any to vnicset:db
+`
+
+func TestParseFirewallRules(t *testing.T) {
+	rules, err := ParseFirewallRules(testFirewallRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `allow tcp/443 from seciplist:office to seclist:web
+allow icmp/echo,tcp/80,tcp/81 from 198.51.100.7,203.0.113.0/24 to seclist:web disabled
+allow tcp/8000-8100,tcp/9000 from vnicset:web to 10.0.1.0/24 acl:backend
+allow all from any to vnicset:db
+`
+	if diff := pretty.Compare(FormatFirewallRules(rules), expected); diff != "" {
+		t.Errorf("Firewall Rules Diff: (-got +want)\n%s", diff)
+	}
+	for i, ipNetwork := range []bool{false, false, true, true} {
+		if rules[i].IPNetwork() != ipNetwork {
+			t.Errorf("Expected rule %q to be an IP network rule: %t", rules[i], ipNetwork)
+		}
+	}
+
+	invalid := []string{
+		"deny tcp/22 from seciplist:office to seclist:web",
+		"allow tcp/22 from seciplist:office",
+		"allow ftp from seciplist:office to seclist:web",
+		"allow tcp/ from seciplist:office to seclist:web",
+		"allow seclist:web from seciplist:office to seclist:web",
+		"allow tcp/22 from secapplication:ssh to seclist:web",
+		"allow tcp/22 from 203.0.113.0/33 to seclist:web",
+		"allow tcp/22 from office to seclist:web",
+		"allow tcp/22 from seciplist:office to seclist:web sometimes",
+	}
+	for _, rule := range invalid {
+		if _, err := ParseFirewallRules(rule); err == nil {
+			t.Errorf("Expected %q not to parse", rule)
+		}
+	}
+}
+
+func TestCompileFirewallRules(t *testing.T) {
+	rules, err := ParseFirewallRules(testFirewallRules + "allow tcp/443 from seciplist:office to seclist:web\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := CompileFirewallRules(rules, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &FirewallObjects{
+		SecurityApplications: []CreateSecurityApplicationInput{
+			{Name: "fw-icmp-echo", Protocol: ICMP, ICMPType: Echo},
+			{Name: "fw-tcp-443", Protocol: TCP, DPort: "443"},
+			{Name: "fw-tcp-80-81", Protocol: TCP, DPort: "80-81"},
+		},
+		SecurityIPLists: []CreateSecurityIPListInput{
+			{Name: "fw-ips-3c9bda57", SecIPEntries: []string{"198.51.100.7", "203.0.113.0/24"}},
+		},
+		SecRules: []CreateSecRuleInput{
+			{
+				Name:            "fw-rule-33f5826b",
+				Action:          "PERMIT",
+				Application:     "fw-tcp-443",
+				Description:     "allow tcp/443 from seciplist:office to seclist:web",
+				SourceList:      "seciplist:office",
+				DestinationList: "seclist:web",
+			},
+			{
+				Name:            "fw-rule-6af49581",
+				Action:          "PERMIT",
+				Application:     "fw-tcp-80-81",
+				Description:     "allow icmp/echo,tcp/80,tcp/81 from 198.51.100.7,203.0.113.0/24 to seclist:web disabled",
+				Disabled:        true,
+				SourceList:      "seciplist:fw-ips-3c9bda57",
+				DestinationList: "seclist:web",
+			},
+			{
+				Name:            "fw-rule-c3378f72",
+				Action:          "PERMIT",
+				Application:     "fw-icmp-echo",
+				Description:     "allow icmp/echo,tcp/80,tcp/81 from 198.51.100.7,203.0.113.0/24 to seclist:web disabled",
+				Disabled:        true,
+				SourceList:      "seciplist:fw-ips-3c9bda57",
+				DestinationList: "seclist:web",
+			},
+		},
+		SecurityProtocols: []CreateSecurityProtocolInput{
+			{Name: "fw-tcp-8000-8100_9000", IPProtocol: "tcp", DstPortSet: []string{"8000-8100", "9000"}},
+		},
+		IPAddressPrefixSets: []CreateIPAddressPrefixSetInput{
+			{Name: "fw-ips-0e6ec2ac", IPAddressPrefixes: []string{"10.0.1.0/24"}},
+		},
+		SecurityRules: []CreateSecurityRuleInput{
+			{
+				Name:                   "fw-rule-22f99b79",
+				Description:            "allow all from any to vnicset:db",
+				DstIPAddressPrefixSets: []string{},
+				DstVnicSet:             "db",
+				Enabled:                true,
+				FlowDirection:          "ingress",
+				SecProtocols:           []string{},
+				SrcIPAddressPrefixSets: []string{},
+			},
+			{
+				Name:                   "fw-rule-474cb06c",
+				ACL:                    "backend",
+				Description:            "allow tcp/8000-8100,tcp/9000 from vnicset:web to 10.0.1.0/24 acl:backend",
+				DstIPAddressPrefixSets: []string{"fw-ips-0e6ec2ac"},
+				Enabled:                true,
+				FlowDirection:          "egress",
+				SecProtocols:           []string{"fw-tcp-8000-8100_9000"},
+				SrcIPAddressPrefixSets: []string{},
+				SrcVnicSet:             "web",
+			},
+		},
+	}
+	if diff := pretty.Compare(objects, expected); diff != "" {
+		t.Errorf("Firewall Objects Diff: (-got +want)\n%s", diff)
+	}
+
+	objects, err = CompileFirewallRules(rules[:1], "edge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objects.SecRules[0].Application != "edge-tcp-443" {
+		t.Errorf("Expected the edge prefix, got %+v", objects.SecRules[0])
+	}
+
+	invalid := []string{
+		"allow tcp/22 from any to seclist:web",
+		"allow tcp/22 from seciplist:office to seciplist:vpn",
+		"allow tcp/22 from seclist:admin,seclist:ops to seclist:web",
+		"allow tcp/22 from 10.0.0.0/8 to 192.168.0.0/16 acl:backend",
+		"allow tcp/22 from vnicset:web,vnicset:db to any",
+		"allow secprotocol:ssh from seclist:admin to ipprefixset:office",
+		"allow icmp/ping from seciplist:office to seclist:web",
+		"allow gre/1 from seciplist:office to seclist:web",
+		"allow tcp/70000 from vnicset:web to any",
+		"allow tcp/22 from seciplist:office to seclist:web\nallow tcp/22 from seciplist:office to seclist:web disabled",
+	}
+	for _, text := range invalid {
+		rules, err := ParseFirewallRules(text)
+		if err != nil {
+			t.Errorf("%q: %s", text, err)
+			continue
+		}
+		if _, err := CompileFirewallRules(rules, ""); err == nil {
+			t.Errorf("Expected %q not to compile", text)
+		}
+	}
+}
+
+func TestSharedNetworkModel_FirewallRules(t *testing.T) {
+	model := &SharedNetworkModel{
+		SecRules: []SecRuleInfo{
+			{Name: "fw-rule-6af49581", Application: "fw-tcp-80-81", SourceList: "seciplist:fw-ips-3c9bda57", DestinationList: "seclist:web", Disabled: true},
+			{Name: "ssh", Application: "/oracle/public/ssh", SourceList: "seciplist:/oracle/public/public-internet", DestinationList: "seclist:admin"},
+			{Name: "edited", Application: "fw-tcp-443", SourceList: "seciplist:fw-ips-3c9bda57", DestinationList: "seclist:web"},
+			{Name: "custom", Application: "rdp", SourceList: "seciplist:office", DestinationList: "seclist:desktops"},
+		},
+		SecurityApplications: map[string]SecurityApplicationInfo{
+			"fw-tcp-80-81":       {Name: "fw-tcp-80-81", Protocol: TCP, DPort: "80-81"},
+			"fw-tcp-443":         {Name: "fw-tcp-443", Protocol: TCP, DPort: "8443"},
+			"/oracle/public/ssh": {Name: "/oracle/public/ssh", Protocol: TCP, DPort: "22"},
+			"rdp":                {Name: "rdp", Protocol: TCP, DPort: "3389"},
+		},
+		SecurityIPLists: map[string]SecurityIPListInfo{
+			"fw-ips-3c9bda57": {Name: "fw-ips-3c9bda57", SecIPEntries: []string{"203.0.113.0/24", "198.51.100.7"}},
+			"office":          {Name: "office", SecIPEntries: []string{"192.0.2.0/24"}},
+		},
+	}
+	expected := []string{
+		"allow secapplication:/oracle/public/ssh from seciplist:/oracle/public/public-internet to seclist:admin",
+		"allow secapplication:fw-tcp-443 from 198.51.100.7,203.0.113.0/24 to seclist:web",
+		"allow secapplication:rdp from seciplist:office to seclist:desktops",
+		"allow tcp/80-81 from 198.51.100.7,203.0.113.0/24 to seclist:web disabled",
+	}
+	rules := model.FirewallRules("")
+	if diff := pretty.Compare(firewallRuleStrings(rules), expected); diff != "" {
+		t.Errorf("Firewall Rules Diff: (-got +want)\n%s", diff)
+	}
+
+	// The decompiled rules compile to the same objects again
+	objects, err := CompileFirewallRules(rules, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secRule := range objects.SecRules {
+		if secRule.Application == "fw-tcp-80-81" && secRule.Name != "fw-rule-6af49581" {
+			t.Errorf("Expected the decompiled rule to compile to sec rule fw-rule-6af49581, got %s", secRule.Name)
+		}
+	}
+	if len(objects.SecurityApplications) != 1 || len(objects.SecurityIPLists) != 1 {
+		t.Errorf("Expected only the compiled security application and security IP list, got %+v", objects)
+	}
+}
+
+func TestIPNetworkModel_FirewallRules(t *testing.T) {
+	model := &IPNetworkModel{
+		SecurityRules: []SecurityRuleInfo{
+			{
+				Name:                   "fw-rule-474cb06c",
+				ACL:                    "backend",
+				DstIPAddressPrefixSets: []string{"fw-ips-0e6ec2ac"},
+				Enabled:                true,
+				FlowDirection:          "egress",
+				SecProtocols:           []string{"fw-tcp-8000-8100_9000"},
+				SrcVnicSet:             "web",
+			},
+			{Name: "fw-rule-22f99b79", DstVnicSet: "db", Enabled: true, FlowDirection: "ingress"},
+			{
+				Name:                   "backup",
+				SrcIPAddressPrefixSets: []string{"office", "fw-ips-0e6ec2ac"},
+				DstVnicSet:             "db",
+				FlowDirection:          "egress",
+				SecProtocols:           []string{"postgres"},
+			},
+		},
+		SecurityProtocols: map[string]SecurityProtocolInfo{
+			"fw-tcp-8000-8100_9000": {Name: "fw-tcp-8000-8100_9000", IPProtocol: "tcp", DstPortSet: []string{"9000", "8000-8100"}},
+			"postgres":              {Name: "postgres", IPProtocol: "tcp", DstPortSet: []string{"5432"}},
+		},
+		IPAddressPrefixSets: map[string]IPAddressPrefixSetInfo{
+			"fw-ips-0e6ec2ac": {Name: "fw-ips-0e6ec2ac", IPAddressPrefixes: []string{"10.0.1.0/24"}},
+			"office":          {Name: "office", IPAddressPrefixes: []string{"192.0.2.0/24"}},
+		},
+	}
+	expected := []string{
+		"allow all from any to vnicset:db",
+		"allow secprotocol:postgres from ipprefixset:office,10.0.1.0/24 to vnicset:db egress disabled",
+		"allow tcp/8000-8100,tcp/9000 from vnicset:web to 10.0.1.0/24 acl:backend",
+	}
+	rules := model.FirewallRules("")
+	if diff := pretty.Compare(firewallRuleStrings(rules), expected); diff != "" {
+		t.Errorf("Firewall Rules Diff: (-got +want)\n%s", diff)
+	}
+
+	objects, err := CompileFirewallRules(rules, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, securityRule := range objects.SecurityRules {
+		names = append(names, securityRule.Name)
+	}
+	if !containsString(names, "fw-rule-474cb06c") || !containsString(names, "fw-rule-22f99b79") {
+		t.Errorf("Expected the decompiled rules to compile to the same security rules, got %v", names)
+	}
+}
+
+func firewallRuleStrings(rules []FirewallRule) []string {
+	result := []string{}
+	for _, rule := range rules {
+		result = append(result, rule.String())
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package compute
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PublishImageListEntryInput specifies the parameters needed to publish a new version of an Image List
+type PublishImageListEntryInput struct {
+	// The name of the Image List
+	// Required
+	Name string
+	// A list of machine images.
+	// Required
+	MachineImages []string
+	// User-defined parameters, in JSON format, that can be passed to an instance of this machine image when it is launched.
+	// Optional
+	Attributes map[string]interface{}
+	// If true, the new entry is made the default entry of the Image List
+	// Optional
+	Promote bool
+}
+
+// PublishImageListEntry creates a new entry in an Image List, with a version one higher than the latest entry.
+func (c *ImageListClient) PublishImageListEntry(input *PublishImageListEntryInput) (*ImageListEntryInfo, error) {
+	imageList, err := c.GetImageList(&GetImageListInput{Name: input.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	version := 1
+	for _, entry := range imageList.Entries {
+		if entry.Version >= version {
+			version = entry.Version + 1
+		}
+	}
+
+	machineImages := []string{}
+	for _, name := range input.MachineImages {
+		machineImages = append(machineImages, c.getQualifiedName(name))
+	}
+
+	createInput := &CreateImageListEntryInput{
+		Name:          input.Name,
+		Attributes:    input.Attributes,
+		MachineImages: machineImages,
+		Version:       version,
+	}
+	entry, err := c.Client.ImageListEntries().CreateImageListEntry(createInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Promote {
+		promoteInput := &PromoteImageListEntryInput{
+			Name:    input.Name,
+			Version: version,
+		}
+		if _, err := c.PromoteImageListEntry(promoteInput); err != nil {
+			return nil, fmt.Errorf("Error promoting image list entry %d: %s", version, err)
+		}
+	}
+
+	return entry, nil
+}
+
+// PromoteImageListEntryInput specifies the Image List entry to make the default
+type PromoteImageListEntryInput struct {
+	// The name of the Image List
+	// Required
+	Name string
+	// Version number of the entry to make the default
+	// Required
+	Version int
+}
+
+// PromoteImageListEntry makes the given entry the default entry of an Image List.
+// The entry must exist, and the change is made with a single update of the Image List,
+// so instances launched from the Image List either use the previous or the new default.
+func (c *ImageListClient) PromoteImageListEntry(input *PromoteImageListEntryInput) (*ImageList, error) {
+	imageList, err := c.GetImageList(&GetImageListInput{Name: input.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	if !imageListHasVersion(imageList, input.Version) {
+		return nil, fmt.Errorf("Image list %s has no entry with version %d", input.Name, input.Version)
+	}
+	if imageList.Default == input.Version {
+		return imageList, nil
+	}
+
+	updateInput := &UpdateImageListInput{
+		Name:        input.Name,
+		Description: imageList.Description,
+		Default:     input.Version,
+	}
+	return c.UpdateImageList(updateInput)
+}
+
+// RollbackImageListInput specifies the Image List to roll back
+type RollbackImageListInput struct {
+	// The name of the Image List
+	// Required
+	Name string
+	// Version number of the entry to make the default.
+	// Defaults to the latest entry before the current default.
+	// Optional
+	Version int
+}
+
+// RollbackImageList makes a previous entry the default entry of an Image List
+func (c *ImageListClient) RollbackImageList(input *RollbackImageListInput) (*ImageList, error) {
+	version := input.Version
+	if version == 0 {
+		imageList, err := c.GetImageList(&GetImageListInput{Name: input.Name})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range imageList.Entries {
+			if entry.Version < imageList.Default && entry.Version > version {
+				version = entry.Version
+			}
+		}
+		if version == 0 {
+			return nil, fmt.Errorf("Image list %s has no entry before the default version %d", input.Name, imageList.Default)
+		}
+	}
+
+	promoteInput := &PromoteImageListEntryInput{
+		Name:    input.Name,
+		Version: version,
+	}
+	return c.PromoteImageListEntry(promoteInput)
+}
+
+// PruneImageListInput specifies the Image List to remove old entries from
+type PruneImageListInput struct {
+	// The name of the Image List
+	// Required
+	Name string
+	// The number of latest entries to keep. The default entry is always kept.
+	// Required
+	Keep int
+	// If true, the entries that would be removed are returned without removing them
+	// Optional
+	DryRun bool
+}
+
+// PruneImageListOutput details the entries removed from an Image List
+type PruneImageListOutput struct {
+	// Versions of the entries that were removed
+	Deleted []int
+	// Versions of the entries that would have been removed, but are used by instances
+	InUse []int
+}
+
+// PruneImageList removes all but the latest entries of an Image List.
+// The default entry, and any entry used by an instance of any user in the identity domain, are never removed.
+func (c *ImageListClient) PruneImageList(input *PruneImageListInput) (*PruneImageListOutput, error) {
+	if input.Keep < 1 {
+		return nil, fmt.Errorf("At least one image list entry must be kept, got %d", input.Keep)
+	}
+
+	imageList, err := c.GetImageList(&GetImageListInput{Name: input.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	allOwners := c.Client.WithOwner(AllOwners)
+	instances, err := allOwners.Instances().ListInstances()
+	if err != nil {
+		return nil, fmt.Errorf("Error listing instances using image list %s: %s", input.Name, err)
+	}
+
+	// Compare fully qualified names, so image lists of the same name of other users don't match
+	domain := *c.client.IdentityDomain
+	imageListName, err := ParseObjectName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	imageListName = imageListName.Qualify(domain, c.Owner())
+
+	inUse := map[int]bool{}
+	for _, instance := range instances {
+		instanceImageList, err := ParseObjectName(instance.ImageList)
+		if err != nil || instanceImageList.Qualify(domain, allOwners.Owner()) != imageListName {
+			continue
+		}
+		if instance.Entry == 0 {
+			// Launched from the default entry
+			inUse[imageList.Default] = true
+		} else {
+			inUse[instance.Entry] = true
+		}
+	}
+
+	versions := []int{}
+	for _, entry := range imageList.Entries {
+		versions = append(versions, entry.Version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	output := &PruneImageListOutput{
+		Deleted: []int{},
+		InUse:   []int{},
+	}
+	for i, version := range versions {
+		if i < input.Keep || version == imageList.Default {
+			continue
+		}
+		if inUse[version] {
+			output.InUse = append(output.InUse, version)
+			continue
+		}
+		if !input.DryRun {
+			deleteInput := &DeleteImageListEntryInput{
+				Name:    input.Name,
+				Version: version,
+			}
+			if err := c.Client.ImageListEntries().DeleteImageListEntry(deleteInput); err != nil {
+				return output, fmt.Errorf("Error deleting image list entry %d: %s", version, err)
+			}
+		}
+		output.Deleted = append(output.Deleted, version)
+	}
+
+	return output, nil
+}
+
+func imageListHasVersion(imageList *ImageList, version int) bool {
+	for _, entry := range imageList.Entries {
+		if entry.Version == version {
+			return true
+		}
+	}
+	return false
+}
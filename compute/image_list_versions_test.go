@@ -0,0 +1,250 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Test that a new entry is published with the next version and promoted to the default.
+func TestImageListClient_PublishImageListEntry(t *testing.T) {
+	server := newImageListVersionsServer(t, []int{1, 2}, 2)
+	defer server.Close()
+
+	client, err := getStubImageListClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &PublishImageListEntryInput{
+		Name:          "test-list",
+		MachineImages: []string{"test-image"},
+		Promote:       true,
+	}
+	entry, err := client.PublishImageListEntry(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Version != 3 {
+		t.Errorf("Expected version 3, got %d", entry.Version)
+	}
+
+	expected := []string{
+		"POST /imagelist/Compute-test/test/test-list/entry/ version=3 machineimages=/Compute-test/test/test-image",
+		"PUT /imagelist/Compute-test/test/test-list default=3",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Publish Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that rolling back makes the previous entry the default, and that unknown versions are rejected.
+func TestImageListClient_RollbackImageList(t *testing.T) {
+	server := newImageListVersionsServer(t, []int{1, 2, 4}, 4)
+	defer server.Close()
+
+	client, err := getStubImageListClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	imageList, err := client.RollbackImageList(&RollbackImageListInput{Name: "test-list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageList.Default != 2 {
+		t.Errorf("Expected default version 2, got %d", imageList.Default)
+	}
+
+	if _, err := client.PromoteImageListEntry(&PromoteImageListEntryInput{Name: "test-list", Version: 3}); err == nil {
+		t.Errorf("Expected an error promoting a missing entry")
+	}
+}
+
+// Test that old entries are pruned, keeping the latest entries, the default entry
+// and any entry still used by an instance of any user.
+func TestImageListClient_PruneImageList(t *testing.T) {
+	server := newImageListVersionsServer(t, []int{1, 2, 3, 4, 5, 6}, 3)
+	defer server.Close()
+
+	client, err := getStubImageListClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	output, err := client.PruneImageList(&PruneImageListInput{Name: "test-list", Keep: 2, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.changes()) != 0 {
+		t.Errorf("Expected no changes in a dry run, got %v", server.changes())
+	}
+	if diff := pretty.Compare(output.Deleted, []int{1}); diff != "" {
+		t.Errorf("Dry Run Output Diff: (-got +want)\n%s", diff)
+	}
+
+	output, err = client.PruneImageList(&PruneImageListInput{Name: "test-list", Keep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &PruneImageListOutput{
+		Deleted: []int{1},
+		InUse:   []int{4, 2},
+	}
+	if diff := pretty.Compare(output, expected); diff != "" {
+		t.Errorf("Prune Output Diff: (-got +want)\n%s", diff)
+	}
+
+	expectedChanges := []string{
+		"DELETE /imagelist/Compute-test/test/test-list/entry/1",
+	}
+	if diff := pretty.Compare(server.changes(), expectedChanges); diff != "" {
+		t.Errorf("Prune Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that the instances using an image list are found when pruning it as another owner.
+func TestImageListClient_PruneImageListOtherOwner(t *testing.T) {
+	server := newImageListVersionsServer(t, []int{1, 2, 3, 4, 5, 6}, 3)
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	output, err := client.WithOwner("jane").ImageList().PruneImageList(&PruneImageListInput{Name: "/Compute-test/test/test-list", Keep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &PruneImageListOutput{
+		Deleted: []int{1},
+		InUse:   []int{4, 2},
+	}
+	if diff := pretty.Compare(output, expected); diff != "" {
+		t.Errorf("Prune Output Diff: (-got +want)\n%s", diff)
+	}
+}
+
+type imageListVersionsServer struct {
+	server   *httptest.Server
+	lock     sync.Mutex
+	versions []int
+	current  int
+	changed  []string
+}
+
+func (s *imageListVersionsServer) Close() {
+	s.server.Close()
+}
+
+func (s *imageListVersionsServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves a single image list, and instances using entry 2 of it, entry 4 of it launched by another user,
+// and entry 1 of other image lists, one of which has the same name but another owner
+func newImageListVersionsServer(t *testing.T, versions []int, current int) *imageListVersionsServer {
+	s := &imageListVersionsServer{
+		versions: versions,
+		current:  current,
+	}
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/imagelist/Compute-test/test/test-list":
+			w.Write([]byte(s.imageListResponse()))
+		case r.Method == "PUT" && r.URL.Path == "/imagelist/Compute-test/test/test-list":
+			var body UpdateImageListInput
+			unmarshalRequestBody(t, r, &body)
+			s.current = body.Default
+			change = fmt.Sprintf("%s default=%d", change, body.Default)
+			w.Write([]byte(s.imageListResponse()))
+		case r.Method == "POST" && r.URL.Path == "/imagelist/Compute-test/test/test-list/entry/":
+			var body CreateImageListEntryInput
+			unmarshalRequestBody(t, r, &body)
+			s.versions = append(s.versions, body.Version)
+			change = fmt.Sprintf("%s version=%d machineimages=%s", change, body.Version, strings.Join(body.MachineImages, ","))
+			w.Write([]byte(fmt.Sprintf(`{"imagelist": "/Compute-test/test/test-list", "version": %d, "machineimages": ["/Compute-test/test/test-image"]}`, body.Version)))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/imagelist/Compute-test/test/test-list/entry/"):
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/instance/Compute-test/":
+			w.Write([]byte(exampleImageListInstancesResponse))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
+
+func (s *imageListVersionsServer) imageListResponse() string {
+	entries := []string{}
+	for _, version := range s.versions {
+		entries = append(entries, fmt.Sprintf(`{"version": %d}`, version))
+	}
+	return fmt.Sprintf(`{"name": "/Compute-test/test/test-list", "default": %d, "entries": [%s]}`, s.current, strings.Join(entries, ", "))
+}
+
+func getStubImageListClient(server *httptest.Server) (*ImageListClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ImageList(), nil
+}
+
+var exampleImageListInstancesResponse = `
+{
+  "result": [
+    {
+      "name": "/Compute-test/test/web/0d5ec0d8-6a43-4a3b-a6d6-3a0e4d1c8b7e",
+      "imagelist": "/Compute-test/test/test-list",
+      "entry": 2,
+      "state": "running"
+    },
+    {
+      "name": "/Compute-test/test/db/5c54b6d4-3f6a-47a6-8a36-1cf4bf0f1d2f",
+      "imagelist": "/Compute-test/test/other-list",
+      "entry": 1,
+      "state": "running"
+    },
+    {
+      "name": "/Compute-test/jane/batch/9a1c3e57-0b6e-4f4f-9a55-7e1d2c3b4a5f",
+      "imagelist": "/Compute-test/test/test-list",
+      "entry": 4,
+      "state": "running"
+    },
+    {
+      "name": "/Compute-test/jane/report/4b8e2f61-7c3d-4e2a-b1f0-6d5a9c8e7f30",
+      "imagelist": "/Compute-test/jane/test-list",
+      "entry": 1,
+      "state": "running"
+    }
+  ]
+}
+`
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForInstanceReadyPollInterval = 10 * time.Second
@@ -129,7 +131,7 @@ type InstanceInfo struct {
 	SSHKeys []string `json:"sshkeys"`
 
 	// The start time of the instance
-	StartTime string `json:"start_time"`
+	StartTime opc.Time `json:"start_time"`
 
 	// State of the instance.
 	State InstanceState `json:"state"`
@@ -232,6 +234,10 @@ type CreateInstanceInput struct {
 	Tags []string `json:"tags"`
 	// Time to wait for an instance to be ready
 	Timeout time.Duration `json:"-"`
+	// If true, the shape is checked against the shapes available in the site
+	// before the launch plan is submitted.
+	// Optional
+	ValidateShape bool `json:"-"`
 }
 
 // StorageAttachmentInput specifies the attributes needed to attach a storage attachment
@@ -331,6 +337,36 @@ type LaunchPlanResponse struct {
 
 // CreateInstance creates and submits a LaunchPlan to launch a new instance.
 func (c *InstancesClient) CreateInstance(input *CreateInstanceInput) (*InstanceInfo, error) {
+	if input.ValidateShape {
+		if err := c.validateShape(input.Shape); err != nil {
+			return nil, err
+		}
+	}
+
+	c.qualifyCreateInstanceInput(input)
+
+	plan := LaunchPlanInput{
+		Instances: []CreateInstanceInput{*input},
+		Timeout:   input.Timeout,
+	}
+
+	var (
+		instanceInfo  *InstanceInfo
+		instanceError error
+	)
+	for i := 0; i < *c.Client.client.MaxRetries; i++ {
+		c.client.DebugLogString(fmt.Sprintf("(Iteration: %d of %d) Creating instance with name %s\n Plan: %+v", i, *c.Client.client.MaxRetries, input.Name, plan))
+
+		instanceInfo, instanceError = c.startInstance(input.Name, plan)
+		if instanceError == nil {
+			c.client.DebugLogString(fmt.Sprintf("(Iteration: %d of %d) Finished creating instance with name %s\n Info: %+v", i, *c.Client.client.MaxRetries, input.Name, instanceInfo))
+			return instanceInfo, nil
+		}
+	}
+	return nil, instanceError
+}
+
+func (c *InstancesClient) qualifyCreateInstanceInput(input *CreateInstanceInput) {
 	qualifiedSSHKeys := []string{}
 	for _, key := range input.SSHKeys {
 		qualifiedSSHKeys = append(qualifiedSSHKeys, c.getQualifiedName(key))
@@ -350,26 +386,19 @@ func (c *InstancesClient) CreateInstance(input *CreateInstanceInput) (*InstanceI
 	input.Networking = c.qualifyNetworking(input.Networking)
 
 	input.Name = fmt.Sprintf(cmpQualifiedName, c.getUserName(), input.Name)
+}
 
-	plan := LaunchPlanInput{
-		Instances: []CreateInstanceInput{*input},
-		Timeout:   input.Timeout,
+func (c *InstancesClient) validateShape(shape string) error {
+	if shape == "" {
+		return errors.New("A shape needs to be specified")
 	}
-
-	var (
-		instanceInfo  *InstanceInfo
-		instanceError error
-	)
-	for i := 0; i < *c.Client.client.MaxRetries; i++ {
-		c.client.DebugLogString(fmt.Sprintf("(Iteration: %d of %d) Creating instance with name %s\n Plan: %+v", i, *c.Client.client.MaxRetries, input.Name, plan))
-
-		instanceInfo, instanceError = c.startInstance(input.Name, plan)
-		if instanceError == nil {
-			c.client.DebugLogString(fmt.Sprintf("(Iteration: %d of %d) Finished creating instance with name %s\n Info: %+v", i, *c.Client.client.MaxRetries, input.Name, instanceInfo))
-			return instanceInfo, nil
+	if _, err := c.Client.Shapes().GetShape(&GetShapeInput{Name: shape}); err != nil {
+		if client.WasNotFoundError(err) {
+			return fmt.Errorf("Unknown shape %q", shape)
 		}
+		return fmt.Errorf("Error validating shape %q: %s", shape, err)
 	}
-	return nil, instanceError
+	return nil
 }
 
 func (c *InstancesClient) startInstance(name string, plan LaunchPlanInput) (*InstanceInfo, error) {
@@ -414,6 +443,177 @@ func (c *InstancesClient) startInstance(name string, plan LaunchPlanInput) (*Ins
 	return instanceInfo, nil
 }
 
+// LaunchInstancesInput specifies the parameters needed to launch several instances with a single launch plan
+type LaunchInstancesInput struct {
+	// The instances to launch
+	// Required
+	Instances []CreateInstanceInput
+	// If true, the instances that launched successfully are deleted when any other instance fails to launch
+	// Optional
+	Rollback bool
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for instance boot
+	Timeout time.Duration
+}
+
+// LaunchInstancesError is returned by LaunchInstances when one or more instances fail to launch
+type LaunchInstancesError struct {
+	// The error for each instance of the launch plan, in the order of the input.
+	// Instances that launched successfully have a nil error.
+	Errors []error
+	// Whether the instances that launched successfully were deleted
+	RolledBack bool
+}
+
+func (e *LaunchInstancesError) Error() string {
+	failures := []string{}
+	for i, err := range e.Errors {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("instance %d: %s", i, err))
+		}
+	}
+	msg := fmt.Sprintf("%d of %d instances failed to launch: %s", len(failures), len(e.Errors), strings.Join(failures, "; "))
+	if e.RolledBack {
+		msg = fmt.Sprintf("%s (launched instances were deleted)", msg)
+	}
+	return msg
+}
+
+// LaunchInstances submits a single LaunchPlan for all of the given instances, and waits for them concurrently.
+// The returned slice holds the information of each instance in the order of the input. If any instance fails
+// to launch, it is deleted and a *LaunchInstancesError describes the failures; the other instances are
+// returned as well, unless Rollback is set, in which case they are deleted too.
+func (c *InstancesClient) LaunchInstances(input *LaunchInstancesInput) ([]*InstanceInfo, error) {
+	if len(input.Instances) == 0 {
+		return nil, errors.New("At least one instance needs to be specified")
+	}
+
+	for i := range input.Instances {
+		if input.Instances[i].ValidateShape {
+			if err := c.validateShape(input.Instances[i].Shape); err != nil {
+				return nil, err
+			}
+		}
+		c.qualifyCreateInstanceInput(&input.Instances[i])
+	}
+
+	plan := LaunchPlanInput{
+		Instances: input.Instances,
+	}
+
+	var responseBody LaunchPlanResponse
+	if err := c.createResource(&plan, &responseBody); err != nil {
+		return nil, err
+	}
+
+	if len(responseBody.Instances) != len(input.Instances) {
+		return nil, fmt.Errorf("Expected information for %d instances, got: %#v", len(input.Instances), responseBody)
+	}
+
+	if input.PollInterval == 0 {
+		input.PollInterval = waitForInstanceReadyPollInterval
+	}
+	if input.Timeout == 0 {
+		input.Timeout = waitForInstanceReadyTimeout
+	}
+
+	launched := c.matchLaunchedInstances(input.Instances, responseBody.Instances)
+
+	infos := make([]*InstanceInfo, len(launched))
+	launchErrors := make([]error, len(launched))
+	var wg sync.WaitGroup
+	for i := range launched {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			infos[i], launchErrors[i] = c.WaitForInstanceRunning(launched[i], input.PollInterval, input.Timeout)
+		}(i)
+	}
+	wg.Wait()
+
+	failed := false
+	for _, err := range launchErrors {
+		if err != nil {
+			failed = true
+		}
+	}
+	if !failed {
+		return infos, nil
+	}
+
+	// Delete the instances that failed, as CreateInstance does, along with the
+	// successful instances when rolling back
+	deleteErrors := make([]error, len(launched))
+	for i := range launched {
+		if launchErrors[i] == nil && !input.Rollback {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			deleteInput := &DeleteInstanceInput{
+				Name:         launched[i].Name,
+				ID:           launched[i].ID,
+				PollInterval: input.PollInterval,
+				Timeout:      input.Timeout,
+			}
+			deleteErrors[i] = c.DeleteInstance(deleteInput)
+		}(i)
+	}
+	wg.Wait()
+
+	launchErr := &LaunchInstancesError{
+		Errors:     launchErrors,
+		RolledBack: input.Rollback,
+	}
+	for i, err := range deleteErrors {
+		if err == nil {
+			if launchErrors[i] != nil || input.Rollback {
+				infos[i] = nil
+			}
+			continue
+		}
+		if launchErrors[i] == nil {
+			launchErr.RolledBack = false
+			launchErrors[i] = fmt.Errorf("Error deleting instance %s: %s", launched[i].Name, err)
+		} else {
+			launchErrors[i] = fmt.Errorf("%s (error deleting instance %s: %s)", launchErrors[i], launched[i].Name, err)
+		}
+	}
+
+	return infos, launchErr
+}
+
+// Matches the instances returned by a launch plan with the instances that were requested.
+// Instances are matched by name, falling back to the order of the response for generated names.
+func (c *InstancesClient) matchLaunchedInstances(requested []CreateInstanceInput, launched []InstanceInfo) []*GetInstanceInput {
+	matched := make([]*GetInstanceInput, len(requested))
+	used := make([]bool, len(launched))
+	for i, spec := range requested {
+		for j, info := range launched {
+			if !used[j] && strings.HasPrefix(info.Name, fmt.Sprintf("%s/", spec.Name)) {
+				matched[i] = &GetInstanceInput{Name: spec.Name, ID: info.ID}
+				used[j] = true
+				break
+			}
+		}
+	}
+	for i, spec := range requested {
+		if matched[i] != nil {
+			continue
+		}
+		for j, info := range launched {
+			if !used[j] {
+				matched[i] = &GetInstanceInput{Name: spec.Name, ID: info.ID}
+				used[j] = true
+				break
+			}
+		}
+	}
+	return matched
+}
+
 // GetInstanceInput specifies the parameters needed to retrieve an instance
 type GetInstanceInput struct {
 	// The Unqualified Name of this Instance
@@ -445,9 +645,12 @@ func (c *InstancesClient) GetInstance(input *GetInstanceInput) (*InstanceInfo, e
 
 	// The returned 'Name' attribute is the fully qualified instance name + "/" + ID
 	// Split these out to accurately populate the fields
-	nID := strings.Split(c.getUnqualifiedName(responseBody.Name), "/")
-	responseBody.Name = nID[0]
-	responseBody.ID = nID[1]
+	name, id, err := c.splitInstanceName(responseBody.Name)
+	if err != nil {
+		return nil, err
+	}
+	responseBody.Name = name
+	responseBody.ID = id
 
 	c.unqualify(&responseBody.VCableID)
 
@@ -495,27 +698,9 @@ func (c *InstancesClient) GetInstanceFromName(input *GetInstanceIDInput) (*Insta
 				return nil, fmt.Errorf("Empty response body when requesting instance %s", input.Name)
 			}
 
-			// The returned 'Name' attribute is the fully qualified instance name + "/" + ID
-			// Split these out to accurately populate the fields
-			nID := strings.Split(c.getUnqualifiedName(i.Name), "/")
-			i.Name = nID[0]
-			i.ID = nID[1]
-
-			c.unqualify(&i.VCableID)
-
-			// Unqualify SSH Key names
-			sshKeyNames := []string{}
-			for _, sshKeyRef := range i.SSHKeys {
-				sshKeyNames = append(sshKeyNames, c.getUnqualifiedName(sshKeyRef))
-			}
-			i.SSHKeys = sshKeyNames
-
-			var networkingErr error
-			i.Networking, networkingErr = c.unqualifyNetworking(i.Networking)
-			if networkingErr != nil {
-				return nil, networkingErr
+			if err := c.unqualifyInstanceInfo(&i); err != nil {
+				return nil, err
 			}
-			i.Storage = c.unqualifyStorage(i.Storage)
 
 			return &i, nil
 		}
@@ -524,6 +709,64 @@ func (c *InstancesClient) GetInstanceFromName(input *GetInstanceIDInput) (*Insta
 	return nil, fmt.Errorf("Unable to find instance: %q", input.Name)
 }
 
+// ListInstances retrieves information about the instances of the client's owner.
+func (c *InstancesClient) ListInstances() ([]InstanceInfo, error) {
+	var instancesInfo InstancesInfo
+	if err := c.getResource(c.getListContainer(), &instancesInfo); err != nil {
+		return nil, err
+	}
+
+	for i := range instancesInfo.Instances {
+		if err := c.unqualifyInstanceInfo(&instancesInfo.Instances[i]); err != nil {
+			return nil, err
+		}
+	}
+	return instancesInfo.Instances, nil
+}
+
+// Splits the name/id of an instance, qualified or not, into its name and ID. The name is unqualified,
+// unless the instance is owned by a user other than the owner of the client.
+func (c *Client) splitInstanceName(qualifiedName string) (string, string, error) {
+	objectName, err := ParseObjectName(qualifiedName)
+	if err != nil {
+		return "", "", err
+	}
+	nID := strings.Split(objectName.Name, "/")
+	if len(nID) != 2 {
+		return "", "", fmt.Errorf("Unexpected instance name %q", qualifiedName)
+	}
+	objectName.Name = nID[0]
+	return c.getUnqualifiedName(objectName.String()), nID[1], nil
+}
+
+func (c *InstancesClient) unqualifyInstanceInfo(i *InstanceInfo) error {
+	// The returned 'Name' attribute is the fully qualified instance name + "/" + ID
+	// Split these out to accurately populate the fields
+	name, id, err := c.splitInstanceName(i.Name)
+	if err != nil {
+		return err
+	}
+	i.Name = name
+	i.ID = id
+
+	c.unqualify(&i.VCableID)
+
+	// Unqualify SSH Key names
+	sshKeyNames := []string{}
+	for _, sshKeyRef := range i.SSHKeys {
+		sshKeyNames = append(sshKeyNames, c.getUnqualifiedName(sshKeyRef))
+	}
+	i.SSHKeys = sshKeyNames
+
+	var networkingErr error
+	i.Networking, networkingErr = c.unqualifyNetworking(i.Networking)
+	if networkingErr != nil {
+		return networkingErr
+	}
+	i.Storage = c.unqualifyStorage(i.Storage)
+	return nil
+}
+
 // UpdateInstanceInput specifies the parameters needed to update an instance
 type UpdateInstanceInput struct {
 	// Name of this instance, generated by the server.
@@ -166,7 +166,7 @@ func TestAccInstanceStopStart(t *testing.T) {
 	volumeName := fmt.Sprintf("%s-volume-%d", _InstanceTestName, rInt)
 	volumeInput := &CreateStorageVolumeInput{
 		Name:           volumeName,
-		Size:           "20",
+		Size:           20 * opc.GB,
 		ImageList:      _InstanceTestImage,
 		ImageListEntry: _InstanceTestImageEntry,
 		Bootable:       true,
@@ -329,7 +329,7 @@ func (i *InstanceInfo) zeroFields() {
 	i.Hostname = ""
 	i.IPAddress = ""
 	i.Networking = map[string]NetworkingInfo{}
-	i.StartTime = ""
+	i.StartTime = opc.Time{}
 	i.VCableID = ""
 	i.VNC = ""
 }
@@ -1,11 +1,15 @@
 package compute
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test that the client can create an instance.
@@ -193,8 +197,13 @@ func TestInstanceClient_RetrieveInstance(t *testing.T) {
 	if info.State != InstanceRunning {
 		t.Errorf("Expected state 'running', was %s", info.State)
 	}
-	if info.SSHKeys[0] != "acme-prod-admin" {
-		t.Errorf("Expected ssh key 'acme-prod-admin', was %s", info.SSHKeys[0])
+	// Names of objects of other users, or of other identity domains, stay qualified
+	if info.SSHKeys[0] != "/Compute-acme/jack.jones@example.com/acme-prod-admin" {
+		t.Errorf("Expected ssh key '/Compute-acme/jack.jones@example.com/acme-prod-admin', was %s", info.SSHKeys[0])
+	}
+	expectedStart := time.Date(2014, 6, 24, 17, 51, 35, 0, time.UTC)
+	if !info.StartTime.Equal(expectedStart) || info.StartTime.String() != "2014-06-24T17:51:35Z" {
+		t.Errorf("Expected start time %s, was %s", expectedStart, info.StartTime)
 	}
 }
 
@@ -467,3 +476,124 @@ var exampleErrorRetrieveResponse = `
 "boot_order": []
 }
 `
+
+// Test that several instances are launched with a single launch plan.
+func TestInstanceClient_LaunchInstances(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			plan := &LaunchPlanInput{}
+			unmarshalRequestBody(t, r, plan)
+			if len(plan.Instances) != 2 {
+				t.Fatalf("Expected 2 instances in the launch plan, got %d", len(plan.Instances))
+			}
+			// Return the instances in a different order, to check they are matched by name
+			w.Write([]byte(exampleLaunchInstancesResponse("second", "first")))
+		case "GET":
+			switch r.URL.Path {
+			case "/instance/Compute-test/test/first/first-id", "/instance/Compute-test/test/second/second-id":
+				w.Write([]byte(exampleLaunchedInstanceResponse(r.URL.Path, "running")))
+			default:
+				t.Errorf("Wrong HTTP URL %v", r.URL.Path)
+			}
+		default:
+			t.Errorf("Wrong HTTP method %s, expected POST or GET", r.Method)
+		}
+	})
+
+	defer server.Close()
+	iv, err := getStubInstancesClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &LaunchInstancesInput{
+		Instances: []CreateInstanceInput{
+			{Name: "first", Shape: "oc3", ImageList: "imagelist"},
+			{Name: "second", Shape: "oc3", ImageList: "imagelist"},
+		},
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	infos, err := iv.LaunchInstances(input)
+	if err != nil {
+		t.Fatalf("Launch instances request failed: %s", err)
+	}
+
+	if infos[0].ID != "first-id" || infos[1].ID != "second-id" {
+		t.Errorf("Expected instance ids [first-id second-id], got [%s %s]", infos[0].ID, infos[1].ID)
+	}
+}
+
+// Test that the launched instances are deleted when one of them fails and rollback is requested.
+func TestInstanceClient_LaunchInstancesRollback(t *testing.T) {
+	var lock sync.Mutex
+	deleted := map[string]bool{}
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.Method {
+		case "POST":
+			w.Write([]byte(exampleLaunchInstancesResponse("first", "second")))
+		case "GET":
+			if deleted[r.URL.Path] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			state := "running"
+			if r.URL.Path == "/instance/Compute-test/test/second/second-id" {
+				state = "error"
+			}
+			w.Write([]byte(exampleLaunchedInstanceResponse(r.URL.Path, state)))
+		case "DELETE":
+			deleted[r.URL.Path] = true
+		default:
+			t.Errorf("Wrong HTTP method %s", r.Method)
+		}
+	})
+
+	defer server.Close()
+	iv, err := getStubInstancesClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &LaunchInstancesInput{
+		Instances: []CreateInstanceInput{
+			{Name: "first", Shape: "oc3", ImageList: "imagelist"},
+			{Name: "second", Shape: "oc3", ImageList: "imagelist"},
+		},
+		Rollback:     true,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	infos, err := iv.LaunchInstances(input)
+	launchErr, ok := err.(*LaunchInstancesError)
+	if !ok {
+		t.Fatalf("Expected a launch instances error, got %v", err)
+	}
+	if launchErr.Errors[0] != nil || launchErr.Errors[1] == nil {
+		t.Errorf("Expected only the second instance to fail, got %v", launchErr.Errors)
+	}
+	if !launchErr.RolledBack {
+		t.Errorf("Expected the launched instances to be rolled back")
+	}
+	if infos[0] != nil || infos[1] != nil {
+		t.Errorf("Expected no instances to be returned after rollback, got %v", infos)
+	}
+	if !deleted["/instance/Compute-test/test/first/first-id"] || !deleted["/instance/Compute-test/test/second/second-id"] {
+		t.Errorf("Expected both instances to be deleted, got %v", deleted)
+	}
+}
+
+func exampleLaunchInstancesResponse(names ...string) string {
+	instances := []string{}
+	for _, name := range names {
+		instances = append(instances, fmt.Sprintf(`{"name": "/Compute-test/test/%s/%s-id", "id": "%s-id", "state": "queued"}`, name, name, name))
+	}
+	return fmt.Sprintf(`{"instances": [%s]}`, strings.Join(instances, ","))
+}
+
+func exampleLaunchedInstanceResponse(path, state string) string {
+	return fmt.Sprintf(`{"name": "%s", "state": "%s", "shape": "oc3"}`, strings.TrimPrefix(path, "/instance"), state)
+}
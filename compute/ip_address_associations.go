@@ -148,6 +148,26 @@ func (c *IPAddressAssociationsClient) DeleteIPAddressAssociation(input *DeleteIP
 	return c.deleteResource(input.Name)
 }
 
+// IPAddressAssociationList represents the list of IP address associations returned by the service
+type IPAddressAssociationList struct {
+	Result []IPAddressAssociationInfo `json:"result"`
+}
+
+// ListIPAddressAssociations returns the IPAddressAssociationInfo structs of the client's owner
+func (c *IPAddressAssociationsClient) ListIPAddressAssociations() ([]IPAddressAssociationInfo, error) {
+	var list IPAddressAssociationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 // Unqualifies any qualified fields in the IPAddressAssociationInfo struct
 func (c *IPAddressAssociationsClient) success(info *IPAddressAssociationInfo) (*IPAddressAssociationInfo, error) {
 	c.unqualify(&info.Name)
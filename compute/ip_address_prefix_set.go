@@ -1,5 +1,7 @@
 package compute
 
+import "fmt"
+
 const (
 	iPAddressPrefixSetDescription   = "ip address prefix set"
 	iPAddressPrefixSetContainerPath = "/network/v1/ipaddressprefixset/"
@@ -62,6 +64,9 @@ type CreateIPAddressPrefixSetInput struct {
 // CreateIPAddressPrefixSet creates a new IP Address Prefix Set from an IPAddressPrefixSetsClient and an input struct.
 // Returns a populated Info struct for the IP Address Prefix Set, and any errors
 func (c *IPAddressPrefixSetsClient) CreateIPAddressPrefixSet(input *CreateIPAddressPrefixSetInput) (*IPAddressPrefixSetInfo, error) {
+	if err := validateIPAddressPrefixes(input.Name, input.IPAddressPrefixes); err != nil {
+		return nil, err
+	}
 	input.Name = c.getQualifiedName(input.Name)
 
 	var ipInfo IPAddressPrefixSetInfo
@@ -112,6 +117,9 @@ type UpdateIPAddressPrefixSetInput struct {
 
 // UpdateIPAddressPrefixSet update the ip address prefix set
 func (c *IPAddressPrefixSetsClient) UpdateIPAddressPrefixSet(updateInput *UpdateIPAddressPrefixSetInput) (*IPAddressPrefixSetInfo, error) {
+	if err := validateIPAddressPrefixes(updateInput.Name, updateInput.IPAddressPrefixes); err != nil {
+		return nil, err
+	}
 	updateInput.Name = c.getQualifiedName(updateInput.Name)
 	var ipInfo IPAddressPrefixSetInfo
 	if err := c.updateResource(updateInput.Name, updateInput, &ipInfo); err != nil {
@@ -133,6 +141,35 @@ func (c *IPAddressPrefixSetsClient) DeleteIPAddressPrefixSet(input *DeleteIPAddr
 	return c.deleteResource(input.Name)
 }
 
+// IPAddressPrefixSetList represents the list of IP address prefix sets returned by the service
+type IPAddressPrefixSetList struct {
+	Result []IPAddressPrefixSetInfo `json:"result"`
+}
+
+// ListIPAddressPrefixSets returns the IPAddressPrefixSetInfo structs of the client's owner
+func (c *IPAddressPrefixSetsClient) ListIPAddressPrefixSets() ([]IPAddressPrefixSetInfo, error) {
+	var list IPAddressPrefixSetList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
+func validateIPAddressPrefixes(name string, prefixes []string) error {
+	for _, prefix := range prefixes {
+		if _, err := ParseIPAddressPrefix(prefix); err != nil {
+			return fmt.Errorf("Invalid IP address prefix of IP address prefix set %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
 // Unqualifies any qualified fields in the IPAddressPrefixSetInfo struct
 func (c *IPAddressPrefixSetsClient) success(info *IPAddressPrefixSetInfo) (*IPAddressPrefixSetInfo, error) {
 	c.unqualify(&info.Name)
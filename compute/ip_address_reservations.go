@@ -174,6 +174,26 @@ func (c *IPAddressReservationsClient) DeleteIPAddressReservation(input *DeleteIP
 	return c.deleteResource(input.Name)
 }
 
+// IPAddressReservationList represents the list of IP address reservations returned by the service
+type IPAddressReservationList struct {
+	Result []IPAddressReservation `json:"result"`
+}
+
+// ListIPAddressReservations returns the IP Address Reservations of the client's owner and any errors
+func (c *IPAddressReservationsClient) ListIPAddressReservations() ([]IPAddressReservation, error) {
+	var list IPAddressReservationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *IPAddressReservationsClient) success(result *IPAddressReservation) (*IPAddressReservation, error) {
 	c.unqualify(&result.Name)
 	if result.IPAddressPool != "" {
@@ -24,7 +24,8 @@ func (c *Client) IPAssociations() *IPAssociationsClient {
 
 // IPAssociationInfo describes an existing IP association.
 type IPAssociationInfo struct {
-	// TODO: it'd probably make sense to expose the `ip` field here too?
+	// The public IP address of the IP reservation
+	IP string `json:"ip"`
 
 	// The three-part name of the object (/Compute-identity_domain/user/object).
 	Name string `json:"name"`
@@ -99,6 +100,26 @@ func (c *IPAssociationsClient) DeleteIPAssociation(input *DeleteIPAssociationInp
 	return c.deleteResource(input.Name)
 }
 
+// IPAssociationList represents the list of IP associations returned by the service
+type IPAssociationList struct {
+	Result []IPAssociationInfo `json:"result"`
+}
+
+// ListIPAssociations retrieves the IP associations of the client's owner.
+func (c *IPAssociationsClient) ListIPAssociations() ([]IPAssociationInfo, error) {
+	var list IPAssociationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *IPAssociationsClient) getQualifiedParentPoolName(parentpool string) string {
 	parts := strings.Split(parentpool, ":")
 	pooltype := parts[0]
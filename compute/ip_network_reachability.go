@@ -0,0 +1,464 @@
+package compute
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Flow directions of security rules
+const (
+	SecurityRuleFlowDirectionEgress  = "egress"
+	SecurityRuleFlowDirectionIngress = "ingress"
+)
+
+// IPNetworkModel is an in-memory model of the ACLs, security rules, security protocols, IP address prefix sets
+// and vNIC sets of the IP networks, to analyze which flows are permitted without calling the API for every question.
+type IPNetworkModel struct {
+	// The ACLs, by name
+	ACLs map[string]ACLInfo
+	// The security rules
+	SecurityRules []SecurityRuleInfo
+	// The security protocols, by name
+	SecurityProtocols map[string]SecurityProtocolInfo
+	// The IP address prefix sets, by name
+	IPAddressPrefixSets map[string]IPAddressPrefixSetInfo
+	// The vNIC sets, by name
+	VirtualNICSets map[string]VirtualNICSet
+	// The IP address of each vNIC of an instance, by vNIC name
+	VirtualNICIPAddresses map[string]string
+	// The instance of each vNIC, in the form name/id, by vNIC name
+	VirtualNICInstances map[string]string
+}
+
+// LoadIPNetworkModel loads the IP network objects of the owner of the client into a model, along with the
+// vNICs of the owner's instances. The ACLs, security protocols and IP address prefix sets the vNIC sets and
+// security rules refer to are loaded too, even if they're owned by other users.
+func (c *Client) LoadIPNetworkModel() (*IPNetworkModel, error) {
+	model := &IPNetworkModel{
+		ACLs:                map[string]ACLInfo{},
+		SecurityProtocols:   map[string]SecurityProtocolInfo{},
+		IPAddressPrefixSets: map[string]IPAddressPrefixSetInfo{},
+		VirtualNICSets:      map[string]VirtualNICSet{},
+	}
+
+	acls, err := c.ACLs().ListACLs()
+	if err != nil {
+		return nil, err
+	}
+	for _, acl := range acls {
+		model.ACLs[acl.Name] = acl
+	}
+
+	securityRules, err := c.SecurityRules().ListSecurityRules()
+	if err != nil {
+		return nil, err
+	}
+	model.SecurityRules = securityRules
+
+	protocols, err := c.SecurityProtocols().ListSecurityProtocols()
+	if err != nil {
+		return nil, err
+	}
+	for _, protocol := range protocols {
+		model.SecurityProtocols[protocol.Name] = protocol
+	}
+
+	prefixSets, err := c.IPAddressPrefixSets().ListIPAddressPrefixSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, prefixSet := range prefixSets {
+		model.IPAddressPrefixSets[prefixSet.Name] = prefixSet
+	}
+
+	vnicSets, err := c.VirtNICSets().ListVirtualNICSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, vnicSet := range vnicSets {
+		model.VirtualNICSets[vnicSet.Name] = vnicSet
+	}
+
+	instances, err := c.Instances().ListInstances()
+	if err != nil {
+		return nil, err
+	}
+	model.VirtualNICInstances, model.VirtualNICIPAddresses = instanceVirtualNICs(instances)
+
+	if err := c.loadIPNetworkReferences(model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// Returns the instance, in the form name/id, and the IP address of each vNIC of the instances on IP networks, by vNIC name
+func instanceVirtualNICs(instances []InstanceInfo) (map[string]string, map[string]string) {
+	vnicInstances := map[string]string{}
+	vnicIPAddresses := map[string]string{}
+	for _, instance := range instances {
+		for _, info := range instance.Networking {
+			if info.IPNetwork == "" || info.Vnic == "" {
+				continue
+			}
+			vnicInstances[info.Vnic] = fmt.Sprintf(cmpQualifiedName, instance.Name, instance.ID)
+			if info.IPAddress != "" {
+				vnicIPAddresses[info.Vnic] = info.IPAddress
+			}
+		}
+	}
+	return vnicInstances, vnicIPAddresses
+}
+
+// Loads the objects the vNIC sets and security rules refer to that aren't in the model yet
+func (c *Client) loadIPNetworkReferences(model *IPNetworkModel) error {
+	for _, vnicSet := range model.VirtualNICSets {
+		for _, name := range vnicSet.AppliedACLs {
+			if _, ok := model.ACLs[name]; ok {
+				continue
+			}
+			acl, err := c.ACLs().GetACL(&GetACLInput{Name: name})
+			if err != nil {
+				return fmt.Errorf("Error loading ACL %s of vNIC set %s: %s", name, vnicSet.Name, err)
+			}
+			model.ACLs[name] = *acl
+		}
+	}
+
+	for _, rule := range model.SecurityRules {
+		for _, name := range rule.SecProtocols {
+			if _, ok := model.SecurityProtocols[name]; ok {
+				continue
+			}
+			protocol, err := c.SecurityProtocols().GetSecurityProtocol(&GetSecurityProtocolInput{Name: name})
+			if err != nil {
+				return fmt.Errorf("Error loading security protocol %s of security rule %s: %s", name, rule.Name, err)
+			}
+			model.SecurityProtocols[name] = *protocol
+		}
+		for _, name := range append(append([]string{}, rule.SrcIPAddressPrefixSets...), rule.DstIPAddressPrefixSets...) {
+			if _, ok := model.IPAddressPrefixSets[name]; ok {
+				continue
+			}
+			prefixSet, err := c.IPAddressPrefixSets().GetIPAddressPrefixSet(&GetIPAddressPrefixSetInput{Name: name})
+			if err != nil {
+				return fmt.Errorf("Error loading IP address prefix set %s of security rule %s: %s", name, rule.Name, err)
+			}
+			model.IPAddressPrefixSets[name] = *prefixSet
+		}
+	}
+	return nil
+}
+
+// IPNetworkEndpoint is the source or destination of a flow on the IP networks: either a vNIC,
+// or a host or network outside of the IP networks of the model
+type IPNetworkEndpoint struct {
+	// The name of a vNIC
+	VirtualNIC string
+	// The IP address of a host, or the CIDR of a network, such as 203.0.113.0/24.
+	// Defaults to the IP address of the vNIC.
+	IPAddress string
+}
+
+func (e IPNetworkEndpoint) String() string {
+	if e.VirtualNIC != "" {
+		return fmt.Sprintf("vNIC %s", e.VirtualNIC)
+	}
+	if strings.Contains(e.IPAddress, "/") {
+		return fmt.Sprintf("network %s", e.IPAddress)
+	}
+	return fmt.Sprintf("host %s", e.IPAddress)
+}
+
+// IPNetworkReachabilityQuery asks whether a flow from a source to a destination is permitted
+type IPNetworkReachabilityQuery struct {
+	// The source of the flow
+	// Required
+	Source IPNetworkEndpoint
+	// The destination of the flow. At least one of the source and destination must be a vNIC.
+	// Required
+	Destination IPNetworkEndpoint
+	// The IP protocol of the flow, such as tcp
+	// Required
+	Protocol string
+	// The destination port of the flow, or the ICMP type for icmp
+	// Optional
+	Port int
+	// The source port of the flow. Security protocols with source ports only match flows with a source port.
+	// Optional
+	SourcePort int
+}
+
+// IPNetworkFlowDecision is the decision of the ACLs of a vNIC on the egress or ingress of a flow
+type IPNetworkFlowDecision struct {
+	// Whether the flow is permitted
+	Allowed bool
+	// The security rule that permits the flow, if a rule does
+	SecurityRule string
+	// The ACL of the security rule
+	ACL string
+}
+
+// IPNetworkReachabilityVerdict is the answer to an IPNetworkReachabilityQuery
+type IPNetworkReachabilityVerdict struct {
+	// Whether the flow is permitted, both on egress from the source and on ingress to the destination
+	Allowed bool
+	// The decision on the egress of the flow from the source, if it's a vNIC
+	Egress *IPNetworkFlowDecision
+	// The decision on the ingress of the flow to the destination, if it's a vNIC
+	Ingress *IPNetworkFlowDecision
+	// The rules and ACLs that were evaluated, one step per line
+	Trace []string
+}
+
+func (v *IPNetworkReachabilityVerdict) trace(format string, args ...interface{}) {
+	v.Trace = append(v.Trace, fmt.Sprintf(format, args...))
+}
+
+// CanReach answers whether the flow of a query is permitted, and traces the security rules that decide it.
+// Flows are denied unless permitted by an enabled security rule in an enabled ACL applied to a vNIC set of the vNIC:
+// an egress rule for the source vNIC, and an ingress rule for the destination vNIC. The source and destination of
+// a rule match if they're in its vNIC set and in one of its IP address prefix sets, where specified. A network
+// matches an IP address prefix set only if one of its prefixes contains the whole network.
+func (m *IPNetworkModel) CanReach(query *IPNetworkReachabilityQuery) (*IPNetworkReachabilityVerdict, error) {
+	if query.Protocol == "" {
+		return nil, fmt.Errorf("The protocol of the flow needs to be specified")
+	}
+	if query.Source.VirtualNIC == "" && query.Destination.VirtualNIC == "" {
+		return nil, fmt.Errorf("At least one of the source and destination of the flow needs to be a vNIC")
+	}
+	source, err := m.resolveEndpoint(query.Source)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := m.resolveEndpoint(query.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &IPNetworkReachabilityVerdict{Allowed: true}
+	for _, endpoint := range []*ipNetworkEndpoint{source, destination} {
+		if endpoint.VirtualNIC == "" {
+			continue
+		}
+		description := endpoint.String()
+		if instance, ok := m.VirtualNICInstances[endpoint.VirtualNIC]; ok {
+			description = fmt.Sprintf("%s of instance %s", description, instance)
+		}
+		if endpoint.prefix != nil {
+			description = fmt.Sprintf("%s has IP address %s and", description, endpoint.prefix.IP)
+		}
+		if len(endpoint.vnicSets) == 0 {
+			verdict.trace("%s isn't in any vNIC sets", description)
+			continue
+		}
+		verdict.trace("%s is in vNIC sets %s", description, strings.Join(endpoint.vnicSets, ", "))
+	}
+
+	if source.VirtualNIC != "" {
+		verdict.Egress = m.decide(verdict, SecurityRuleFlowDirectionEgress, source, source, destination, query)
+		verdict.Allowed = verdict.Allowed && verdict.Egress.Allowed
+	}
+	if destination.VirtualNIC != "" {
+		verdict.Ingress = m.decide(verdict, SecurityRuleFlowDirectionIngress, destination, source, destination, query)
+		verdict.Allowed = verdict.Allowed && verdict.Ingress.Allowed
+	}
+	return verdict, nil
+}
+
+// Decides the egress or ingress of a flow at a vNIC by the security rules of the ACLs applied to its vNIC sets
+func (m *IPNetworkModel) decide(verdict *IPNetworkReachabilityVerdict, direction string, vnic, source, destination *ipNetworkEndpoint, query *IPNetworkReachabilityQuery) *IPNetworkFlowDecision {
+	acls := []string{}
+	for _, name := range vnic.vnicSets {
+		acls = append(acls, m.VirtualNICSets[name].AppliedACLs...)
+	}
+	acls = uniqueSortedStrings(acls)
+
+	enabled := []string{}
+	for _, name := range acls {
+		acl, ok := m.ACLs[name]
+		switch {
+		case !ok:
+			verdict.trace("ACL %s of %s isn't loaded", name, vnic)
+		case !acl.Enabled:
+			verdict.trace("ACL %s of %s is disabled", name, vnic)
+		default:
+			enabled = append(enabled, name)
+		}
+	}
+	if len(enabled) == 0 {
+		verdict.trace("%s has no enabled ACLs, so %s is denied", vnic, direction)
+		return &IPNetworkFlowDecision{}
+	}
+
+	for _, rule := range m.SecurityRules {
+		if !containsString(enabled, rule.ACL) || !strings.EqualFold(rule.FlowDirection, direction) {
+			continue
+		}
+		if !rule.Enabled {
+			verdict.trace("Security rule %s of ACL %s doesn't apply, as it's disabled", rule.Name, rule.ACL)
+			continue
+		}
+		if reason := m.matchesEndpoint(rule.SrcVnicSet, rule.SrcIPAddressPrefixSets, source); reason != "" {
+			verdict.trace("Security rule %s of ACL %s doesn't apply, as the source %s", rule.Name, rule.ACL, reason)
+			continue
+		}
+		if reason := m.matchesEndpoint(rule.DstVnicSet, rule.DstIPAddressPrefixSets, destination); reason != "" {
+			verdict.trace("Security rule %s of ACL %s doesn't apply, as the destination %s", rule.Name, rule.ACL, reason)
+			continue
+		}
+		protocol, reason := m.matchingSecurityProtocol(rule.SecProtocols, query)
+		if reason != "" {
+			verdict.trace("Security rule %s of ACL %s doesn't apply, as %s", rule.Name, rule.ACL, reason)
+			continue
+		}
+		verdict.trace("Security rule %s of ACL %s permits %s of %s", rule.Name, rule.ACL, direction, protocol)
+		return &IPNetworkFlowDecision{
+			Allowed:      true,
+			SecurityRule: rule.Name,
+			ACL:          rule.ACL,
+		}
+	}
+
+	verdict.trace("No %s security rule of ACLs %s permits the flow, so %s is denied", direction, strings.Join(enabled, ", "), direction)
+	return &IPNetworkFlowDecision{}
+}
+
+// Returns why the endpoint doesn't match the vNIC set and IP address prefix sets of a rule, or nothing if it matches
+func (m *IPNetworkModel) matchesEndpoint(vnicSet string, prefixSets []string, endpoint *ipNetworkEndpoint) string {
+	if vnicSet != "" && !containsString(endpoint.vnicSets, vnicSet) {
+		return fmt.Sprintf("%s isn't in vNIC set %s", endpoint, vnicSet)
+	}
+	if len(prefixSets) == 0 {
+		return ""
+	}
+	if endpoint.prefix != nil {
+		for _, name := range prefixSets {
+			if prefixSet, ok := m.IPAddressPrefixSets[name]; ok && prefixesContain(prefixSet.IPAddressPrefixes, endpoint.prefix) {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("%s isn't in IP address prefix sets %s", endpoint, strings.Join(prefixSets, ", "))
+}
+
+// Returns a description of the security protocol that matches the flow, or why none does. No security protocols match all flows.
+func (m *IPNetworkModel) matchingSecurityProtocol(names []string, query *IPNetworkReachabilityQuery) (string, string) {
+	if len(names) == 0 {
+		return "all protocols", ""
+	}
+	descriptions := []string{}
+	for _, name := range names {
+		protocol, ok := m.SecurityProtocols[name]
+		if !ok {
+			descriptions = append(descriptions, fmt.Sprintf("%s isn't loaded", name))
+			continue
+		}
+		if securityProtocolMatches(&protocol, query) {
+			return fmt.Sprintf("security protocol %s (%s)", name, describeSecurityProtocol(&protocol)), ""
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s is %s", name, describeSecurityProtocol(&protocol)))
+	}
+	return "", fmt.Sprintf("no security protocol matches: %s", strings.Join(descriptions, ", "))
+}
+
+func securityProtocolMatches(protocol *SecurityProtocolInfo, query *IPNetworkReachabilityQuery) bool {
+	if protocol.IPProtocol != "" && !strings.EqualFold(protocol.IPProtocol, "all") && !strings.EqualFold(protocol.IPProtocol, query.Protocol) {
+		return false
+	}
+	if len(protocol.DstPortSet) > 0 {
+		if ports, err := protocol.DstPorts(); err != nil || !ports.Contains(query.Port) {
+			return false
+		}
+	}
+	if len(protocol.SrcPortSet) > 0 {
+		if ports, err := protocol.SrcPorts(); err != nil || query.SourcePort == 0 || !ports.Contains(query.SourcePort) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeSecurityProtocol(protocol *SecurityProtocolInfo) string {
+	description := protocol.IPProtocol
+	if description == "" {
+		description = "all"
+	}
+	if len(protocol.DstPortSet) > 0 {
+		description = fmt.Sprintf("%s port %s", description, strings.Join(protocol.DstPortSet, ", "))
+	}
+	if len(protocol.SrcPortSet) > 0 {
+		description = fmt.Sprintf("%s from port %s", description, strings.Join(protocol.SrcPortSet, ", "))
+	}
+	return description
+}
+
+// Whether one of the prefixes contains the whole network
+func prefixesContain(prefixes []string, network *net.IPNet) bool {
+	ones, bits := network.Mask.Size()
+	for _, prefix := range prefixes {
+		p, err := parseIPPrefix(prefix)
+		if err != nil {
+			continue
+		}
+		pOnes, pBits := p.Mask.Size()
+		if pBits == bits && pOnes <= ones && p.Contains(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses a CIDR, or an IP address as a network of a single address
+func parseIPPrefix(prefix string) (*net.IPNet, error) {
+	if strings.Contains(prefix, "/") {
+		_, network, err := net.ParseCIDR(prefix)
+		return network, err
+	}
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address %q", prefix)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// An endpoint with its vNIC sets, if it's a vNIC, and its IP address or network
+type ipNetworkEndpoint struct {
+	IPNetworkEndpoint
+	vnicSets []string
+	prefix   *net.IPNet
+}
+
+func (m *IPNetworkModel) resolveEndpoint(endpoint IPNetworkEndpoint) (*ipNetworkEndpoint, error) {
+	resolved := &ipNetworkEndpoint{IPNetworkEndpoint: endpoint}
+	address := endpoint.IPAddress
+	if endpoint.VirtualNIC != "" {
+		_, isInstanceVNIC := m.VirtualNICInstances[endpoint.VirtualNIC]
+		for name, vnicSet := range m.VirtualNICSets {
+			if containsString(vnicSet.VirtualNICs, endpoint.VirtualNIC) {
+				resolved.vnicSets = append(resolved.vnicSets, name)
+			}
+		}
+		if !isInstanceVNIC && len(resolved.vnicSets) == 0 {
+			return nil, fmt.Errorf("vNIC %s isn't in the IP network model", endpoint.VirtualNIC)
+		}
+		sort.Strings(resolved.vnicSets)
+		if address == "" {
+			address = m.VirtualNICIPAddresses[endpoint.VirtualNIC]
+		}
+	}
+	if address != "" {
+		prefix, err := parseIPPrefix(address)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid IP address or CIDR %q of %s", address, endpoint)
+		}
+		resolved.prefix = prefix
+	} else if endpoint.VirtualNIC == "" {
+		return nil, fmt.Errorf("Either a vNIC or an IP address needs to be specified")
+	}
+	return resolved, nil
+}
@@ -0,0 +1,183 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestIPNetworkModel_CanReach(t *testing.T) {
+	model := loadStubIPNetworkModel(t)
+
+	cases := []struct {
+		name    string
+		query   IPNetworkReachabilityQuery
+		allowed bool
+		egress  *IPNetworkFlowDecision
+		ingress *IPNetworkFlowDecision
+	}{
+		{
+			name:    "egress and ingress rules between vNIC sets",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Destination: IPNetworkEndpoint{VirtualNIC: "db_eth0"}, Protocol: "tcp", Port: 5432},
+			allowed: true,
+			egress:  &IPNetworkFlowDecision{Allowed: true, SecurityRule: "web-egress", ACL: "web-acl"},
+			ingress: &IPNetworkFlowDecision{Allowed: true, SecurityRule: "db-ingress-pgsql", ACL: "db-acl"},
+		},
+		{
+			name:    "port outside of the security protocol",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Destination: IPNetworkEndpoint{VirtualNIC: "db_eth0"}, Protocol: "tcp", Port: 22},
+			egress:  &IPNetworkFlowDecision{Allowed: true, SecurityRule: "web-egress", ACL: "web-acl"},
+			ingress: &IPNetworkFlowDecision{},
+		},
+		{
+			name:    "IP address prefix set",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Protocol: "tcp", Port: 443},
+			allowed: true,
+			ingress: &IPNetworkFlowDecision{Allowed: true, SecurityRule: "web-ingress-https", ACL: "web-acl"},
+		},
+		{
+			name:    "network in an IP address prefix set",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{IPAddress: "203.0.113.128/25"}, Destination: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Protocol: "tcp", Port: 22},
+			allowed: true,
+			ingress: &IPNetworkFlowDecision{Allowed: true, SecurityRule: "web-ingress-ssh", ACL: "web-acl"},
+		},
+		{
+			name:    "network larger than the IP address prefix set",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{IPAddress: "203.0.0.0/16"}, Destination: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Protocol: "tcp", Port: 22},
+			ingress: &IPNetworkFlowDecision{},
+		},
+		{
+			name:    "disabled rule",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: IPNetworkEndpoint{VirtualNIC: "db_eth0"}, Protocol: "tcp", Port: 22},
+			ingress: &IPNetworkFlowDecision{},
+		},
+		{
+			name:   "disabled ACL",
+			query:  IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{VirtualNIC: "db_eth0"}, Destination: IPNetworkEndpoint{IPAddress: "192.0.2.1"}, Protocol: "tcp", Port: 443},
+			egress: &IPNetworkFlowDecision{},
+		},
+		{
+			name:    "default deny without ACLs",
+			query:   IPNetworkReachabilityQuery{Source: IPNetworkEndpoint{IPAddress: "192.0.2.1"}, Destination: IPNetworkEndpoint{VirtualNIC: "batch_eth0"}, Protocol: "udp", Port: 53},
+			ingress: &IPNetworkFlowDecision{},
+		},
+	}
+	for _, c := range cases {
+		verdict, err := model.CanReach(&c.query)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if verdict.Allowed != c.allowed {
+			t.Errorf("%s: expected allowed to be %t, got %t: %v", c.name, c.allowed, verdict.Allowed, verdict.Trace)
+		}
+		if diff := pretty.Compare(verdict.Egress, c.egress); diff != "" {
+			t.Errorf("%s: Egress Diff: (-got +want)\n%s", c.name, diff)
+		}
+		if diff := pretty.Compare(verdict.Ingress, c.ingress); diff != "" {
+			t.Errorf("%s: Ingress Diff: (-got +want)\n%s", c.name, diff)
+		}
+	}
+}
+
+func TestIPNetworkModel_CanReachTrace(t *testing.T) {
+	model := loadStubIPNetworkModel(t)
+
+	query := &IPNetworkReachabilityQuery{
+		Source:      IPNetworkEndpoint{IPAddress: "198.51.100.7"},
+		Destination: IPNetworkEndpoint{VirtualNIC: "db_eth0"},
+		Protocol:    "tcp",
+		Port:        22,
+	}
+	verdict, err := model.CanReach(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"vNIC db_eth0 of instance db/2 has IP address 192.168.1.3 and is in vNIC sets db",
+		"ACL db-egress-acl of vNIC db_eth0 is disabled",
+		"Security rule db-ingress-pgsql of ACL db-acl doesn't apply, as the source host 198.51.100.7 isn't in vNIC set web",
+		"Security rule db-ingress-ssh of ACL db-acl doesn't apply, as it's disabled",
+		"No ingress security rule of ACLs db-acl permits the flow, so ingress is denied",
+	}
+	if diff := pretty.Compare(verdict.Trace, expected); diff != "" {
+		t.Errorf("Trace Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestIPNetworkModel_CanReachInvalid(t *testing.T) {
+	model := loadStubIPNetworkModel(t)
+
+	invalid := []IPNetworkReachabilityQuery{
+		{Source: IPNetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: IPNetworkEndpoint{IPAddress: "192.0.2.1"}, Protocol: "tcp"},
+		{Source: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Destination: IPNetworkEndpoint{VirtualNIC: "missing_eth0"}, Protocol: "tcp"},
+		{Source: IPNetworkEndpoint{IPAddress: "not an address"}, Destination: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Protocol: "tcp"},
+		{Source: IPNetworkEndpoint{VirtualNIC: "web_eth0"}, Destination: IPNetworkEndpoint{VirtualNIC: "db_eth0"}},
+	}
+	for _, query := range invalid {
+		if _, err := model.CanReach(&query); err == nil {
+			t.Errorf("Expected an error for query %+v", query)
+		}
+	}
+}
+
+func loadStubIPNetworkModel(t *testing.T) *IPNetworkModel {
+	server, client := newStubResponseServer(t, stubIPNetworkResponses())
+	defer server.Close()
+
+	model, err := client.LoadIPNetworkModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"web_eth0":   "web/1",
+		"db_eth0":    "db/2",
+		"batch_eth0": "batch/3",
+	}
+	if diff := pretty.Compare(model.VirtualNICInstances, expected); diff != "" {
+		t.Fatalf("vNIC Instances Diff: (-got +want)\n%s", diff)
+	}
+	if _, ok := model.SecurityProtocols["/Compute-test/dba/pgsql"]; !ok {
+		t.Fatalf("Expected the security protocol of another user to be loaded")
+	}
+	return model
+}
+
+// The stub responses to the requests of LoadIPNetworkModel
+func stubIPNetworkResponses() map[string]string {
+	return map[string]string{
+		"/network/v1/acl/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web-acl", "enabledFlag": true},
+			{"name": "/Compute-test/test/db-acl", "enabledFlag": true},
+			{"name": "/Compute-test/test/db-egress-acl", "enabledFlag": false}]}`,
+		"/network/v1/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web-egress", "acl": "/Compute-test/test/web-acl", "flowDirection": "egress", "enabledFlag": true,
+			 "srcVnicSet": "/Compute-test/test/web"},
+			{"name": "/Compute-test/test/web-ingress-https", "acl": "/Compute-test/test/web-acl", "flowDirection": "ingress", "enabledFlag": true,
+			 "srcIpAddressPrefixSets": ["/Compute-test/test/public-internet"], "secProtocols": ["/Compute-test/test/https"]},
+			{"name": "/Compute-test/test/web-ingress-ssh", "acl": "/Compute-test/test/web-acl", "flowDirection": "ingress", "enabledFlag": true,
+			 "srcIpAddressPrefixSets": ["/Compute-test/test/office"], "secProtocols": ["/Compute-test/test/ssh"]},
+			{"name": "/Compute-test/test/db-ingress-pgsql", "acl": "/Compute-test/test/db-acl", "flowDirection": "ingress", "enabledFlag": true,
+			 "srcVnicSet": "/Compute-test/test/web", "dstVnicSet": "/Compute-test/test/db", "secProtocols": ["/Compute-test/dba/pgsql"]},
+			{"name": "/Compute-test/test/db-ingress-ssh", "acl": "/Compute-test/test/db-acl", "flowDirection": "ingress", "enabledFlag": false,
+			 "srcIpAddressPrefixSets": ["/Compute-test/test/public-internet"], "secProtocols": ["/Compute-test/test/ssh"]},
+			{"name": "/Compute-test/test/db-egress", "acl": "/Compute-test/test/db-egress-acl", "flowDirection": "egress", "enabledFlag": true}]}`,
+		"/network/v1/secprotocol/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/https", "ipProtocol": "tcp", "dstPortSet": ["443"]},
+			{"name": "/Compute-test/test/ssh", "ipProtocol": "tcp", "dstPortSet": ["22"]}]}`,
+		// Owned by another user, so loaded separately
+		"/network/v1/secprotocol/Compute-test/dba/pgsql": `{"name": "/Compute-test/dba/pgsql", "ipProtocol": "tcp", "dstPortSet": ["5432-5433"]}`,
+		"/network/v1/ipaddressprefixset/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/public-internet", "ipAddressPrefixes": ["0.0.0.0/0"]},
+			{"name": "/Compute-test/test/office", "ipAddressPrefixes": ["203.0.113.0/24"]}]}`,
+		"/network/v1/vnicset/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web", "appliedAcls": ["/Compute-test/test/web-acl"], "vnics": ["/Compute-test/test/web_eth0"]},
+			{"name": "/Compute-test/test/db", "appliedAcls": ["/Compute-test/test/db-acl", "/Compute-test/test/db-egress-acl"], "vnics": ["/Compute-test/test/db_eth0"]},
+			{"name": "/Compute-test/test/batch", "vnics": ["/Compute-test/test/batch_eth0"]}]}`,
+		"/instance/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web/1", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "ip": "192.168.1.2", "vnic": "/Compute-test/test/web_eth0"}}},
+			{"name": "/Compute-test/test/db/2", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "ip": "192.168.1.3", "vnic": "/Compute-test/test/db_eth0"}}},
+			{"name": "/Compute-test/test/batch/3", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/batch_eth0"}}}]}`,
+	}
+}
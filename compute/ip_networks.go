@@ -1,5 +1,7 @@
 package compute
 
+import "fmt"
+
 const (
 	iPNetworkDescription   = "ip network"
 	iPNetworkContainerPath = "/network/v1/ipnetwork/"
@@ -88,6 +90,9 @@ type CreateIPNetworkInput struct {
 // CreateIPNetwork creates a new IP Network from an IPNetworksClient and an input struct.
 // Returns a populated Info struct for the IP Network, and any errors
 func (c *IPNetworksClient) CreateIPNetwork(input *CreateIPNetworkInput) (*IPNetworkInfo, error) {
+	if _, err := ParseIPNetworkPrefix(input.IPAddressPrefix); err != nil {
+		return nil, fmt.Errorf("Invalid IP address prefix of IP network %s: %s", input.Name, err)
+	}
 	input.Name = c.getQualifiedName(input.Name)
 	input.IPNetworkExchange = c.getQualifiedName(input.IPNetworkExchange)
 
@@ -162,6 +167,9 @@ type UpdateIPNetworkInput struct {
 
 // UpdateIPNetwork updates the specified ip network
 func (c *IPNetworksClient) UpdateIPNetwork(input *UpdateIPNetworkInput) (*IPNetworkInfo, error) {
+	if _, err := ParseIPNetworkPrefix(input.IPAddressPrefix); err != nil {
+		return nil, fmt.Errorf("Invalid IP address prefix of IP network %s: %s", input.Name, err)
+	}
 	input.Name = c.getQualifiedName(input.Name)
 	input.IPNetworkExchange = c.getQualifiedName(input.IPNetworkExchange)
 
@@ -185,6 +193,26 @@ func (c *IPNetworksClient) DeleteIPNetwork(input *DeleteIPNetworkInput) error {
 	return c.deleteResource(input.Name)
 }
 
+// IPNetworkList represents the list of IP networks returned by the service
+type IPNetworkList struct {
+	Result []IPNetworkInfo `json:"result"`
+}
+
+// ListIPNetworks returns the IPNetworkInfo structs of the client's owner
+func (c *IPNetworksClient) ListIPNetworks() ([]IPNetworkInfo, error) {
+	var list IPNetworkList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 // Unqualifies any qualified fields in the IPNetworkInfo struct
 func (c *IPNetworksClient) success(info *IPNetworkInfo) (*IPNetworkInfo, error) {
 	c.unqualify(&info.Name)
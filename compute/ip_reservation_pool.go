@@ -0,0 +1,386 @@
+package compute
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// IPReservationTemporaryTag marks the IP reservations and IP address reservations that are released once
+	// they've been unassociated for a while
+	IPReservationTemporaryTag = "temporary"
+	// IPReservationUnusedSinceTag prefixes the tag that records since when a temporary reservation is unassociated
+	IPReservationUnusedSinceTag = "unused-since="
+)
+
+// PooledIPReservation is a reservation of a public IP address, either a permanent IP reservation of the shared
+// network or an IP address reservation of IP networks
+type PooledIPReservation struct {
+	// The name of the IP reservation or IP address reservation
+	Name string
+	// The reserved IP address
+	IPAddress string
+	// The network of the reservation: shared for IP reservations, or ipnetwork for IP address reservations
+	Network string
+	// The pool the IP address is reserved from, such as /oracle/public/ippool or public-ippool
+	Pool string
+	// Whether the reservation is associated with a vcable or vNIC
+	Associated bool
+	// Whether the reservation is tagged as temporary
+	Temporary bool
+	// Since when a temporary reservation is unassociated, as recorded by ReleaseIPReservations. Zero if unknown.
+	UnusedSince time.Time
+	// The tags of the reservation
+	Tags []string
+}
+
+func (r *PooledIPReservation) String() string {
+	if r.Network == PublicExposureNetworkIP {
+		return fmt.Sprintf("IP address reservation %s (%s)", r.Name, r.IPAddress)
+	}
+	return fmt.Sprintf("IP reservation %s (%s)", r.Name, r.IPAddress)
+}
+
+// ListPooledIPReservations lists the permanent IP reservations of the shared network and the IP address reservations
+// of IP networks of the owner of the client, and whether they're associated, sorted by network and name.
+// Reservations that aren't permanent are left out, as they're released with the instance they're associated with.
+func (c *Client) ListPooledIPReservations() ([]PooledIPReservation, error) {
+	pooled := []PooledIPReservation{}
+
+	reservations, err := c.IPReservations().ListIPReservations()
+	if err != nil {
+		return nil, err
+	}
+	associations, err := c.IPAssociations().ListIPAssociations()
+	if err != nil {
+		return nil, err
+	}
+	for _, reservation := range reservations {
+		if !reservation.Permanent {
+			continue
+		}
+		associated := reservation.Used
+		for i := range associations {
+			if c.ipAssociationOfReservation(&associations[i], reservation.Name) {
+				associated = true
+			}
+		}
+		pooled = append(pooled, newPooledIPReservation(reservation.Name, reservation.IP, PublicExposureNetworkShared,
+			string(reservation.ParentPool), associated, reservation.Tags))
+	}
+
+	addressReservations, err := c.IPAddressReservations().ListIPAddressReservations()
+	if err != nil {
+		return nil, err
+	}
+	addressAssociations, err := c.IPAddressAssociations().ListIPAddressAssociations()
+	if err != nil {
+		return nil, err
+	}
+	for _, reservation := range addressReservations {
+		associated := false
+		for _, association := range addressAssociations {
+			if association.IPAddressReservation == reservation.Name {
+				associated = true
+			}
+		}
+		pooled = append(pooled, newPooledIPReservation(reservation.Name, reservation.IPAddress, PublicExposureNetworkIP,
+			reservation.IPAddressPool, associated, reservation.Tags))
+	}
+
+	sort.SliceStable(pooled, func(i, j int) bool {
+		if pooled[i].Network != pooled[j].Network {
+			return pooled[i].Network > pooled[j].Network
+		}
+		return pooled[i].Name < pooled[j].Name
+	})
+	return pooled, nil
+}
+
+// ListUnassociatedIPReservations lists the pooled reservations that aren't associated with a vcable or vNIC, such as
+// the ones left behind by deleted instances
+func (c *Client) ListUnassociatedIPReservations() ([]PooledIPReservation, error) {
+	pooled, err := c.ListPooledIPReservations()
+	if err != nil {
+		return nil, err
+	}
+	unassociated := []PooledIPReservation{}
+	for _, reservation := range pooled {
+		if !reservation.Associated {
+			unassociated = append(unassociated, reservation)
+		}
+	}
+	return unassociated, nil
+}
+
+func newPooledIPReservation(name, ipAddress, network, pool string, associated bool, tags []string) PooledIPReservation {
+	reservation := PooledIPReservation{
+		Name:       name,
+		IPAddress:  ipAddress,
+		Network:    network,
+		Pool:       pool,
+		Associated: associated,
+		Temporary:  hasTag(tags, IPReservationTemporaryTag),
+		Tags:       tags,
+	}
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, IPReservationUnusedSinceTag) {
+			continue
+		}
+		if since, err := time.Parse(time.RFC3339, strings.TrimPrefix(tag, IPReservationUnusedSinceTag)); err == nil {
+			reservation.UnusedSince = since.UTC()
+		}
+	}
+	return reservation
+}
+
+// AllocateIPReservationInput describes the reservation to allocate
+type AllocateIPReservationInput struct {
+	// The network of the reservation: shared for an IP reservation, or ipnetwork for an IP address reservation
+	// Required
+	Network string
+	// The pool of the reservation: /oracle/public/ippool on the shared network, or public-ippool or cloud-ippool
+	// on IP networks
+	// Optional, defaults to the public pool of the network
+	Pool string
+	// Only a free reservation with all of these tags is allocated. A new reservation is created with them.
+	// Optional
+	Tags []string
+	// Whether the reservation is temporary. Only a free reservation that is temporary too is allocated.
+	// Optional
+	Temporary bool
+	// The name of the reservation to create if none is free
+	// Optional on the shared network, required on IP networks
+	Name string
+}
+
+// AllocateIPReservation returns a free reservation of the pool with the tags, or creates one if none is free.
+// Free reservations are allocated in order of name. The reservation is allocated once it's associated, so
+// concurrent callers may get the same free reservation.
+func (c *Client) AllocateIPReservation(input *AllocateIPReservationInput) (*PooledIPReservation, error) {
+	pool := input.Pool
+	switch input.Network {
+	case PublicExposureNetworkShared:
+		if pool == "" {
+			pool = string(PublicReservationPool)
+		}
+	case PublicExposureNetworkIP:
+		if pool == "" {
+			pool = PublicIPAddressPool
+		}
+	default:
+		return nil, fmt.Errorf("Unknown network %q, must be %s or %s", input.Network, PublicExposureNetworkShared, PublicExposureNetworkIP)
+	}
+
+	unassociated, err := c.ListUnassociatedIPReservations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range unassociated {
+		reservation := &unassociated[i]
+		if reservation.Network != input.Network || reservation.Pool != pool || reservation.Temporary != input.Temporary {
+			continue
+		}
+		free := true
+		for _, tag := range input.Tags {
+			if !hasTag(reservation.Tags, tag) {
+				free = false
+			}
+		}
+		if !free {
+			continue
+		}
+		// Keep the reservation from being released before it's associated
+		if !reservation.UnusedSince.IsZero() {
+			if err := c.tagPooledIPReservation(reservation, time.Time{}); err != nil {
+				return nil, err
+			}
+		}
+		return reservation, nil
+	}
+
+	tags := append([]string{}, input.Tags...)
+	if input.Temporary && !hasTag(tags, IPReservationTemporaryTag) {
+		tags = append(tags, IPReservationTemporaryTag)
+	}
+	if input.Network == PublicExposureNetworkShared {
+		createInput := &CreateIPReservationInput{
+			Name:       input.Name,
+			ParentPool: IPReservationPool(pool),
+			Permanent:  true,
+			Tags:       tags,
+		}
+		reservation, err := c.IPReservations().CreateIPReservation(createInput)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating IP reservation: %s", err)
+		}
+		created := newPooledIPReservation(reservation.Name, reservation.IP, PublicExposureNetworkShared,
+			string(reservation.ParentPool), false, reservation.Tags)
+		return &created, nil
+	}
+
+	if input.Name == "" {
+		return nil, fmt.Errorf("No IP address reservation of %s is free, and no name was given to create one", pool)
+	}
+	createInput := &CreateIPAddressReservationInput{
+		Name:          input.Name,
+		IPAddressPool: pool,
+		Tags:          tags,
+	}
+	reservation, err := c.IPAddressReservations().CreateIPAddressReservation(createInput)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating IP address reservation %s: %s", input.Name, err)
+	}
+	created := newPooledIPReservation(reservation.Name, reservation.IPAddress, PublicExposureNetworkIP,
+		reservation.IPAddressPool, false, reservation.Tags)
+	return &created, nil
+}
+
+// ReleaseIPReservationsInput specifies when temporary reservations are released
+type ReleaseIPReservationsInput struct {
+	// Temporary reservations are released once they've been unassociated for this long
+	// Required
+	UnusedFor time.Duration
+	// If true, the release is returned without tagging or deleting any reservations
+	// Optional
+	DryRun bool
+	// Returns the current time. Defaults to time.Now, and can be replaced in tests.
+	// Optional
+	Clock func() time.Time
+}
+
+// IPReservationRelease details the temporary reservations that ReleaseIPReservations tags and deletes
+type IPReservationRelease struct {
+	// The reservations found unassociated for the first time, which are tagged with the time
+	Marked []PooledIPReservation
+	// The reservations that were associated again, which are no longer tagged with a time
+	Unmarked []PooledIPReservation
+	// The reservations that were deleted
+	Released []PooledIPReservation
+}
+
+// ReleaseIPReservations deletes the reservations tagged as temporary that have been unassociated for longer than
+// UnusedFor. Reservations don't record when they were last associated, so the time is counted from the first run
+// that finds a reservation unassociated, and recorded in its tags. It's meant to be run periodically, such as
+// every hour. Returns the release that was applied.
+func (c *Client) ReleaseIPReservations(input *ReleaseIPReservationsInput) (*IPReservationRelease, error) {
+	if input.UnusedFor <= 0 {
+		return nil, fmt.Errorf("The period temporary IP reservations are unused for before they're released must be positive")
+	}
+	now := time.Now
+	if input.Clock != nil {
+		now = input.Clock
+	}
+	at := now().UTC().Truncate(time.Second)
+
+	pooled, err := c.ListPooledIPReservations()
+	if err != nil {
+		return nil, err
+	}
+
+	release := &IPReservationRelease{
+		Marked:   []PooledIPReservation{},
+		Unmarked: []PooledIPReservation{},
+		Released: []PooledIPReservation{},
+	}
+	for _, reservation := range pooled {
+		switch {
+		case !reservation.Temporary:
+			continue
+		case reservation.Associated:
+			if !reservation.UnusedSince.IsZero() {
+				release.Unmarked = append(release.Unmarked, reservation)
+			}
+		case reservation.UnusedSince.IsZero():
+			release.Marked = append(release.Marked, reservation)
+		case !at.Before(reservation.UnusedSince.Add(input.UnusedFor)):
+			release.Released = append(release.Released, reservation)
+		}
+	}
+	if input.DryRun {
+		return release, nil
+	}
+
+	for i := range release.Marked {
+		if err := c.tagPooledIPReservation(&release.Marked[i], at); err != nil {
+			return release, err
+		}
+	}
+	for i := range release.Unmarked {
+		if err := c.tagPooledIPReservation(&release.Unmarked[i], time.Time{}); err != nil {
+			return release, err
+		}
+	}
+	for _, reservation := range release.Released {
+		if err := c.deletePooledIPReservation(&reservation); err != nil {
+			return release, err
+		}
+	}
+	return release, nil
+}
+
+// Replaces the time a reservation is unassociated since in its tags, or removes it for the zero time.
+// The reservation is read again, so that none of its other attributes are lost in the update.
+func (c *Client) tagPooledIPReservation(reservation *PooledIPReservation, since time.Time) error {
+	tags := func(current []string) []string {
+		updated := []string{}
+		for _, tag := range current {
+			if !strings.HasPrefix(tag, IPReservationUnusedSinceTag) {
+				updated = append(updated, tag)
+			}
+		}
+		if !since.IsZero() {
+			updated = append(updated, IPReservationUnusedSinceTag+since.Format(time.RFC3339))
+		}
+		return updated
+	}
+
+	if reservation.Network == PublicExposureNetworkShared {
+		current, err := c.IPReservations().GetIPReservation(&GetIPReservationInput{Name: reservation.Name})
+		if err != nil {
+			return err
+		}
+		updateInput := &UpdateIPReservationInput{
+			Name:       current.Name,
+			ParentPool: current.ParentPool,
+			Permanent:  current.Permanent,
+			Tags:       tags(current.Tags),
+		}
+		if _, err := c.IPReservations().UpdateIPReservation(updateInput); err != nil {
+			return fmt.Errorf("Error tagging %s: %s", reservation, err)
+		}
+		reservation.Tags = updateInput.Tags
+	} else {
+		current, err := c.IPAddressReservations().GetIPAddressReservation(&GetIPAddressReservationInput{Name: reservation.Name})
+		if err != nil {
+			return err
+		}
+		updateInput := &UpdateIPAddressReservationInput{
+			Name:          current.Name,
+			Description:   current.Description,
+			IPAddressPool: current.IPAddressPool,
+			Tags:          tags(current.Tags),
+		}
+		if _, err := c.IPAddressReservations().UpdateIPAddressReservation(updateInput); err != nil {
+			return fmt.Errorf("Error tagging %s: %s", reservation, err)
+		}
+		reservation.Tags = updateInput.Tags
+	}
+	reservation.UnusedSince = since
+	return nil
+}
+
+func (c *Client) deletePooledIPReservation(reservation *PooledIPReservation) error {
+	var err error
+	if reservation.Network == PublicExposureNetworkShared {
+		err = c.IPReservations().DeleteIPReservation(&DeleteIPReservationInput{Name: reservation.Name})
+	} else {
+		err = c.IPAddressReservations().DeleteIPAddressReservation(&DeleteIPAddressReservationInput{Name: reservation.Name})
+	}
+	if err != nil {
+		return fmt.Errorf("Error releasing %s: %s", reservation, err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package compute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestClient_ListPooledIPReservations(t *testing.T) {
+	client, _, closeServer := newStubIPReservationPoolServer(t)
+	defer closeServer()
+
+	pooled, err := client.ListPooledIPReservations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unusedSince := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expected := []PooledIPReservation{
+		{
+			Name:      "temp-ip",
+			IPAddress: "192.0.2.2",
+			Network:   PublicExposureNetworkShared,
+			Pool:      "/oracle/public/ippool",
+			Temporary: true,
+			Tags:      []string{"temporary"},
+		},
+		{
+			Name:       "web-ip",
+			IPAddress:  "192.0.2.1",
+			Network:    PublicExposureNetworkShared,
+			Pool:       "/oracle/public/ippool",
+			Associated: true,
+		},
+		{
+			Name:        "busy-public",
+			IPAddress:   "198.51.100.4",
+			Network:     PublicExposureNetworkIP,
+			Pool:        PublicIPAddressPool,
+			Associated:  true,
+			Temporary:   true,
+			UnusedSince: unusedSince,
+			Tags:        []string{"temporary", "unused-since=2026-10-01T00:00:00Z"},
+		},
+		{
+			Name:      "spare-public",
+			IPAddress: "198.51.100.2",
+			Network:   PublicExposureNetworkIP,
+			Pool:      PublicIPAddressPool,
+		},
+		{
+			Name:        "temp-public",
+			IPAddress:   "198.51.100.3",
+			Network:     PublicExposureNetworkIP,
+			Pool:        PublicIPAddressPool,
+			Temporary:   true,
+			UnusedSince: unusedSince,
+			Tags:        []string{"temporary", "unused-since=2026-10-01T00:00:00Z"},
+		},
+		{
+			Name:       "web-public",
+			IPAddress:  "198.51.100.1",
+			Network:    PublicExposureNetworkIP,
+			Pool:       PublicIPAddressPool,
+			Associated: true,
+		},
+	}
+	if diff := pretty.Compare(pooled, expected); diff != "" {
+		t.Errorf("Pooled Diff: (-got +want)\n%s", diff)
+	}
+
+	unassociated, err := client.ListUnassociatedIPReservations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, reservation := range unassociated {
+		got = append(got, reservation.String())
+	}
+	expectedUnassociated := []string{
+		"IP reservation temp-ip (192.0.2.2)",
+		"IP address reservation spare-public (198.51.100.2)",
+		"IP address reservation temp-public (198.51.100.3)",
+	}
+	if diff := pretty.Compare(got, expectedUnassociated); diff != "" {
+		t.Errorf("Unassociated Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient_AllocateIPReservation(t *testing.T) {
+	client, requests, closeServer := newStubIPReservationPoolServer(t)
+	defer closeServer()
+
+	allocations := []struct {
+		input    *AllocateIPReservationInput
+		expected string
+	}{
+		{&AllocateIPReservationInput{Network: PublicExposureNetworkShared, Temporary: true}, "IP reservation temp-ip (192.0.2.2)"},
+		{&AllocateIPReservationInput{Network: PublicExposureNetworkIP}, "IP address reservation spare-public (198.51.100.2)"},
+		{&AllocateIPReservationInput{Network: PublicExposureNetworkIP, Temporary: true}, "IP address reservation temp-public (198.51.100.3)"},
+		{&AllocateIPReservationInput{Network: PublicExposureNetworkShared, Name: "new-ip"}, "IP reservation new-ip ()"},
+		{&AllocateIPReservationInput{Network: PublicExposureNetworkIP, Tags: []string{"db"}, Name: "db-public"}, "IP address reservation db-public ()"},
+	}
+	for _, allocation := range allocations {
+		reservation, err := client.AllocateIPReservation(allocation.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation.String() != allocation.expected {
+			t.Errorf("Expected %s to be allocated, got %s", allocation.expected, reservation)
+		}
+		if !reservation.UnusedSince.IsZero() {
+			t.Errorf("Expected %s to no longer be tagged as unused", reservation)
+		}
+	}
+
+	expectedRequests := []string{
+		"PUT /network/v1/ipreservation/Compute-test/test/temp-public",
+		"POST /ip/reservation/",
+		"POST /network/v1/ipreservation/",
+	}
+	if diff := pretty.Compare(*requests, expectedRequests); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+
+	if _, err := client.AllocateIPReservation(&AllocateIPReservationInput{Network: PublicExposureNetworkIP, Pool: PrivateIPAddressPool}); err == nil {
+		t.Errorf("Expected an error creating an IP address reservation without a name")
+	}
+	if _, err := client.AllocateIPReservation(&AllocateIPReservationInput{Network: "private"}); err == nil {
+		t.Errorf("Expected an error allocating a reservation of an unknown network")
+	}
+}
+
+func TestClient_ReleaseIPReservations(t *testing.T) {
+	client, requests, closeServer := newStubIPReservationPoolServer(t)
+	defer closeServer()
+
+	now := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	input := &ReleaseIPReservationsInput{
+		UnusedFor: 24 * time.Hour,
+		DryRun:    true,
+		Clock:     func() time.Time { return now },
+	}
+	release, err := client.ReleaseIPReservations(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"Marked":   {"IP reservation temp-ip (192.0.2.2)"},
+		"Unmarked": {"IP address reservation busy-public (198.51.100.4)"},
+		"Released": {"IP address reservation temp-public (198.51.100.3)"},
+	}
+	if diff := pretty.Compare(describeIPReservationRelease(release), expected); diff != "" {
+		t.Errorf("Release Diff: (-got +want)\n%s", diff)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("Expected no changes in a dry run, got %v", *requests)
+	}
+
+	input.DryRun = false
+	if _, err = client.ReleaseIPReservations(input); err != nil {
+		t.Fatal(err)
+	}
+	expectedRequests := []string{
+		"PUT /ip/reservation/Compute-test/test/temp-ip",
+		"PUT /network/v1/ipreservation/Compute-test/test/busy-public",
+		"DELETE /network/v1/ipreservation/Compute-test/test/temp-public",
+	}
+	if diff := pretty.Compare(*requests, expectedRequests); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+
+	// temp-ip is released once it's been unused for a day since it was marked
+	now = now.Add(23 * time.Hour)
+	if release, err = client.ReleaseIPReservations(input); err != nil {
+		t.Fatal(err)
+	}
+	if len(release.Released) != 0 {
+		t.Errorf("Expected nothing to be released yet, got %v", release.Released)
+	}
+	now = now.Add(time.Hour)
+	if release, err = client.ReleaseIPReservations(input); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string][]string{
+		"Marked":   {},
+		"Unmarked": {},
+		"Released": {"IP reservation temp-ip (192.0.2.2)"},
+	}
+	if diff := pretty.Compare(describeIPReservationRelease(release), expected); diff != "" {
+		t.Errorf("Release Diff: (-got +want)\n%s", diff)
+	}
+
+	if _, err := client.ReleaseIPReservations(&ReleaseIPReservationsInput{}); err == nil {
+		t.Errorf("Expected an error releasing reservations without a period")
+	}
+}
+
+func describeIPReservationRelease(release *IPReservationRelease) map[string][]string {
+	describe := func(reservations []PooledIPReservation) []string {
+		described := []string{}
+		for _, reservation := range reservations {
+			described = append(described, reservation.String())
+		}
+		return described
+	}
+	return map[string][]string{
+		"Marked":   describe(release.Marked),
+		"Unmarked": describe(release.Unmarked),
+		"Released": describe(release.Released),
+	}
+}
+
+// Returns a client of a stub server that stores IP reservations and associations of both networks, some of them
+// temporary, and records the requests that change them
+func newStubIPReservationPoolServer(t *testing.T) (*Client, *[]string, func()) {
+	store := newStubObjectStore(t)
+	store.load("/ip/reservation", `{"result": [
+		{"name": "/Compute-test/test/web-ip", "ip": "192.0.2.1", "parentpool": "/oracle/public/ippool", "permanent": true, "used": true},
+		{"name": "/Compute-test/test/temp-ip", "ip": "192.0.2.2", "parentpool": "/oracle/public/ippool", "permanent": true, "tags": ["temporary"]},
+		{"name": "/Compute-test/test/dynamic-ip", "ip": "192.0.2.3", "parentpool": "/oracle/public/ippool", "used": true}]}`)
+	store.load("/ip/association", `{"result": [
+		{"name": "/Compute-test/test/web-ip-association", "reservation": "/Compute-test/test/web-ip",
+		 "parentpool": "ipreservation:/Compute-test/test/web-ip", "vcable": "/Compute-test/test/vcable-1"}]}`)
+	store.load("/network/v1/ipreservation", `{"result": [
+		{"name": "/Compute-test/test/web-public", "ipAddress": "198.51.100.1", "ipAddressPool": "/oracle/public/public-ippool"},
+		{"name": "/Compute-test/test/spare-public", "ipAddress": "198.51.100.2", "ipAddressPool": "/oracle/public/public-ippool"},
+		{"name": "/Compute-test/test/temp-public", "ipAddress": "198.51.100.3", "ipAddressPool": "/oracle/public/public-ippool",
+		 "description": "Build agent", "tags": ["temporary", "unused-since=2026-10-01T00:00:00Z"]},
+		{"name": "/Compute-test/test/busy-public", "ipAddress": "198.51.100.4", "ipAddressPool": "/oracle/public/public-ippool",
+		 "tags": ["temporary", "unused-since=2026-10-01T00:00:00Z"]}]}`)
+	store.load("/network/v1/ipassociation", `{"result": [
+		{"name": "/Compute-test/test/web-public", "ipAddressReservation": "/Compute-test/test/web-public", "vnic": "/Compute-test/test/web1_eth0"},
+		{"name": "/Compute-test/test/busy-public", "ipAddressReservation": "/Compute-test/test/busy-public", "vnic": "/Compute-test/test/web2_eth1"}]}`)
+
+	client, closeServer := store.newClient()
+	return client, &store.requests, closeServer
+}
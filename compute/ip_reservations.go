@@ -143,6 +143,26 @@ func (c *IPReservationsClient) DeleteIPReservation(input *DeleteIPReservationInp
 	return c.deleteResource(input.Name)
 }
 
+// IPReservationList represents the list of IP reservations returned by the service
+type IPReservationList struct {
+	Result []IPReservation `json:"result"`
+}
+
+// ListIPReservations retrieves the IP reservations of the client's owner.
+func (c *IPReservationsClient) ListIPReservations() ([]IPReservation, error) {
+	var list IPReservationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *IPReservationsClient) success(result *IPReservation) (*IPReservation, error) {
 	c.unqualify(&result.Name)
 	return result, nil
@@ -0,0 +1,335 @@
+package compute
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/storage"
+)
+
+const waitForMachineImageAvailablePollInterval = 10 * time.Second
+const waitForMachineImageAvailableTimeout = 3600 * time.Second
+
+// MachineImageContainer is the Object Storage Classic container that machine image files are read from
+const MachineImageContainer = "compute_images"
+
+// defaultMachineImageSegmentSize is the default size of the segments a large machine image file is uploaded in.
+// Object Storage Classic limits a single object to 5GB.
+const defaultMachineImageSegmentSize = 1024 * 1024 * 1024
+
+// MachineImageState specifies the constants that a machine image state can be in
+type MachineImageState string
+
+const (
+	// MachineImageAvailable - available
+	MachineImageAvailable MachineImageState = "available"
+	// MachineImageError - error
+	MachineImageError MachineImageState = "error"
+)
+
+// ImportMachineImageInput specifies the parameters needed to import a custom machine image
+type ImportMachineImageInput struct {
+	// Path of the local .tar.gz file containing a single raw disk image.
+	// Required
+	File string
+	// The name of the machine image to create.
+	// Required
+	Name string
+	// The name of the Image List to publish the machine image in. The Image List must exist.
+	// Required
+	ImageList string
+	// The version of the new image list entry. Defaults to one more than the latest entry.
+	// Optional
+	ImageListVersion int
+	// The name of the object to upload to the compute_images container. Defaults to the base name of File.
+	// Optional
+	ObjectName string
+	// The account of the associated Object Storage Classic instance.
+	// Defaults to /Compute-<identity_domain>/cloud_storage.
+	// Optional
+	Account string
+	// Describing the image
+	// Optional
+	Description string
+	// Dictionary of attributes to be made available to the instance
+	// Optional
+	Attributes map[string]interface{}
+	// Files larger than this size, in bytes, are uploaded as a segmented large object. Defaults to 1GB.
+	// Optional
+	SegmentSize int64
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for the machine image to be available
+	Timeout time.Duration
+}
+
+// ImportMachineImageOutput details the objects created by importing a machine image
+type ImportMachineImageOutput struct {
+	// The uploaded machine image file
+	Object *storage.ObjectInfo
+	// The registered machine image
+	MachineImage *MachineImage
+	// The image list entry the machine image was published as
+	ImageListEntry *ImageListEntryInfo
+}
+
+// ImportMachineImage uploads a machine image file to Object Storage Classic, registers it as a machine image,
+// waits for it to be available and publishes it as a new image list entry. Anything created is removed again
+// if a later step fails.
+func (c *MachineImagesClient) ImportMachineImage(objectClient *storage.ObjectClient, input *ImportMachineImageInput) (*ImportMachineImageOutput, error) {
+	if input.Name == "" || input.ImageList == "" {
+		return nil, fmt.Errorf("Both machine image name and image list need to be specified")
+	}
+	if err := ValidateMachineImageFile(input.File); err != nil {
+		return nil, err
+	}
+
+	if input.ObjectName == "" {
+		input.ObjectName = filepath.Base(input.File)
+	}
+	if input.Account == "" {
+		input.Account = fmt.Sprintf("%s/cloud_storage", c.getACME())
+	}
+	if input.SegmentSize == 0 {
+		input.SegmentSize = defaultMachineImageSegmentSize
+	}
+	if input.PollInterval == 0 {
+		input.PollInterval = waitForMachineImageAvailablePollInterval
+	}
+	if input.Timeout == 0 {
+		input.Timeout = waitForMachineImageAvailableTimeout
+	}
+
+	output := &ImportMachineImageOutput{}
+	segments, object, err := c.uploadMachineImageFile(objectClient, input)
+	uploaded := segments
+	if object != nil {
+		// Delete the manifest before the segments it refers to
+		uploaded = append([]string{input.ObjectName}, segments...)
+	}
+	if err != nil {
+		return nil, c.cleanupImport(objectClient, uploaded, "", err)
+	}
+	output.Object = object
+
+	createInput := &CreateMachineImageInput{
+		Account:     input.Account,
+		Name:        input.Name,
+		File:        input.ObjectName,
+		Description: input.Description,
+		Attributes:  input.Attributes,
+	}
+	if _, err = c.CreateMachineImage(createInput); err != nil {
+		return nil, c.cleanupImport(objectClient, uploaded, "", err)
+	}
+
+	getInput := &GetMachineImageInput{
+		Account: input.Account,
+		Name:    input.Name,
+	}
+	if output.MachineImage, err = c.WaitForMachineImageAvailable(getInput, input.PollInterval, input.Timeout); err != nil {
+		return nil, c.cleanupImport(objectClient, uploaded, input.Name, err)
+	}
+
+	if input.ImageListVersion == 0 {
+		imageList, err := c.Client.ImageList().GetImageList(&GetImageListInput{Name: input.ImageList})
+		if err != nil {
+			return nil, c.cleanupImport(objectClient, uploaded, input.Name, err)
+		}
+		input.ImageListVersion = 1
+		for _, entry := range imageList.Entries {
+			if entry.Version >= input.ImageListVersion {
+				input.ImageListVersion = entry.Version + 1
+			}
+		}
+	}
+
+	entryInput := &CreateImageListEntryInput{
+		Name:          input.ImageList,
+		MachineImages: []string{c.getQualifiedName(input.Name)},
+		Version:       input.ImageListVersion,
+	}
+	if output.ImageListEntry, err = c.Client.ImageListEntries().CreateImageListEntry(entryInput); err != nil {
+		return nil, c.cleanupImport(objectClient, uploaded, input.Name, err)
+	}
+
+	return output, nil
+}
+
+// Uploads the machine image file, in segments if it is larger than the segment size, and checks the uploaded object.
+// Returns the names of the segments that were uploaded, and the object if it was created, even if the upload fails.
+func (c *MachineImagesClient) uploadMachineImageFile(objectClient *storage.ObjectClient, input *ImportMachineImageInput) ([]string, *storage.ObjectInfo, error) {
+	file, err := os.Open(input.File)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fi.Size() <= input.SegmentSize {
+		object, err := objectClient.CreateObject(&storage.CreateObjectInput{
+			Name:        input.ObjectName,
+			Container:   MachineImageContainer,
+			ContentType: "application/tar+gzip",
+			Body:        file,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, object, checkMachineImageObject(object, fi.Size(), "")
+	}
+
+	segments := []string{}
+	segmentPrefix := fmt.Sprintf("%s_segments/", input.ObjectName)
+	for offset, i := int64(0), 1; offset < fi.Size(); offset, i = offset+input.SegmentSize, i+1 {
+		segment := fmt.Sprintf("%s%08d", segmentPrefix, i)
+		c.client.DebugLogString(fmt.Sprintf("Uploading machine image segment %s", segment))
+		_, err := objectClient.CreateObject(&storage.CreateObjectInput{
+			Name:      segment,
+			Container: MachineImageContainer,
+			Body:      io.NewSectionReader(file, offset, input.SegmentSize),
+		})
+		if err != nil {
+			return segments, nil, fmt.Errorf("Error uploading machine image segment %s: %s", segment, err)
+		}
+		segments = append(segments, segment)
+	}
+
+	manifest := fmt.Sprintf("%s/%s", MachineImageContainer, segmentPrefix)
+	object, err := objectClient.CreateObject(&storage.CreateObjectInput{
+		Name:           input.ObjectName,
+		Container:      MachineImageContainer,
+		ContentType:    "application/tar+gzip",
+		ObjectManifest: manifest,
+		Body:           bytes.NewReader([]byte{}),
+	})
+	if err != nil {
+		return segments, nil, err
+	}
+	return segments, object, checkMachineImageObject(object, fi.Size(), manifest)
+}
+
+// Checks that the uploaded machine image object has the size of the file, and refers to its segments if it was segmented.
+// The size of a segmented large object is the size of all of its segments.
+func checkMachineImageObject(object *storage.ObjectInfo, size int64, manifest string) error {
+	if object.ObjectManifest != manifest {
+		return fmt.Errorf("Uploaded machine image object %s has manifest %q, expected %q", object.Name, object.ObjectManifest, manifest)
+	}
+	if int64(object.ContentLength) != size {
+		return fmt.Errorf("Uploaded machine image object %s has %d bytes, expected %d", object.Name, object.ContentLength, size)
+	}
+	return nil
+}
+
+// Removes the objects and machine image created by a failed import, and returns the error that caused the failure
+func (c *MachineImagesClient) cleanupImport(objectClient *storage.ObjectClient, objects []string, machineImage string, importErr error) error {
+	cleanupErrors := []string{}
+	if machineImage != "" {
+		if err := c.DeleteMachineImage(&DeleteMachineImageInput{Name: machineImage}); err != nil {
+			cleanupErrors = append(cleanupErrors, fmt.Sprintf("machine image %s: %s", machineImage, err))
+		}
+	}
+	for _, object := range objects {
+		deleteInput := &storage.DeleteObjectInput{
+			Name:      object,
+			Container: MachineImageContainer,
+		}
+		if err := objectClient.DeleteObject(deleteInput); err != nil {
+			cleanupErrors = append(cleanupErrors, fmt.Sprintf("object %s: %s", object, err))
+		}
+	}
+
+	if len(cleanupErrors) > 0 {
+		return fmt.Errorf("Error importing machine image: %s (error cleaning up %s)", importErr, strings.Join(cleanupErrors, ", "))
+	}
+	return fmt.Errorf("Error importing machine image: %s", importErr)
+}
+
+// WaitForMachineImageAvailable waits for a machine image to be available
+func (c *MachineImagesClient) WaitForMachineImageAvailable(input *GetMachineImageInput, pollInterval, timeout time.Duration) (*MachineImage, error) {
+	var info *MachineImage
+	var getErr error
+	err := c.client.WaitFor("machine image to be available", pollInterval, timeout, func() (bool, error) {
+		info, getErr = c.GetMachineImage(input)
+		if getErr != nil {
+			return false, getErr
+		}
+		switch s := MachineImageState(info.State); s {
+		case MachineImageError:
+			return false, fmt.Errorf("Error creating machine image: %s", info.ErrorReason)
+		case MachineImageAvailable:
+			c.client.DebugLogString("Machine Image Available")
+			return true, nil
+		default:
+			c.client.DebugLogString(fmt.Sprintf("Machine image state: %s, waiting", s))
+			return false, nil
+		}
+	})
+	return info, err
+}
+
+// ValidateMachineImageFile checks that a local machine image file is a gzipped tar archive
+// containing a single raw disk image, as expected by Oracle Compute Cloud Service.
+// The disk image can have any name, such as disk.img or System.raw, but must not be empty.
+func ValidateMachineImageFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("Machine image file %s is not gzip compressed: %s", path, err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	images := []string{}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Machine image file %s is not a valid tar archive: %s", path, err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			name := strings.TrimPrefix(header.Name, "./")
+			if strings.Contains(name, "/") {
+				return fmt.Errorf("Machine image file %s must contain the disk image at the top level, found %s", path, header.Name)
+			}
+			if header.Size == 0 {
+				return fmt.Errorf("Machine image file %s contains an empty disk image %s", path, header.Name)
+			}
+			images = append(images, name)
+		default:
+			return fmt.Errorf("Machine image file %s must only contain a raw disk image, found %s", path, header.Name)
+		}
+	}
+
+	if len(images) != 1 {
+		return fmt.Errorf("Machine image file %s must contain a single raw disk image, found %d", path, len(images))
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package compute
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+	"github.com/hashicorp/go-oracle-terraform/storage"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestValidateMachineImageFile(t *testing.T) {
+	if err := ValidateMachineImageFile(filepath.Join(_TestFileFixturesPath, "dummy.tar.gz")); err != nil {
+		t.Fatalf("Expected the dummy machine image to be valid: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "machine-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The disk image doesn't need to be named .img
+	path := filepath.Join(dir, "raw.tar.gz")
+	writeTestTarball(t, path, map[string]string{"System.raw": "dummy disk"})
+	if err := ValidateMachineImageFile(path); err != nil {
+		t.Errorf("Expected raw.tar.gz to be a valid machine image file: %s", err)
+	}
+
+	invalid := map[string]map[string]string{
+		"empty.tar.gz":      {},
+		"two-disks.tar.gz":  {"disk1.img": "dummy disk", "disk2.img": "dummy disk"},
+		"empty-disk.tar.gz": {"disk.img": ""},
+		"nested.tar.gz":     {"images/disk.img": "dummy disk"},
+	}
+	for name, files := range invalid {
+		path := filepath.Join(dir, name)
+		writeTestTarball(t, path, files)
+		if err := ValidateMachineImageFile(path); err == nil {
+			t.Errorf("Expected %s to be an invalid machine image file", name)
+		}
+	}
+
+	if err := ValidateMachineImageFile(filepath.Join(_TestFileFixturesPath, "missing.tar.gz")); err == nil {
+		t.Errorf("Expected an error validating a missing file")
+	}
+}
+
+// Test that a machine image is uploaded in segments, registered and published.
+func TestMachineImagesClient_ImportMachineImage(t *testing.T) {
+	server := newMachineImageImportServer(t, false)
+	defer server.Close()
+
+	mClient, objectClient := getStubMachineImageImportClients(t, server.URL)
+
+	input := &ImportMachineImageInput{
+		File:         filepath.Join(_TestFileFixturesPath, "dummy.tar.gz"),
+		Name:         "test-image",
+		ImageList:    "test-list",
+		SegmentSize:  64,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	output, err := mClient.ImportMachineImage(objectClient, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.ImageListEntry.Version != 3 {
+		t.Errorf("Expected image list entry version 3, got %d", output.ImageListEntry.Version)
+	}
+	if output.MachineImage.State != string(MachineImageAvailable) {
+		t.Errorf("Expected machine image to be available, got %s", output.MachineImage.State)
+	}
+
+	expected := []string{
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz_segments/00000001",
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz_segments/00000002",
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz_segments/00000003",
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz manifest=compute_images/dummy.tar.gz_segments/",
+		"POST /machineimage/ file=dummy.tar.gz",
+		"POST /imagelist/Compute-test/test/test-list/entry/",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Import Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that everything created is removed again when publishing the machine image fails.
+func TestMachineImagesClient_ImportMachineImageCleanup(t *testing.T) {
+	server := newMachineImageImportServer(t, true)
+	defer server.Close()
+
+	mClient, objectClient := getStubMachineImageImportClients(t, server.URL)
+
+	input := &ImportMachineImageInput{
+		File:             filepath.Join(_TestFileFixturesPath, "dummy.tar.gz"),
+		Name:             "test-image",
+		ImageList:        "test-list",
+		ImageListVersion: 1,
+		PollInterval:     1 * time.Second,
+		Timeout:          10 * time.Second,
+	}
+	if _, err := mClient.ImportMachineImage(objectClient, input); err == nil {
+		t.Fatal("Expected an error importing the machine image")
+	}
+
+	expected := []string{
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz",
+		"POST /machineimage/ file=dummy.tar.gz",
+		"POST /imagelist/Compute-test/test/test-list/entry/",
+		"DELETE /machineimage/Compute-test/test/test-image",
+		"DELETE /v1/Storage-test/compute_images/dummy.tar.gz",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Import Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that an upload that doesn't have the size of the machine image file is removed again.
+func TestMachineImagesClient_ImportMachineImageTruncated(t *testing.T) {
+	server := newMachineImageImportServer(t, false)
+	server.truncate = true
+	defer server.Close()
+
+	mClient, objectClient := getStubMachineImageImportClients(t, server.URL)
+
+	input := &ImportMachineImageInput{
+		File:         filepath.Join(_TestFileFixturesPath, "dummy.tar.gz"),
+		Name:         "test-image",
+		ImageList:    "test-list",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := mClient.ImportMachineImage(objectClient, input); err == nil {
+		t.Fatal("Expected an error importing a truncated machine image")
+	}
+
+	expected := []string{
+		"PUT /v1/Storage-test/compute_images/dummy.tar.gz",
+		"DELETE /v1/Storage-test/compute_images/dummy.tar.gz",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Import Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+type machineImageImportServer struct {
+	URL     string
+	close   func()
+	lock    sync.Mutex
+	changed []string
+	// The sizes and manifests of the uploaded objects, by path
+	sizes     map[string]int
+	manifests map[string]string
+	// Whether uploaded objects lose their last byte
+	truncate bool
+}
+
+func (s *machineImageImportServer) Close() {
+	s.close()
+}
+
+func (s *machineImageImportServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves both the storage and compute requests of an import, recording every change made
+func newMachineImageImportServer(t *testing.T, failEntry bool) *machineImageImportServer {
+	s := &machineImageImportServer{
+		sizes:     map[string]int{},
+		manifests: map[string]string{},
+	}
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.URL.Path == "/auth/v1.0":
+			w.Header().Set("X-Auth-Token", "token")
+			return
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/v1/"):
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("Error reading %s: %s", r.URL.Path, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			s.sizes[r.URL.Path] = len(body)
+			if s.truncate && len(body) > 0 {
+				s.sizes[r.URL.Path]--
+			}
+			if manifest := r.Header.Get("X-Object-Manifest"); manifest != "" {
+				s.manifests[r.URL.Path] = manifest
+				change = change + " manifest=" + manifest
+			}
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/"):
+			size, ok := s.sizes[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if manifest := s.manifests[r.URL.Path]; manifest != "" {
+				// Like Object Storage, a segmented large object has the size of all of its segments
+				w.Header().Set("X-Object-Manifest", manifest)
+				for path, segmentSize := range s.sizes {
+					if strings.HasPrefix(path, "/v1/Storage-test/"+manifest) {
+						size += segmentSize
+					}
+				}
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			w.Write(make([]byte, size))
+		case r.Method == "POST" && r.URL.Path == "/machineimage/":
+			var body CreateMachineImageInput
+			unmarshalRequestBody(t, r, &body)
+			change = change + " file=" + body.File
+			w.Write([]byte(`{"name": "/Compute-test/test/test-image", "state": "pending"}`))
+		case r.Method == "GET" && r.URL.Path == "/machineimage/Compute-test/test/test-image":
+			w.Write([]byte(`{"name": "/Compute-test/test/test-image", "state": "available", "file": "dummy.tar.gz"}`))
+		case r.Method == "GET" && r.URL.Path == "/imagelist/Compute-test/test/test-list":
+			w.Write([]byte(`{"name": "/Compute-test/test/test-list", "default": 2, "entries": [{"version": 1}, {"version": 2}]}`))
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/imagelist/"):
+			if failEntry {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				var body CreateImageListEntryInput
+				unmarshalRequestBody(t, r, &body)
+				w.Write([]byte(`{"imagelist": "/Compute-test/test/test-list", "version": 3, "machineimages": ["/Compute-test/test/test-image"]}`))
+			}
+		}
+		if r.Method != "GET" && r.Method != "HEAD" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	s.URL = server.URL
+	s.close = server.Close
+	return s
+}
+
+func getStubMachineImageImportClients(t *testing.T, serverURL string) (*MachineImagesClient, *storage.ObjectClient) {
+	endpoint, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	sClient, err := storage.NewStorageClient(&opc.Config{
+		IdentityDomain: opc.String("test"),
+		Username:       opc.String("test"),
+		Password:       opc.String("test"),
+		APIEndpoint:    endpoint,
+		HTTPClient:     http.DefaultClient,
+	})
+	if err != nil {
+		t.Fatalf("err getting stub storage client: %s", err)
+	}
+
+	return client.MachineImages(), sClient.Objects()
+}
+
+// Writes a gzipped tar archive of the given files and their contents
+func writeTestTarball(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,138 @@
+package compute
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	objectNameDomainPrefix = "/Compute-"
+	objectNameOraclePrefix = "/oracle/"
+)
+
+// Security list types, which prefix the source and destination list names of a security rule
+const (
+	// SecurityListType prefixes the name of a security list, such as seclist:/Compute-mydomain/jane@example.com/web
+	SecurityListType = "seclist"
+	// SecurityIPListType prefixes the name of a security IP list, such as seciplist:/oracle/public/public-internet
+	SecurityIPListType = "seciplist"
+)
+
+// ObjectName is the name of an object in the Compute API. Qualified names are either owned by a user,
+// such as /Compute-mydomain/jane@example.com/web-server, owned by the identity domain, such as the
+// account /Compute-mydomain/default, or provided by Oracle, such as /oracle/public/OL_7.2_UEKR4_x86_64.
+// Names of the objects of the client's own user are usually given unqualified, such as web-server.
+type ObjectName struct {
+	// The identity domain that owns the object, without the Compute- prefix, such as mydomain.
+	// Empty for names provided by Oracle, and for unqualified names.
+	Domain string
+	// The user that owns the object, such as jane@example.com. Empty for objects owned by the
+	// identity domain. For names provided by Oracle, the namespace, such as public.
+	User string
+	// The name of the object, which has further parts for instances, in the form name/id,
+	// and for the objects of an orchestration, in the form orchestration/object.
+	Name string
+	// Whether the object is provided by Oracle, under /oracle
+	Oracle bool
+	// The list type prefixing the name in a security rule, such as seclist or seciplist
+	ListType string
+}
+
+// ParseObjectName parses a qualified or unqualified object name, optionally prefixed by a security list type
+func ParseObjectName(s string) (ObjectName, error) {
+	var name ObjectName
+	value := s
+	for _, listType := range []string{SecurityListType, SecurityIPListType} {
+		if strings.HasPrefix(value, listType+":") {
+			name.ListType = listType
+			value = strings.TrimPrefix(value, listType+":")
+			break
+		}
+	}
+	if value == "" {
+		return name, fmt.Errorf("Invalid object name %q: the name is empty", s)
+	}
+
+	switch {
+	case strings.HasPrefix(value, objectNameOraclePrefix):
+		parts := strings.SplitN(strings.TrimPrefix(value, objectNameOraclePrefix), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return name, fmt.Errorf("Invalid object name %q: expected /oracle/namespace/name", s)
+		}
+		name.Oracle = true
+		name.User = parts[0]
+		name.Name = parts[1]
+	case strings.HasPrefix(value, objectNameDomainPrefix):
+		parts := strings.SplitN(strings.TrimPrefix(value, objectNameDomainPrefix), "/", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+			return name, fmt.Errorf("Invalid object name %q: expected /Compute-domain/user/name", s)
+		}
+		name.Domain = parts[0]
+		if len(parts) == 2 {
+			name.Name = parts[1]
+		} else {
+			if parts[1] == "" {
+				return name, fmt.Errorf("Invalid object name %q: the user is empty", s)
+			}
+			name.User = parts[1]
+			name.Name = parts[2]
+		}
+	case strings.HasPrefix(value, "/"):
+		return name, fmt.Errorf("Invalid object name %q: qualified names start with /Compute- or /oracle/", s)
+	default:
+		name.Name = value
+	}
+
+	for _, part := range strings.Split(name.Name, "/") {
+		if part == "" {
+			return name, fmt.Errorf("Invalid object name %q: the name has an empty part", s)
+		}
+	}
+	return name, nil
+}
+
+// IsQualified returns whether the name identifies its owner, so it doesn't depend on the user of the client
+func (n ObjectName) IsQualified() bool {
+	return n.Oracle || n.Domain != ""
+}
+
+// Qualify returns the name qualified with the given identity domain and user, if it isn't qualified already
+func (n ObjectName) Qualify(domain, user string) ObjectName {
+	if n.IsQualified() {
+		return n
+	}
+	n.Domain = domain
+	n.User = user
+	return n
+}
+
+// Unqualify returns the name without its identity domain and user, if they're the given identity domain
+// and user. The names of objects owned by other users, by the identity domain or by Oracle stay qualified,
+// so they still identify the same object when qualified again.
+func (n ObjectName) Unqualify(domain, user string) ObjectName {
+	if n.Oracle || n.Domain != domain || n.User == "" || n.User != user {
+		return n
+	}
+	n.Domain = ""
+	n.User = ""
+	return n
+}
+
+// String formats the name, with its list type prefix if it has one
+func (n ObjectName) String() string {
+	var name string
+	switch {
+	case n.Oracle:
+		name = fmt.Sprintf("%s%s/%s", objectNameOraclePrefix, n.User, n.Name)
+	case n.Domain != "" && n.User != "":
+		name = fmt.Sprintf("%s%s/%s/%s", objectNameDomainPrefix, n.Domain, n.User, n.Name)
+	case n.Domain != "":
+		name = fmt.Sprintf("%s%s/%s", objectNameDomainPrefix, n.Domain, n.Name)
+	default:
+		name = n.Name
+	}
+	if n.ListType != "" {
+		return fmt.Sprintf("%s:%s", n.ListType, name)
+	}
+	return name
+}
@@ -0,0 +1,101 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseObjectName(t *testing.T) {
+	valid := map[string]ObjectName{
+		"web": {Name: "web"},
+		"web/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a": {Name: "web/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a"},
+		"/Compute-acme/jane@example.com/web":       {Domain: "acme", User: "jane@example.com", Name: "web"},
+		"/Compute-acme/jane@example.com/web/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a": {
+			Domain: "acme", User: "jane@example.com", Name: "web/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a",
+		},
+		"/Compute-acme/jane@example.com/orchestration/instance-1": {Domain: "acme", User: "jane@example.com", Name: "orchestration/instance-1"},
+		"/Compute-acme/default":                                   {Domain: "acme", Name: "default"},
+		"/oracle/public/OL_7.2_UEKR4_x86_64":                      {Oracle: true, User: "public", Name: "OL_7.2_UEKR4_x86_64"},
+		"/oracle/private/storage/snapshot/collocated":             {Oracle: true, User: "private", Name: "storage/snapshot/collocated"},
+		"seclist:web": {ListType: SecurityListType, Name: "web"},
+		"seclist:/Compute-acme/john@example.com/web": {ListType: SecurityListType, Domain: "acme", User: "john@example.com", Name: "web"},
+		"seciplist:/oracle/public/public-internet":   {ListType: SecurityIPListType, Oracle: true, User: "public", Name: "public-internet"},
+		// Only security list types are list prefixes
+		"ipreservation:web": {Name: "ipreservation:web"},
+	}
+	for s, expected := range valid {
+		name, err := ParseObjectName(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %s", s, err)
+			continue
+		}
+		if diff := pretty.Compare(name, expected); diff != "" {
+			t.Errorf("Parsed %q Diff: (-got +want)\n%s", s, diff)
+		}
+		if name.String() != s {
+			t.Errorf("Expected %q to format as itself, got %q", s, name.String())
+		}
+	}
+
+	invalid := []string{
+		"",
+		"seclist:",
+		"/",
+		"/Compute-",
+		"/Compute-acme",
+		"/Compute-acme/",
+		"/Compute-/jane@example.com/web",
+		"/Compute-acme//web",
+		"/Compute-acme/jane@example.com/",
+		"/Compute-acme/jane@example.com/web/",
+		"/oracle",
+		"/oracle/public",
+		"/oracle/public/",
+		"/oracle//image",
+		"/other/jane@example.com/web",
+		"web//id",
+		"web/",
+	}
+	for _, s := range invalid {
+		if _, err := ParseObjectName(s); err == nil {
+			t.Errorf("Expected %q not to parse", s)
+		}
+	}
+}
+
+func TestObjectName_QualifyAndUnqualify(t *testing.T) {
+	cases := []struct {
+		name        string
+		qualified   string
+		unqualified string
+	}{
+		{"web", "/Compute-acme/jane@example.com/web", "web"},
+		{"web/id", "/Compute-acme/jane@example.com/web/id", "web/id"},
+		{"seclist:web", "seclist:/Compute-acme/jane@example.com/web", "seclist:web"},
+		// Another user's objects in the same domain stay qualified
+		{"/Compute-acme/john@example.com/web", "/Compute-acme/john@example.com/web", "/Compute-acme/john@example.com/web"},
+		// As do the objects of another domain, of the domain itself and of Oracle
+		{"/Compute-other/jane@example.com/web", "/Compute-other/jane@example.com/web", "/Compute-other/jane@example.com/web"},
+		{"/Compute-acme/default", "/Compute-acme/default", "/Compute-acme/default"},
+		{"/oracle/public/image", "/oracle/public/image", "/oracle/public/image"},
+		{"seciplist:/oracle/public/public-internet", "seciplist:/oracle/public/public-internet", "seciplist:/oracle/public/public-internet"},
+	}
+	for _, c := range cases {
+		name, err := ParseObjectName(c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qualified := name.Qualify("acme", "jane@example.com")
+		if qualified.String() != c.qualified {
+			t.Errorf("Expected %q to qualify as %q, got %q", c.name, c.qualified, qualified)
+		}
+		if !qualified.IsQualified() {
+			t.Errorf("Expected %q to be qualified", qualified)
+		}
+		unqualified := qualified.Unqualify("acme", "jane@example.com")
+		if unqualified.String() != c.unqualified {
+			t.Errorf("Expected %q to unqualify as %q, got %q", qualified, c.unqualified, unqualified)
+		}
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForOrchestrationActivePollInterval = 10 * time.Second
 const waitForOrchestrationActiveTimeout = 3600 * time.Second
 const waitForOrchestrationDeletePollInterval = 10 * time.Second
 const waitForOrchestrationDeleteTimeout = 3600 * time.Second
+const defaultOrchestrationUpdateAttempts = 5
 
 // OrchestrationsClient is a client for the Orchestration functions of the Compute API.
 type OrchestrationsClient struct {
@@ -104,11 +106,11 @@ type Orchestration struct {
 	// Strings that describe the orchestration and help you identify it.
 	Tags []string `json:"tags"`
 	// Time the orchestration was last audited
-	TimeAudited string `json:"time_audited"`
+	TimeAudited opc.Time `json:"time_audited"`
 	// The time when the orchestration was added to Oracle Compute Cloud Service.
-	TimeCreated string `json:"time_created"`
+	TimeCreated opc.Time `json:"time_created"`
 	// The time when the orchestration was last updated in Oracle Compute Cloud Service.
-	TimeUpdated string `json:"time_updated"`
+	TimeUpdated opc.Time `json:"time_updated"`
 	// Unique Resource Identifier
 	URI string `json:"uri"`
 	// Name of the user who added this orchestration or made the most recent update to this orchestration.
@@ -342,12 +344,37 @@ type UpdateOrchestrationInput struct {
 	Tags []string `json:"tags,omitempty"`
 	// Version of this orchestration. It is automatically generated by the server.
 	Version int `json:"version,omitempty"`
+	// If true, the update is only applied when the server's copy of the orchestration is still at Version.
+	// A mismatch is returned as an *OrchestrationVersionConflictError holding the current server copy.
+	// Optional
+	CompareVersion bool `json:"-"`
 	// Time to wait between polls to check status
 	PollInterval time.Duration `json:"-"`
 	// Time to wait for an orchestration to be ready
 	Timeout time.Duration `json:"-"`
 }
 
+// OrchestrationVersionConflictError is returned by UpdateOrchestration when CompareVersion is set
+// and the orchestration has been modified on the server since the expected version was read.
+type OrchestrationVersionConflictError struct {
+	// The three-part name of the Orchestration
+	Name string
+	// The version the update was based on
+	ExpectedVersion int
+	// The current server copy of the orchestration
+	Current *Orchestration
+}
+
+func (e *OrchestrationVersionConflictError) Error() string {
+	return fmt.Sprintf("Orchestration %s has been modified: expected version %d, found version %d", e.Name, e.ExpectedVersion, e.Current.Version)
+}
+
+// WasOrchestrationVersionConflict determines if the given error was caused by a version conflict when updating an orchestration
+func WasOrchestrationVersionConflict(e error) bool {
+	_, ok := e.(*OrchestrationVersionConflictError)
+	return ok
+}
+
 // UpdateOrchestration updates the orchestration.
 func (c *OrchestrationsClient) UpdateOrchestration(input *UpdateOrchestrationInput) (*Orchestration, error) {
 	var updatedOrchestration Orchestration
@@ -360,7 +387,20 @@ func (c *OrchestrationsClient) UpdateOrchestration(input *UpdateOrchestrationInp
 		}
 	}
 
+	if input.CompareVersion {
+		// Check the server copy first, so a stale update never reaches the server.
+		// The version is also sent with the update, in case of a concurrent modification in between.
+		if err := c.checkOrchestrationVersion(input.Name, input.Version); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.updateResource(input.Name, input, &updatedOrchestration); err != nil {
+		if input.CompareVersion && client.WasConflictError(err) {
+			if checkErr := c.checkOrchestrationVersion(input.Name, input.Version); checkErr != nil {
+				return nil, checkErr
+			}
+		}
 		return nil, err
 	}
 
@@ -386,6 +426,91 @@ func (c *OrchestrationsClient) UpdateOrchestration(input *UpdateOrchestrationInp
 	return &orchestrationInfo, nil
 }
 
+func (c *OrchestrationsClient) checkOrchestrationVersion(name string, version int) error {
+	current, err := c.GetOrchestration(&GetOrchestrationInput{Name: name})
+	if err != nil {
+		return err
+	}
+	if current.Version != version {
+		return &OrchestrationVersionConflictError{
+			Name:            name,
+			ExpectedVersion: version,
+			Current:         current,
+		}
+	}
+	return nil
+}
+
+// RetryUpdateOrchestrationInput describes an update to apply to the current copy of an Orchestration
+type RetryUpdateOrchestrationInput struct {
+	// The three-part name of the Orchestration (/Compute-identity_domain/user/object).
+	// Required
+	Name string
+	// Applies the desired changes to an update built from the current server copy of the orchestration.
+	// It may be called more than once, and must not keep state between calls.
+	// Required
+	Mutate func(input *UpdateOrchestrationInput) error
+	// Number of times to re-read and re-apply the update on a version conflict. Defaults to 5, can't be negative.
+	// Optional
+	MaxAttempts int
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for an orchestration to be ready
+	Timeout time.Duration
+}
+
+// RetryUpdateOrchestration reads the current orchestration, applies Mutate to it and updates it
+// using CompareVersion. If the orchestration was modified in between, the read and mutation are retried.
+func (c *OrchestrationsClient) RetryUpdateOrchestration(input *RetryUpdateOrchestrationInput) (*Orchestration, error) {
+	if input.Mutate == nil {
+		return nil, fmt.Errorf("A mutate function must be specified to update orchestration %s", input.Name)
+	}
+	if input.MaxAttempts < 0 {
+		return nil, fmt.Errorf("The number of attempts to update orchestration %s can't be negative, got %d", input.Name, input.MaxAttempts)
+	}
+	if input.MaxAttempts == 0 {
+		input.MaxAttempts = defaultOrchestrationUpdateAttempts
+	}
+
+	var updateErr error
+	for i := 0; i < input.MaxAttempts; i++ {
+		current, err := c.GetOrchestration(&GetOrchestrationInput{Name: input.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		updateInput := &UpdateOrchestrationInput{
+			Account:        current.Account,
+			Description:    current.Description,
+			DesiredState:   current.DesiredState,
+			Name:           current.Name,
+			Objects:        current.Objects,
+			Tags:           current.Tags,
+			Version:        current.Version,
+			CompareVersion: true,
+			PollInterval:   input.PollInterval,
+			Timeout:        input.Timeout,
+		}
+		if err := input.Mutate(updateInput); err != nil {
+			return nil, err
+		}
+		// The mutation must not be able to skip the version check
+		updateInput.Version = current.Version
+		updateInput.CompareVersion = true
+
+		var info *Orchestration
+		info, updateErr = c.UpdateOrchestration(updateInput)
+		if updateErr == nil {
+			return info, nil
+		}
+		if !WasOrchestrationVersionConflict(updateErr) {
+			return nil, updateErr
+		}
+		c.client.DebugLogString(fmt.Sprintf("(Iteration: %d of %d) %s, retrying", i+1, input.MaxAttempts, updateErr))
+	}
+	return nil, updateErr
+}
+
 // DeleteOrchestrationInput describes the Orchestration to delete
 type DeleteOrchestrationInput struct {
 	// The three-part name of the Orchestration (/Compute-identity_domain/user/object).
@@ -3,7 +3,11 @@ package compute
 import (
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/helper"
 	"github.com/hashicorp/go-oracle-terraform/opc"
@@ -354,6 +358,175 @@ func TestAccOrchestrationLifeCycle_relationship(t *testing.T) {
 		"Relationship between instances not setup properly")
 }
 
+// Test that a compare-and-swap update is rejected when the server copy has moved on.
+func TestOrchestrationsClient_UpdateOrchestrationVersionConflict(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Wrong HTTP method %s, expected GET", r.Method)
+		}
+		w.Write([]byte(exampleOrchestrationResponse(3)))
+	})
+
+	defer server.Close()
+	client, err := getStubOrchestrationsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &UpdateOrchestrationInput{
+		Name:           "test-orchestration",
+		DesiredState:   OrchestrationDesiredStateActive,
+		Version:        2,
+		CompareVersion: true,
+	}
+	_, err = client.UpdateOrchestration(input)
+	conflict, ok := err.(*OrchestrationVersionConflictError)
+	if !ok {
+		t.Fatalf("Expected a version conflict error, got %v", err)
+	}
+	assert.Equal(t, 2, conflict.ExpectedVersion)
+	assert.Equal(t, 3, conflict.Current.Version)
+}
+
+// Test that a conflict reported by the server is returned as a version conflict.
+func TestOrchestrationsClient_UpdateOrchestrationServerConflict(t *testing.T) {
+	gets := 0
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			gets++
+			if gets == 1 {
+				w.Write([]byte(exampleOrchestrationResponse(2)))
+				return
+			}
+			w.Write([]byte(exampleOrchestrationResponse(3)))
+		case "PUT":
+			var body UpdateOrchestrationInput
+			unmarshalRequestBody(t, r, &body)
+			if body.Version != 2 {
+				t.Errorf("Expected version 2 to be sent, was %d", body.Version)
+			}
+			w.WriteHeader(http.StatusConflict)
+		default:
+			t.Errorf("Wrong HTTP method %s, expected GET or PUT", r.Method)
+		}
+	})
+
+	defer server.Close()
+	client, err := getStubOrchestrationsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &UpdateOrchestrationInput{
+		Name:           "test-orchestration",
+		DesiredState:   OrchestrationDesiredStateActive,
+		Version:        2,
+		CompareVersion: true,
+	}
+	_, err = client.UpdateOrchestration(input)
+	if !WasOrchestrationVersionConflict(err) {
+		t.Fatalf("Expected a version conflict error, got %v", err)
+	}
+}
+
+// Test that the update is re-applied to the current server copy after a conflict.
+func TestOrchestrationsClient_RetryUpdateOrchestration(t *testing.T) {
+	version := 1
+	puts := 0
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		expectedPath := "/platform/v1/orchestration/Compute-test/test/test-orchestration"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Wrong HTTP URL %v, expected %v", r.URL.Path, expectedPath)
+		}
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(exampleOrchestrationResponse(version)))
+		case "PUT":
+			puts++
+			var body UpdateOrchestrationInput
+			unmarshalRequestBody(t, r, &body)
+			if body.Description != fmt.Sprintf("updated from %d", body.Version) {
+				t.Errorf("Expected the mutation to be applied to version %d, got %q", body.Version, body.Description)
+			}
+			if puts == 1 {
+				// Another writer updated the orchestration first
+				version++
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			version++
+			w.Write([]byte(exampleOrchestrationResponse(version)))
+		default:
+			t.Errorf("Wrong HTTP method %s, expected GET or PUT", r.Method)
+		}
+	})
+
+	defer server.Close()
+	client, err := getStubOrchestrationsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &RetryUpdateOrchestrationInput{
+		Name: "test-orchestration",
+		Mutate: func(update *UpdateOrchestrationInput) error {
+			update.Description = fmt.Sprintf("updated from %d", update.Version)
+			return nil
+		},
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	info, err := client.RetryUpdateOrchestration(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, puts, "Expected the update to be retried once")
+	assert.Equal(t, 3, info.Version)
+
+	input.MaxAttempts = -1
+	if _, err := client.RetryUpdateOrchestration(input); err == nil {
+		t.Errorf("Expected an error for a negative number of attempts")
+	}
+	assert.Equal(t, 2, puts, "Expected no update for a negative number of attempts")
+}
+
+func getStubOrchestrationsClient(server *httptest.Server) (*OrchestrationsClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Orchestrations(), nil
+}
+
+func exampleOrchestrationResponse(version int) string {
+	return fmt.Sprintf(`{
+  "name": "/Compute-test/test/test-orchestration",
+  "desired_state": "active",
+  "status": "active",
+  "version": %d,
+  "objects": [
+    {
+      "label": "test",
+      "orchestration": "/Compute-test/test/test-orchestration",
+      "type": "Instance",
+      "template": {
+        "name": "/Compute-test/test/test-instance",
+        "shape": "oc3"
+      },
+      "health": {
+        "status": "active"
+      }
+    }
+  ]
+}`, version)
+}
+
 func getOrchestrationsTestClients() (*OrchestrationsClient, error) {
 	client, err := getTestClient(&opc.Config{})
 	if err != nil {
@@ -0,0 +1,109 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Test that a client with another owner addresses the owner's objects, and lists them across the domain.
+func TestClient_WithOwner(t *testing.T) {
+	var lock sync.Mutex
+	requests := []string{}
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+		switch r.URL.Path {
+		case "/storage/volume/Compute-test/jane/data":
+			w.Write([]byte(`{"name": "/Compute-test/jane/data", "size": "10737418240", "status": "online"}`))
+		case "/storage/volume/Compute-test/":
+			w.Write([]byte(`{"result": [
+				{"name": "/Compute-test/test/scratch", "size": "10737418240"},
+				{"name": "/Compute-test/jane/data", "size": "10737418240"}]}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jane := client.WithOwner("jane")
+	if jane.Owner() != "jane" || client.Owner() != "test" {
+		t.Fatalf("Unexpected owners %s and %s", jane.Owner(), client.Owner())
+	}
+	if jane.auth != client.auth {
+		t.Errorf("Expected the clients to share their authentication")
+	}
+
+	volume, err := jane.StorageVolumes().GetStorageVolume(&GetStorageVolumeInput{Name: "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volume.Name != "data" {
+		t.Errorf("Expected the owner's volume to be unqualified, got %s", volume.Name)
+	}
+
+	volumes, err := client.WithOwner(AllOwners).StorageVolumes().ListStorageVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, v := range volumes {
+		names = append(names, v.Name)
+	}
+	if diff := pretty.Compare(names, []string{"scratch", "/Compute-test/jane/data"}); diff != "" {
+		t.Errorf("Listed Volumes Diff: (-got +want)\n%s", diff)
+	}
+
+	expected := []string{
+		"GET /storage/volume/Compute-test/jane/data",
+		"GET /storage/volume/Compute-test/",
+	}
+	if diff := pretty.Compare(requests, expected); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestClient_ownerNameHelpers(t *testing.T) {
+	client, server, err := getBlankTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	other := client.WithOwner("other-user")
+	if name := other.getQualifiedName("web"); name != "/Compute-test-domain/other-user/web" {
+		t.Errorf("Expected the name to be qualified with the owner, got %s", name)
+	}
+	if name := other.getUnqualifiedName("/Compute-test-domain/other-user/web"); name != "web" {
+		t.Errorf("Expected the owner's name to be unqualified, got %s", name)
+	}
+	if name := other.getUnqualifiedName("/Compute-test-domain/test-user/web"); name != "/Compute-test-domain/test-user/web" {
+		t.Errorf("Expected the authenticated user's name to stay qualified, got %s", name)
+	}
+	if container := other.getListContainer(); container != "/Compute-test-domain/other-user/" {
+		t.Errorf("Expected the owner's container, got %s", container)
+	}
+
+	all := client.WithOwner(AllOwners)
+	if name := all.getQualifiedName("web"); name != "/Compute-test-domain/test-user/web" {
+		t.Errorf("Expected the name to be qualified with the authenticated user, got %s", name)
+	}
+	if container := all.getListContainer(); container != "/Compute-test-domain/" {
+		t.Errorf("Expected the domain container, got %s", container)
+	}
+}
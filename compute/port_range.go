@@ -0,0 +1,275 @@
+package compute
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The bounds of transport ports, and of ICMP types, which the ports of ICMP security protocols are
+const (
+	MinPort     = 1
+	MaxPort     = 65535
+	MinICMPType = 0
+	MaxICMPType = 255
+)
+
+// PortRange is a range of ports, such as 5900-5999, or a single port, such as 22
+type PortRange struct {
+	// The first port of the range
+	Low int
+	// The last port of the range, the same as the first for a single port
+	High int
+}
+
+// ParsePortRange parses a port, such as 22, or a port range, such as 8000-8100, in the format of the API.
+// Ports are between 1 and 65535, and the first port of a range can't be after the last.
+func ParsePortRange(s string) (PortRange, error) {
+	return parsePortRange(s, MinPort, MaxPort)
+}
+
+func parsePortRange(s string, min, max int) (PortRange, error) {
+	parts := strings.SplitN(s, "-", 2)
+	low, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return PortRange{}, fmt.Errorf("Invalid port %q", s)
+	}
+	high := low
+	if len(parts) == 2 {
+		if high, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return PortRange{}, fmt.Errorf("Invalid port range %q", s)
+		}
+	}
+	if low < min || high > max {
+		return PortRange{}, fmt.Errorf("Invalid port range %q: ports need to be between %d and %d", s, min, max)
+	}
+	if low > high {
+		return PortRange{}, fmt.Errorf("Invalid port range %q: the first port is after the last", s)
+	}
+	return PortRange{Low: low, High: high}, nil
+}
+
+// String returns the port range in the format of the API, such as 22 or 8000-8100
+func (r PortRange) String() string {
+	if r.Low == r.High {
+		return strconv.Itoa(r.Low)
+	}
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// Contains returns whether the port is in the range
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Low && port <= r.High
+}
+
+// ContainsRange returns whether all ports of the other range are in the range
+func (r PortRange) ContainsRange(other PortRange) bool {
+	return other.Low >= r.Low && other.High <= r.High
+}
+
+// Overlaps returns whether the ranges have any ports in common
+func (r PortRange) Overlaps(other PortRange) bool {
+	return r.Low <= other.High && other.Low <= r.High
+}
+
+// PortSet is a set of ports, as sorted port ranges that neither overlap nor are adjacent
+type PortSet []PortRange
+
+// AllPorts is the set of all ports
+var AllPorts = PortSet{{Low: MinPort, High: MaxPort}}
+
+// AllICMPTypes is the set of all ICMP types
+var AllICMPTypes = PortSet{{Low: MinICMPType, High: MaxICMPType}}
+
+// NewPortSet returns the set of the ports of the ranges
+func NewPortSet(ranges ...PortRange) PortSet {
+	sorted := make([]PortRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Low < sorted[j].Low })
+
+	set := PortSet{}
+	for _, r := range sorted {
+		if last := len(set) - 1; last >= 0 && r.Low <= set[last].High+1 {
+			if r.High > set[last].High {
+				set[last].High = r.High
+			}
+			continue
+		}
+		set = append(set, r)
+	}
+	return set
+}
+
+// ParsePortSet parses ports and port ranges in the format of the API, such as the destination ports of a security protocol
+func ParsePortSet(entries []string) (PortSet, error) {
+	return parsePortSet(entries, MinPort, MaxPort)
+}
+
+func parsePortSet(entries []string, min, max int) (PortSet, error) {
+	ranges := make([]PortRange, 0, len(entries))
+	for _, entry := range entries {
+		r, err := parsePortRange(entry, min, max)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return NewPortSet(ranges...), nil
+}
+
+// ParseProtocolPorts parses the ports of a security protocol of the IP protocol. The ports of icmp and icmpv6 are ICMP types,
+// between 0 and 255. No ports are all ports, and other protocols than tcp, udp, sctp, icmp and icmpv6 can't have ports.
+func ParseProtocolPorts(protocol string, entries []string) (PortSet, error) {
+	switch strings.ToLower(protocol) {
+	case string(TCP), string(UDP), string(SCTP):
+		if len(entries) == 0 {
+			return AllPorts, nil
+		}
+		return ParsePortSet(entries)
+	case string(ICMP), string(ICMPV6):
+		if len(entries) == 0 {
+			return AllICMPTypes, nil
+		}
+		return parsePortSet(entries, MinICMPType, MaxICMPType)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("Protocol %q can't have ports", protocol)
+	}
+	return PortSet{}, nil
+}
+
+// Strings returns the port ranges in the format of the API
+func (s PortSet) Strings() []string {
+	entries := make([]string, 0, len(s))
+	for _, r := range s {
+		entries = append(entries, r.String())
+	}
+	return entries
+}
+
+// String returns the port ranges in the format of the API, separated by commas
+func (s PortSet) String() string {
+	return strings.Join(s.Strings(), ",")
+}
+
+// IsEmpty returns whether the set has no ports
+func (s PortSet) IsEmpty() bool {
+	return len(s) == 0
+}
+
+// Contains returns whether the port is in the set
+func (s PortSet) Contains(port int) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i].High >= port })
+	return i < len(s) && s[i].Contains(port)
+}
+
+// ContainsSet returns whether all ports of the other set are in the set
+func (s PortSet) ContainsSet(other PortSet) bool {
+	for _, r := range other {
+		i := sort.Search(len(s), func(i int) bool { return s[i].High >= r.Low })
+		if i == len(s) || !s[i].ContainsRange(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Overlaps returns whether the sets have any ports in common
+func (s PortSet) Overlaps(other PortSet) bool {
+	return !s.Intersect(other).IsEmpty()
+}
+
+// Union returns the set of the ports in either set
+func (s PortSet) Union(other PortSet) PortSet {
+	return NewPortSet(append(append([]PortRange{}, s...), other...)...)
+}
+
+// Intersect returns the set of the ports in both sets
+func (s PortSet) Intersect(other PortSet) PortSet {
+	set := PortSet{}
+	for i, j := 0, 0; i < len(s) && j < len(other); {
+		low, high := s[i].Low, s[i].High
+		if other[j].Low > low {
+			low = other[j].Low
+		}
+		if other[j].High < high {
+			high = other[j].High
+		}
+		if low <= high {
+			set = append(set, PortRange{Low: low, High: high})
+		}
+		if s[i].High < other[j].High {
+			i++
+		} else {
+			j++
+		}
+	}
+	return set
+}
+
+// Ports returns the destination ports of a tcp or udp security application, which are all ports if it has none.
+// Security applications of other protocols have no ports.
+func (i *SecurityApplicationInfo) Ports() (PortSet, error) {
+	if i.Protocol != TCP && i.Protocol != UDP {
+		return PortSet{}, nil
+	}
+	if i.DPort == "" {
+		return AllPorts, nil
+	}
+	r, err := ParsePortRange(i.DPort)
+	if err != nil {
+		return nil, fmt.Errorf("Security application %s has an invalid port: %s", i.Name, err)
+	}
+	return NewPortSet(r), nil
+}
+
+// DstPorts returns the destination ports of the security protocol, or the ICMP types for icmp, which are all if it has none
+func (i *SecurityProtocolInfo) DstPorts() (PortSet, error) {
+	ports, err := ParseProtocolPorts(i.IPProtocol, i.DstPortSet)
+	if err != nil {
+		return nil, fmt.Errorf("Security protocol %s has invalid destination ports: %s", i.Name, err)
+	}
+	return ports, nil
+}
+
+// SrcPorts returns the source ports of the security protocol, or the ICMP types for icmp, which are all if it has none
+func (i *SecurityProtocolInfo) SrcPorts() (PortSet, error) {
+	ports, err := ParseProtocolPorts(i.IPProtocol, i.SrcPortSet)
+	if err != nil {
+		return nil, fmt.Errorf("Security protocol %s has invalid source ports: %s", i.Name, err)
+	}
+	return ports, nil
+}
+
+// Checks the ports and ICMP type and code of a security application against its protocol
+func validateSecurityApplication(input *CreateSecurityApplicationInput) error {
+	switch input.Protocol {
+	case TCP, UDP:
+		if input.DPort == "" {
+			return fmt.Errorf("Security application %s needs a port, as its protocol is %s", input.Name, input.Protocol)
+		}
+		if _, err := ParsePortRange(input.DPort); err != nil {
+			return fmt.Errorf("Security application %s has an invalid port: %s", input.Name, err)
+		}
+	default:
+		if input.DPort != "" {
+			return fmt.Errorf("Security application %s can't have a port, as its protocol is %s", input.Name, input.Protocol)
+		}
+	}
+	if input.Protocol != ICMP && (input.ICMPType != "" || input.ICMPCode != "") {
+		return fmt.Errorf("Security application %s can only have an ICMP type and code with the icmp protocol", input.Name)
+	}
+	return nil
+}
+
+// Checks the ports of a security protocol against its IP protocol
+func validateSecurityProtocol(name, protocol string, dstPorts, srcPorts []string) error {
+	if _, err := ParseProtocolPorts(protocol, dstPorts); err != nil {
+		return fmt.Errorf("Security protocol %s has invalid destination ports: %s", name, err)
+	}
+	if _, err := ParseProtocolPorts(protocol, srcPorts); err != nil {
+		return fmt.Errorf("Security protocol %s has invalid source ports: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package compute
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParsePortRange(t *testing.T) {
+	valid := map[string]PortRange{
+		"22":        {Low: 22, High: 22},
+		"5900-5999": {Low: 5900, High: 5999},
+		"1-65535":   {Low: 1, High: 65535},
+	}
+	for s, expected := range valid {
+		r, err := ParsePortRange(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse: %s", s, err)
+			continue
+		}
+		if r != expected {
+			t.Errorf("Expected %q to parse as %+v, got %+v", s, expected, r)
+		}
+		if r.String() != s {
+			t.Errorf("Expected %+v to format as %q, got %q", r, s, r.String())
+		}
+	}
+	for _, s := range []string{"", "ssh", "10-", "20-10", "0", "70000", "1-65536"} {
+		if _, err := ParsePortRange(s); err == nil {
+			t.Errorf("Expected %q not to parse", s)
+		}
+	}
+}
+
+func TestPortSet(t *testing.T) {
+	set, err := ParsePortSet([]string{"443", "8000-8100", "80", "8050-8200", "81"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(set.Strings(), []string{"80-81", "443", "8000-8200"}); diff != "" {
+		t.Errorf("Port Set Diff: (-got +want)\n%s", diff)
+	}
+	for port, expected := range map[int]bool{80: true, 82: false, 443: true, 8150: true, 8201: false, 1: false} {
+		if set.Contains(port) != expected {
+			t.Errorf("Expected %s to contain port %d: %t", set, port, expected)
+		}
+	}
+
+	other := NewPortSet(PortRange{Low: 22, High: 22}, PortRange{Low: 8100, High: 9000})
+	if diff := pretty.Compare(set.Union(other).String(), "22,80-81,443,8000-9000"); diff != "" {
+		t.Errorf("Union Diff: (-got +want)\n%s", diff)
+	}
+	if diff := pretty.Compare(set.Intersect(other).String(), "8100-8200"); diff != "" {
+		t.Errorf("Intersection Diff: (-got +want)\n%s", diff)
+	}
+	if !set.Overlaps(other) || set.Overlaps(NewPortSet(PortRange{Low: 22, High: 79})) {
+		t.Errorf("Unexpected overlap of %s", set)
+	}
+	if !AllPorts.ContainsSet(set) || set.ContainsSet(other) || !set.ContainsSet(NewPortSet(PortRange{Low: 8010, High: 8020})) {
+		t.Errorf("Unexpected containment of %s", set)
+	}
+	if !NewPortSet().IsEmpty() || set.IsEmpty() {
+		t.Errorf("Unexpected emptiness")
+	}
+}
+
+func TestParseProtocolPorts(t *testing.T) {
+	cases := []struct {
+		protocol string
+		entries  []string
+		expected string
+	}{
+		{"tcp", nil, "1-65535"},
+		{"udp", []string{"53"}, "53"},
+		{"icmp", []string{"0", "8"}, "0,8"},
+		{"icmp", nil, "0-255"},
+		{"gre", nil, ""},
+	}
+	for _, c := range cases {
+		ports, err := ParseProtocolPorts(c.protocol, c.entries)
+		if err != nil {
+			t.Errorf("%s %v: %s", c.protocol, c.entries, err)
+			continue
+		}
+		if ports.String() != c.expected {
+			t.Errorf("Expected %s %v to parse as %q, got %q", c.protocol, c.entries, c.expected, ports)
+		}
+	}
+	for protocol, entries := range map[string][]string{"tcp": {"0"}, "icmp": {"256"}, "gre": {"1"}} {
+		if _, err := ParseProtocolPorts(protocol, entries); err == nil {
+			t.Errorf("Expected %s %v not to parse", protocol, entries)
+		}
+	}
+
+	application := &SecurityApplicationInfo{Name: "ssh", Protocol: TCP}
+	if ports, err := application.Ports(); err != nil || ports.String() != "1-65535" {
+		t.Errorf("Expected all ports for a tcp security application without a port, got %s (%v)", ports, err)
+	}
+	application.DPort = "22"
+	if ports, err := application.Ports(); err != nil || ports.String() != "22" {
+		t.Errorf("Expected port 22, got %s (%v)", ports, err)
+	}
+}
+
+// Test that security applications and protocols with invalid ports are rejected without calling the API
+func TestPortValidation(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applications := []CreateSecurityApplicationInput{
+		{Name: "web", Protocol: TCP},
+		{Name: "web", Protocol: TCP, DPort: "8100-8000"},
+		{Name: "ping", Protocol: ICMP, DPort: "22"},
+		{Name: "ssh", Protocol: TCP, DPort: "22", ICMPType: Echo},
+	}
+	for i := range applications {
+		if _, err := client.SecurityApplications().CreateSecurityApplication(&applications[i]); err == nil {
+			t.Errorf("Expected an error creating security application %+v", applications[i])
+		}
+	}
+	if _, err := client.SecurityProtocols().CreateSecurityProtocol(&CreateSecurityProtocolInput{Name: "web", IPProtocol: "tcp", DstPortSet: []string{"443", "http"}}); err == nil {
+		t.Errorf("Expected an error creating a security protocol with an invalid port")
+	}
+	if _, err := client.SecurityProtocols().UpdateSecurityProtocol(&UpdateSecurityProtocolInput{Name: "ping", IPProtocol: "icmp", SrcPortSet: []string{"300"}}); err == nil {
+		t.Errorf("Expected an error updating a security protocol with an invalid ICMP type")
+	}
+}
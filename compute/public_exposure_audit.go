@@ -0,0 +1,400 @@
+package compute
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// PublicInternetIPList is the predefined security IP list of all addresses on the internet
+const PublicInternetIPList = "/oracle/public/public-internet"
+
+// ExposureSeverity is how severe the exposure of an instance to the internet is
+type ExposureSeverity string
+
+const (
+	// ExposureSeverityHigh - a remote administration or database port is exposed
+	ExposureSeverityHigh ExposureSeverity = "high"
+	// ExposureSeverityLow - other ports are exposed
+	ExposureSeverityLow ExposureSeverity = "low"
+)
+
+// The networks an instance can be exposed on
+const (
+	PublicExposureNetworkShared = "shared"
+	PublicExposureNetworkIP     = "ipnetwork"
+)
+
+// The ports whose exposure is of high severity, by service
+var sensitivePorts = []struct {
+	service string
+	port    int
+}{
+	{"SSH", 22},
+	{"Oracle Database", 1521},
+	{"SQL Server", 1433},
+	{"MySQL", 3306},
+	{"RDP", 3389},
+	{"PostgreSQL", 5432},
+	{"Redis", 6379},
+	{"MongoDB", 27017},
+}
+
+// PublicExposureFinding is a protocol and ports of an instance that are reachable from the internet
+type PublicExposureFinding struct {
+	// The instance, in the form name/id
+	Instance string `json:"instance"`
+	// The network the instance is exposed on, shared or ipnetwork
+	Network string `json:"network"`
+	// The vcable on the shared network, or the vNIC on an IP network, that's exposed
+	Interface string `json:"interface"`
+	// The public IP addresses of the interface
+	PublicIPAddresses []string `json:"public_ip_addresses"`
+	// The protocol that's exposed, such as tcp, or all
+	Protocol string `json:"protocol"`
+	// The ports that are exposed, such as 22 or 5900-5999. Empty for all ports.
+	Ports string `json:"ports"`
+	// The sec rule or security rule that permits the traffic, or the security list whose policy does
+	Rule string `json:"rule"`
+	// The source of the traffic the rule permits, such as seciplist:/oracle/public/public-internet
+	Source string `json:"source"`
+	// How severe the exposure is
+	Severity ExposureSeverity `json:"severity"`
+	// The sensitive services among the exposed ports, such as SSH
+	Services []string `json:"services,omitempty"`
+}
+
+// PublicExposureReport lists the instance and port pairs that are reachable from the internet
+type PublicExposureReport struct {
+	Findings []PublicExposureFinding `json:"findings"`
+}
+
+// AuditPublicExposure reports the instances of the owner of the client that are reachable from the internet, and on which ports.
+// An instance is exposed on the shared network if it has a public IP address, from an IP reservation associated with its vcable,
+// and a sec rule permits traffic from a security IP list of the whole internet, such as /oracle/public/public-internet, to one
+// of its security lists, or one of its security lists has an inbound policy of permit. An instance is exposed on an IP network
+// if it has a public IP address, from an IP address reservation associated with its vNIC, and an enabled ingress security rule
+// of an enabled ACL of the vNIC permits traffic from an IP address prefix set containing 0.0.0.0/0, or from any source.
+func (c *Client) AuditPublicExposure() (*PublicExposureReport, error) {
+	shared, err := c.LoadSharedNetworkModel()
+	if err != nil {
+		return nil, err
+	}
+	ipNetwork, err := c.LoadIPNetworkModel()
+	if err != nil {
+		return nil, err
+	}
+	vcableIPAddresses, err := c.loadVCablePublicIPAddresses()
+	if err != nil {
+		return nil, err
+	}
+	vnicIPAddresses, err := c.loadVirtualNICPublicIPAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PublicExposureReport{Findings: []PublicExposureFinding{}}
+	report.Findings = append(report.Findings, shared.publicExposure(vcableIPAddresses)...)
+	report.Findings = append(report.Findings, ipNetwork.publicExposure(vnicIPAddresses)...)
+	report.sort()
+	return report, nil
+}
+
+// Returns the public IP addresses associated with each vcable, by vcable name
+func (c *Client) loadVCablePublicIPAddresses() (map[string][]string, error) {
+	reservations, err := c.IPReservations().ListIPReservations()
+	if err != nil {
+		return nil, err
+	}
+	reservationIPAddresses := map[string]string{}
+	for _, reservation := range reservations {
+		reservationIPAddresses[c.getQualifiedName(reservation.Name)] = reservation.IP
+	}
+
+	associations, err := c.IPAssociations().ListIPAssociations()
+	if err != nil {
+		return nil, err
+	}
+	addresses := map[string][]string{}
+	for _, association := range associations {
+		ip := association.IP
+		if ip == "" {
+			ip = reservationIPAddresses[c.getQualifiedName(association.Reservation)]
+		}
+		if ip != "" {
+			addresses[association.VCable] = append(addresses[association.VCable], ip)
+		}
+	}
+	return addresses, nil
+}
+
+// Returns the public IP addresses associated with each vNIC, by vNIC name
+func (c *Client) loadVirtualNICPublicIPAddresses() (map[string][]string, error) {
+	reservations, err := c.IPAddressReservations().ListIPAddressReservations()
+	if err != nil {
+		return nil, err
+	}
+	publicIPAddresses := map[string]string{}
+	for _, reservation := range reservations {
+		if reservation.IPAddressPool == PublicIPAddressPool {
+			publicIPAddresses[reservation.Name] = reservation.IPAddress
+		}
+	}
+
+	associations, err := c.IPAddressAssociations().ListIPAddressAssociations()
+	if err != nil {
+		return nil, err
+	}
+	addresses := map[string][]string{}
+	for _, association := range associations {
+		if ip, ok := publicIPAddresses[association.IPAddressReservation]; ok {
+			addresses[association.Vnic] = append(addresses[association.Vnic], ip)
+		}
+	}
+	return addresses, nil
+}
+
+// The whole internet, as a network
+var publicInternet = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+
+func (m *SharedNetworkModel) publicExposure(vcableIPAddresses map[string][]string) []PublicExposureFinding {
+	instanceVCables := map[string][]string{}
+	for vcable, instance := range m.VCableInstances {
+		instanceVCables[instance] = append(instanceVCables[instance], vcable)
+	}
+	// Returns a finding for each vcable with a public IP address of each instance in the security list
+	exposed := func(list string, finding PublicExposureFinding) []PublicExposureFinding {
+		findings := []PublicExposureFinding{}
+		for instance, lists := range m.InstanceSecurityLists {
+			if !containsString(lists, list) {
+				continue
+			}
+			for _, vcable := range instanceVCables[instance] {
+				if addresses, ok := vcableIPAddresses[vcable]; ok {
+					f := finding
+					f.Instance = instance
+					f.Network = PublicExposureNetworkShared
+					f.Interface = vcable
+					f.PublicIPAddresses = uniqueSortedStrings(addresses)
+					findings = append(findings, f.withSeverity())
+				}
+			}
+		}
+		return findings
+	}
+
+	findings := []PublicExposureFinding{}
+	for _, rule := range m.SecRules {
+		if rule.Disabled || !strings.EqualFold(rule.Action, "PERMIT") {
+			continue
+		}
+		source, err := ParseObjectName(rule.SourceList)
+		if err != nil || source.ListType != SecurityIPListType {
+			continue
+		}
+		ipList, ok := m.SecurityIPLists[securityListKey(source)]
+		if !ok || !prefixesContain(ipList.SecIPEntries, publicInternet) {
+			continue
+		}
+		destination, err := ParseObjectName(rule.DestinationList)
+		if err != nil || destination.ListType == SecurityIPListType {
+			continue
+		}
+		finding := PublicExposureFinding{
+			Protocol: string(All),
+			Rule:     rule.Name,
+			Source:   rule.SourceList,
+		}
+		if application, ok := m.SecurityApplications[rule.Application]; ok {
+			finding.Protocol = string(application.Protocol)
+			if application.Protocol == TCP || application.Protocol == UDP {
+				finding.Ports = application.DPort
+			}
+		}
+		findings = append(findings, exposed(securityListKey(destination), finding)...)
+	}
+
+	for name, list := range m.SecurityLists {
+		if strings.EqualFold(string(list.Policy), string(SecurityListPolicyPermit)) {
+			findings = append(findings, exposed(name, PublicExposureFinding{
+				Protocol: string(All),
+				Rule:     name,
+				Source:   "inbound policy permit",
+			})...)
+		}
+	}
+	return findings
+}
+
+func (m *IPNetworkModel) publicExposure(vnicIPAddresses map[string][]string) []PublicExposureFinding {
+	findings := []PublicExposureFinding{}
+	for _, rule := range m.SecurityRules {
+		acl, ok := m.ACLs[rule.ACL]
+		if !ok || !acl.Enabled || !rule.Enabled || !strings.EqualFold(rule.FlowDirection, SecurityRuleFlowDirectionIngress) {
+			continue
+		}
+		if rule.SrcVnicSet != "" || !m.prefixSetsContain(rule.SrcIPAddressPrefixSets, publicInternet) {
+			continue
+		}
+
+		source := "any"
+		if len(rule.SrcIPAddressPrefixSets) > 0 {
+			source = strings.Join(rule.SrcIPAddressPrefixSets, ", ")
+		}
+		protocols := []PublicExposureFinding{{Protocol: string(All), Rule: rule.Name, Source: source}}
+		if len(rule.SecProtocols) > 0 {
+			protocols = protocols[:0]
+			for _, name := range rule.SecProtocols {
+				finding := PublicExposureFinding{Protocol: string(All), Rule: rule.Name, Source: source}
+				if protocol, ok := m.SecurityProtocols[name]; ok {
+					if protocol.IPProtocol != "" {
+						finding.Protocol = protocol.IPProtocol
+					}
+					finding.Ports = strings.Join(protocol.DstPortSet, ",")
+				}
+				protocols = append(protocols, finding)
+			}
+		}
+
+		for _, vnic := range m.aclVirtualNICs(rule.ACL) {
+			addresses, ok := vnicIPAddresses[vnic]
+			if !ok {
+				continue
+			}
+			if rule.DstVnicSet != "" && !containsString(m.VirtualNICSets[rule.DstVnicSet].VirtualNICs, vnic) {
+				continue
+			}
+			if len(rule.DstIPAddressPrefixSets) > 0 {
+				destination, err := parseIPPrefix(m.VirtualNICIPAddresses[vnic])
+				if err != nil || !m.prefixSetsContain(rule.DstIPAddressPrefixSets, destination) {
+					continue
+				}
+			}
+			instance, ok := m.VirtualNICInstances[vnic]
+			if !ok {
+				continue
+			}
+			for _, finding := range protocols {
+				finding.Instance = instance
+				finding.Network = PublicExposureNetworkIP
+				finding.Interface = vnic
+				finding.PublicIPAddresses = uniqueSortedStrings(addresses)
+				findings = append(findings, finding.withSeverity())
+			}
+		}
+	}
+	return findings
+}
+
+// Whether one of the IP address prefix sets contains the whole network. No prefix sets contain all networks.
+func (m *IPNetworkModel) prefixSetsContain(names []string, network *net.IPNet) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if prefixSet, ok := m.IPAddressPrefixSets[name]; ok && prefixesContain(prefixSet.IPAddressPrefixes, network) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the vNICs of the vNIC sets an ACL is applied to
+func (m *IPNetworkModel) aclVirtualNICs(acl string) []string {
+	vnics := []string{}
+	for _, vnicSet := range m.VirtualNICSets {
+		if containsString(vnicSet.AppliedACLs, acl) {
+			vnics = append(vnics, vnicSet.VirtualNICs...)
+		}
+	}
+	return uniqueSortedStrings(vnics)
+}
+
+// Sets the severity of the finding by the sensitive services among its ports
+func (f PublicExposureFinding) withSeverity() PublicExposureFinding {
+	f.Severity = ExposureSeverityLow
+	f.Services = nil
+	protocol := strings.ToLower(f.Protocol)
+	if protocol != string(TCP) && protocol != string(UDP) && protocol != string(All) {
+		return f
+	}
+	ports := AllPorts
+	if f.Ports != "" {
+		var err error
+		if ports, err = ParsePortSet(strings.Split(f.Ports, ",")); err != nil {
+			return f
+		}
+	}
+	for _, sensitive := range sensitivePorts {
+		if ports.Contains(sensitive.port) {
+			f.Severity = ExposureSeverityHigh
+			f.Services = append(f.Services, sensitive.service)
+		}
+	}
+	return f
+}
+
+// Sorts the findings by severity, instance, interface and rule
+func (r *PublicExposureReport) sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity == ExposureSeverityHigh
+		}
+		if a.Instance != b.Instance {
+			return a.Instance < b.Instance
+		}
+		if a.Interface != b.Interface {
+			return a.Interface < b.Interface
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Ports < b.Ports
+	})
+}
+
+// JSON returns the report as indented JSON
+func (r *PublicExposureReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown returns the report as a Markdown document, with a table of the findings
+func (r *PublicExposureReport) Markdown() string {
+	instances := map[string]bool{}
+	high := 0
+	for _, finding := range r.Findings {
+		instances[finding.Instance] = true
+		if finding.Severity == ExposureSeverityHigh {
+			high++
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Public exposure report\n\n")
+	if len(r.Findings) == 0 {
+		buf.WriteString("No instances are reachable from the internet.\n")
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "%d exposed instance and port pairs on %d instances, %d of high severity.\n\n", len(r.Findings), len(instances), high)
+	buf.WriteString("| Severity | Instance | Network | Interface | Public IP addresses | Protocol | Ports | Services | Rule | Source |\n")
+	buf.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, f := range r.Findings {
+		ports := f.Ports
+		if ports == "" {
+			ports = "all"
+		}
+		fmt.Fprintf(&buf, "| %s | %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			f.Severity, markdownCell(f.Instance), f.Network, markdownCell(f.Interface), strings.Join(f.PublicIPAddresses, ", "),
+			f.Protocol, ports, strings.Join(f.Services, ", "), markdownCell(f.Rule), markdownCell(f.Source))
+	}
+	return buf.String()
+}
+
+// Escapes the characters of a Markdown table cell
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
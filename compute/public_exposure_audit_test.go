@@ -0,0 +1,168 @@
+package compute
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestClient_AuditPublicExposure(t *testing.T) {
+	responses := stubSharedNetworkResponses()
+	for path, response := range stubIPNetworkResponses() {
+		responses[path] = response
+	}
+	responses["/seclist/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web", "policy": "DENY", "outbound_cidr_policy": "PERMIT"},
+		{"name": "/Compute-test/test/db", "policy": "PERMIT", "outbound_cidr_policy": "DENY"}]}`
+	responses["/instance/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web/1", "ip": "10.0.0.1", "vcable_id": "/Compute-test/test/vcable-1", "networking": {
+			"eth0": {"seclists": ["/Compute-test/test/web"]},
+			"eth1": {"ipnetwork": "/Compute-test/test/private", "ip": "192.168.1.2", "vnic": "/Compute-test/test/web_eth0"}}},
+		{"name": "/Compute-test/test/db/2", "ip": "10.0.0.2", "vcable_id": "/Compute-test/test/vcable-2", "networking": {
+			"eth0": {"seclists": ["/Compute-test/test/db"]},
+			"eth1": {"ipnetwork": "/Compute-test/test/private", "ip": "192.168.1.3", "vnic": "/Compute-test/test/db_eth0"}}},
+		{"name": "/Compute-test/test/batch/3", "ip": "10.0.0.3", "vcable_id": "/Compute-test/test/vcable-3", "networking": {
+			"eth0": {"seclists": []},
+			"eth1": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/batch_eth0"}}}]}`
+	responses["/ip/reservation/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web-ip", "ip": "192.0.2.1", "parentpool": "/oracle/public/ippool", "permanent": true, "used": true}]}`
+	responses["/ip/association/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web-ip-association", "reservation": "/Compute-test/test/web-ip",
+		 "parentpool": "ipreservation:/Compute-test/test/web-ip", "vcable": "/Compute-test/test/vcable-1"},
+		{"name": "/Compute-test/test/db-ip-association", "ip": "192.0.2.2", "reservation": "/Compute-test/test/db-temporary",
+		 "parentpool": "ippool:/oracle/public/ippool", "vcable": "/Compute-test/test/vcable-2"}]}`
+	responses["/network/v1/ipreservation/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web-public", "ipAddress": "198.51.100.1", "ipAddressPool": "/oracle/public/public-ippool"},
+		{"name": "/Compute-test/test/db-private", "ipAddress": "10.1.0.3", "ipAddressPool": "/oracle/public/cloud-ippool"}]}`
+	responses["/network/v1/ipassociation/Compute-test/test/"] = `{"result": [
+		{"name": "/Compute-test/test/web-public-association", "ipAddressReservation": "/Compute-test/test/web-public", "vnic": "/Compute-test/test/web_eth0"},
+		{"name": "/Compute-test/test/db-private-association", "ipAddressReservation": "/Compute-test/test/db-private", "vnic": "/Compute-test/test/db_eth0"}]}`
+
+	server, client := newStubResponseServer(t, responses)
+	defer server.Close()
+
+	report, err := client.AuditPublicExposure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	allServices := []string{"SSH", "Oracle Database", "SQL Server", "MySQL", "RDP", "PostgreSQL", "Redis", "MongoDB"}
+	expected := []PublicExposureFinding{
+		{
+			Instance:          "db/2",
+			Network:           PublicExposureNetworkShared,
+			Interface:         "vcable-2",
+			PublicIPAddresses: []string{"192.0.2.2"},
+			Protocol:          "all",
+			Rule:              "db",
+			Source:            "inbound policy permit",
+			Severity:          ExposureSeverityHigh,
+			Services:          allServices,
+		},
+		{
+			Instance:          "web/1",
+			Network:           PublicExposureNetworkShared,
+			Interface:         "vcable-1",
+			PublicIPAddresses: []string{"192.0.2.1"},
+			Protocol:          "tcp",
+			Ports:             "443",
+			Rule:              "internet-to-web-https",
+			Source:            "seciplist:/oracle/public/public-internet",
+			Severity:          ExposureSeverityLow,
+		},
+		{
+			Instance:          "web/1",
+			Network:           PublicExposureNetworkIP,
+			Interface:         "web_eth0",
+			PublicIPAddresses: []string{"198.51.100.1"},
+			Protocol:          "tcp",
+			Ports:             "443",
+			Rule:              "web-ingress-https",
+			Source:            "public-internet",
+			Severity:          ExposureSeverityLow,
+		},
+	}
+	if diff := pretty.Compare(report.Findings, expected); diff != "" {
+		t.Errorf("Findings Diff: (-got +want)\n%s", diff)
+	}
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PublicExposureReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(decoded, report); diff != "" {
+		t.Errorf("JSON Round Trip Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestPublicExposureFinding_withSeverity(t *testing.T) {
+	cases := []struct {
+		protocol string
+		ports    string
+		severity ExposureSeverity
+		services []string
+	}{
+		{"tcp", "22", ExposureSeverityHigh, []string{"SSH"}},
+		{"tcp", "3000-3400", ExposureSeverityHigh, []string{"MySQL", "RDP"}},
+		{"udp", "3389", ExposureSeverityHigh, []string{"RDP"}},
+		{"tcp", "5432-5433,8080", ExposureSeverityHigh, []string{"PostgreSQL"}},
+		{"tcp", "443", ExposureSeverityLow, nil},
+		{"icmp", "", ExposureSeverityLow, nil},
+	}
+	for _, c := range cases {
+		finding := PublicExposureFinding{Protocol: c.protocol, Ports: c.ports}.withSeverity()
+		if finding.Severity != c.severity {
+			t.Errorf("Expected %s/%s to be of %s severity, got %s", c.protocol, c.ports, c.severity, finding.Severity)
+		}
+		if diff := pretty.Compare(finding.Services, c.services); diff != "" {
+			t.Errorf("%s/%s Services Diff: (-got +want)\n%s", c.protocol, c.ports, diff)
+		}
+	}
+}
+
+func TestPublicExposureReport_Markdown(t *testing.T) {
+	report := &PublicExposureReport{
+		Findings: []PublicExposureFinding{
+			{
+				Instance:          "db/2",
+				Network:           PublicExposureNetworkIP,
+				Interface:         "db_eth0",
+				PublicIPAddresses: []string{"198.51.100.2"},
+				Protocol:          "tcp",
+				Ports:             "22",
+				Rule:              "db-ingress-ssh",
+				Source:            "any",
+				Severity:          ExposureSeverityHigh,
+				Services:          []string{"SSH"},
+			},
+			{
+				Instance:          "web/1",
+				Network:           PublicExposureNetworkShared,
+				Interface:         "vcable-1",
+				PublicIPAddresses: []string{"192.0.2.1"},
+				Protocol:          "all",
+				Rule:              "web",
+				Source:            "inbound policy permit",
+				Severity:          ExposureSeverityLow,
+			},
+		},
+	}
+	expected := "# Public exposure report\n\n" +
+		"2 exposed instance and port pairs on 2 instances, 1 of high severity.\n\n" +
+		"| Severity | Instance | Network | Interface | Public IP addresses | Protocol | Ports | Services | Rule | Source |\n" +
+		"|---|---|---|---|---|---|---|---|---|---|\n" +
+		"| high | db/2 | ipnetwork | db_eth0 | 198.51.100.2 | tcp | 22 | SSH | db-ingress-ssh | any |\n" +
+		"| low | web/1 | shared | vcable-1 | 192.0.2.1 | all | all |  | web | inbound policy permit |\n"
+	if markdown := report.Markdown(); markdown != expected {
+		t.Errorf("Expected Markdown:\n%s\ngot:\n%s", expected, markdown)
+	}
+
+	empty := &PublicExposureReport{}
+	if markdown := empty.Markdown(); markdown != "# Public exposure report\n\nNo instances are reachable from the internet.\n" {
+		t.Errorf("Unexpected Markdown of an empty report:\n%s", markdown)
+	}
+}
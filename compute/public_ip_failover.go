@@ -0,0 +1,357 @@
+package compute
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// The type of the parent pool of an IP association with an IP reservation, such as ipreservation:web-ip
+const ipReservationParentPoolType = "ipreservation"
+
+// PublicIPOwner is who holds a public IP address: the reservation of the IP address, and the association,
+// interface and instance it's associated with
+type PublicIPOwner struct {
+	// The public IP address
+	IPAddress string
+	// The network of the reservation: shared for IP reservations, or ipnetwork for IP address reservations
+	Network string
+	// The IP reservation or IP address reservation. Empty for IP addresses of the pool that aren't reserved.
+	Reservation string
+	// The IP association or IP address association. Empty if the IP address isn't associated.
+	Association string
+	// The vcable the IP address is associated with, on the shared network
+	VCable string
+	// The vNIC the IP address is associated with, on IP networks
+	VirtualNIC string
+	// The instance of the vcable or vNIC, in the form name/id. Empty if it isn't an instance of the owner of the client.
+	Instance string
+}
+
+// Associated returns whether the IP address is associated with a vcable or vNIC
+func (o *PublicIPOwner) Associated() bool {
+	return o.VCable != "" || o.VirtualNIC != ""
+}
+
+func (o *PublicIPOwner) String() string {
+	holder := "isn't associated"
+	switch {
+	case o.VCable != "":
+		holder = fmt.Sprintf("is associated with vcable %s", o.VCable)
+	case o.VirtualNIC != "":
+		holder = fmt.Sprintf("is associated with vNIC %s", o.VirtualNIC)
+	}
+	if o.Instance != "" {
+		holder = fmt.Sprintf("%s of instance %s", holder, o.Instance)
+	}
+	if o.Reservation == "" {
+		return fmt.Sprintf("Public IP address %s %s", o.IPAddress, holder)
+	}
+	return fmt.Sprintf("Public IP address %s of reservation %s %s", o.IPAddress, o.Reservation, holder)
+}
+
+// FindPublicIPOwner returns who holds the public IP address, among the IP reservations and IP associations of
+// the shared network, and the IP address reservations and IP address associations of IP networks, of the owner
+// of the client. Returns nil if none of them has the IP address.
+func (c *Client) FindPublicIPOwner(ipAddress string) (*PublicIPOwner, error) {
+	ip := net.ParseIP(ipAddress).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%q isn't an IPv4 address", ipAddress)
+	}
+	ipAddress = ip.String()
+
+	owner, err := c.findSharedNetworkPublicIPOwner(ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		if owner, err = c.findIPNetworkPublicIPOwner(ipAddress); err != nil {
+			return nil, err
+		}
+	}
+	if owner == nil || !owner.Associated() {
+		return owner, nil
+	}
+
+	instances, err := c.Instances().ListInstances()
+	if err != nil {
+		return nil, err
+	}
+	vnicInstances, _ := instanceVirtualNICs(instances)
+	owner.Instance = vnicInstances[owner.VirtualNIC]
+	for _, instance := range instances {
+		if owner.VCable != "" && instance.VCableID == owner.VCable {
+			owner.Instance = fmt.Sprintf(cmpQualifiedName, instance.Name, instance.ID)
+		}
+	}
+	return owner, nil
+}
+
+// Returns whether the IP association is of the IP reservation
+func (c *Client) ipAssociationOfReservation(association *IPAssociationInfo, reservation string) bool {
+	if association.Reservation != "" {
+		return c.getQualifiedName(association.Reservation) == c.getQualifiedName(reservation)
+	}
+	return association.ParentPool == fmt.Sprintf("%s:%s", ipReservationParentPoolType, c.getUnqualifiedName(reservation))
+}
+
+func (c *Client) findSharedNetworkPublicIPOwner(ipAddress string) (*PublicIPOwner, error) {
+	reservations, err := c.IPReservations().ListIPReservations()
+	if err != nil {
+		return nil, err
+	}
+	var owner *PublicIPOwner
+	for _, reservation := range reservations {
+		if reservation.IP == ipAddress {
+			owner = &PublicIPOwner{IPAddress: ipAddress, Network: PublicExposureNetworkShared, Reservation: reservation.Name}
+		}
+	}
+
+	associations, err := c.IPAssociations().ListIPAssociations()
+	if err != nil {
+		return nil, err
+	}
+	for i, association := range associations {
+		matches := association.IP == ipAddress
+		if owner != nil {
+			matches = c.ipAssociationOfReservation(&associations[i], owner.Reservation)
+		}
+		if !matches {
+			continue
+		}
+		if owner == nil {
+			// An IP address of the pool that isn't reserved
+			owner = &PublicIPOwner{IPAddress: ipAddress, Network: PublicExposureNetworkShared}
+		}
+		owner.Association = association.Name
+		owner.VCable = association.VCable
+		break
+	}
+	return owner, nil
+}
+
+func (c *Client) findIPNetworkPublicIPOwner(ipAddress string) (*PublicIPOwner, error) {
+	reservations, err := c.IPAddressReservations().ListIPAddressReservations()
+	if err != nil {
+		return nil, err
+	}
+	var owner *PublicIPOwner
+	for _, reservation := range reservations {
+		if reservation.IPAddress == ipAddress {
+			owner = &PublicIPOwner{IPAddress: ipAddress, Network: PublicExposureNetworkIP, Reservation: reservation.Name}
+		}
+	}
+	if owner == nil {
+		return nil, nil
+	}
+
+	associations, err := c.IPAddressAssociations().ListIPAddressAssociations()
+	if err != nil {
+		return nil, err
+	}
+	for _, association := range associations {
+		if association.IPAddressReservation == owner.Reservation {
+			owner.Association = association.Name
+			owner.VirtualNIC = association.Vnic
+			break
+		}
+	}
+	return owner, nil
+}
+
+// FailoverPublicIPInput describes a floating public IP address to move to a standby instance
+type FailoverPublicIPInput struct {
+	// The name of the IP reservation, on the shared network, or of the IP address reservation, on IP networks
+	// Required
+	Reservation string
+	// The vcable of the standby instance, to move an IP reservation to
+	// Required on the shared network
+	VCable string
+	// The vNIC of the standby instance, to move an IP address reservation to
+	// Required on IP networks
+	VirtualNIC string
+}
+
+// PublicIPFailover is a floating public IP address that was moved to a standby instance
+type PublicIPFailover struct {
+	// The public IP address
+	IPAddress string
+	// The IP reservation or IP address reservation
+	Reservation string
+	// The vcable or vNIC that held the IP address before. Empty if the IP address wasn't associated.
+	From string
+	// The vcable or vNIC that holds the IP address now
+	To string
+	// The IP association or IP address association of the IP address with the standby
+	Association string
+}
+
+// FailoverPublicIP moves a floating public IP address from the vcable or vNIC it's associated with to the one of
+// a standby instance, and verifies the new association. On IP networks, the IP address association is updated to
+// the standby vNIC, or created if there's none. On the shared network, where IP associations can't be updated,
+// the IP association is deleted and one is created with the standby vcable. If creating or verifying the new
+// association fails, the IP address is associated with the vcable or vNIC that held it before again.
+func (c *Client) FailoverPublicIP(input *FailoverPublicIPInput) (*PublicIPFailover, error) {
+	switch {
+	case input.Reservation == "":
+		return nil, fmt.Errorf("A reservation is required to fail over a public IP address")
+	case (input.VCable == "") == (input.VirtualNIC == ""):
+		return nil, fmt.Errorf("Either a vcable or a vNIC is required to fail over reservation %s", input.Reservation)
+	case input.VCable != "":
+		return c.failoverIPReservation(input.Reservation, input.VCable)
+	}
+	return c.failoverIPAddressReservation(input.Reservation, input.VirtualNIC)
+}
+
+// Moves an IP reservation of the shared network to the vcable
+func (c *Client) failoverIPReservation(reservationName, vcable string) (*PublicIPFailover, error) {
+	reservation, err := c.IPReservations().GetIPReservation(&GetIPReservationInput{Name: reservationName})
+	if err != nil {
+		return nil, fmt.Errorf("Error getting IP reservation %s: %s", reservationName, err)
+	}
+	failover := &PublicIPFailover{IPAddress: reservation.IP, Reservation: reservation.Name, To: c.getUnqualifiedName(vcable)}
+
+	associations, err := c.IPAssociations().ListIPAssociations()
+	if err != nil {
+		return nil, err
+	}
+	var current *IPAssociationInfo
+	for i := range associations {
+		if c.ipAssociationOfReservation(&associations[i], reservation.Name) {
+			current = &associations[i]
+			break
+		}
+	}
+	if current != nil {
+		failover.From = current.VCable
+		if current.VCable == failover.To {
+			failover.Association = current.Name
+			return failover, nil
+		}
+		if err := c.IPAssociations().DeleteIPAssociation(&DeleteIPAssociationInput{Name: current.Name}); err != nil {
+			return nil, fmt.Errorf("Error disassociating IP reservation %s from vcable %s: %s", reservation.Name, current.VCable, err)
+		}
+	}
+
+	parentPool := fmt.Sprintf("%s:%s", ipReservationParentPoolType, reservation.Name)
+	association, err := c.IPAssociations().CreateIPAssociation(&CreateIPAssociationInput{ParentPool: parentPool, VCable: vcable})
+	if err == nil {
+		failover.Association = association.Name
+		err = c.verifyIPAssociation(association.Name, reservation.Name, failover.To)
+	}
+	if err == nil {
+		return failover, nil
+	}
+
+	err = fmt.Errorf("Error associating IP reservation %s with vcable %s: %s", reservation.Name, failover.To, err)
+	if failover.Association != "" {
+		if deleteErr := c.IPAssociations().DeleteIPAssociation(&DeleteIPAssociationInput{Name: failover.Association}); deleteErr != nil {
+			return nil, fmt.Errorf("%s, and deleting IP association %s failed: %s", err, failover.Association, deleteErr)
+		}
+	}
+	if current == nil {
+		return nil, err
+	}
+	if _, rollbackErr := c.IPAssociations().CreateIPAssociation(&CreateIPAssociationInput{ParentPool: parentPool, VCable: current.VCable}); rollbackErr != nil {
+		return nil, fmt.Errorf("%s, and associating it with vcable %s again failed: %s", err, current.VCable, rollbackErr)
+	}
+	return nil, fmt.Errorf("%s, associated it with vcable %s again", err, current.VCable)
+}
+
+// Checks that the IP association associates the IP reservation with the vcable
+func (c *Client) verifyIPAssociation(name, reservation, vcable string) error {
+	association, err := c.IPAssociations().GetIPAssociation(&GetIPAssociationInput{Name: name})
+	if err != nil {
+		return fmt.Errorf("Error verifying IP association %s: %s", name, err)
+	}
+	if association.VCable != vcable || !c.ipAssociationOfReservation(association, reservation) {
+		return fmt.Errorf("IP association %s associates %s with vcable %s instead", name, describeParentPool(association), association.VCable)
+	}
+	return nil
+}
+
+func describeParentPool(association *IPAssociationInfo) string {
+	if association.Reservation != "" {
+		return association.Reservation
+	}
+	return strings.TrimPrefix(association.ParentPool, ipReservationParentPoolType+":")
+}
+
+// Moves an IP address reservation of IP networks to the vNIC
+func (c *Client) failoverIPAddressReservation(reservationName, vnic string) (*PublicIPFailover, error) {
+	reservation, err := c.IPAddressReservations().GetIPAddressReservation(&GetIPAddressReservationInput{Name: reservationName})
+	if err != nil {
+		return nil, fmt.Errorf("Error getting IP address reservation %s: %s", reservationName, err)
+	}
+	failover := &PublicIPFailover{IPAddress: reservation.IPAddress, Reservation: reservation.Name, To: c.getUnqualifiedName(vnic)}
+
+	associations, err := c.IPAddressAssociations().ListIPAddressAssociations()
+	if err != nil {
+		return nil, err
+	}
+	var current *IPAddressAssociationInfo
+	for i := range associations {
+		if associations[i].IPAddressReservation == reservation.Name {
+			current = &associations[i]
+			break
+		}
+	}
+
+	if current == nil {
+		// Name the association after the reservation, as it's the only association of the reservation
+		association, err := c.IPAddressAssociations().CreateIPAddressAssociation(&CreateIPAddressAssociationInput{
+			Name:                 reservation.Name,
+			IPAddressReservation: reservation.Name,
+			Vnic:                 vnic,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error associating IP address reservation %s with vNIC %s: %s", reservation.Name, failover.To, err)
+		}
+		failover.Association = association.Name
+		if err := c.verifyIPAddressAssociation(association.Name, failover.To); err != nil {
+			if deleteErr := c.IPAddressAssociations().DeleteIPAddressAssociation(&DeleteIPAddressAssociationInput{Name: association.Name}); deleteErr != nil {
+				return nil, fmt.Errorf("%s, and deleting IP address association %s failed: %s", err, association.Name, deleteErr)
+			}
+			return nil, err
+		}
+		return failover, nil
+	}
+
+	failover.From = current.Vnic
+	failover.Association = current.Name
+	if current.Vnic == failover.To {
+		return failover, nil
+	}
+	update := func(vnic string) error {
+		_, err := c.IPAddressAssociations().UpdateIPAddressAssociation(&UpdateIPAddressAssociationInput{
+			Name:                 current.Name,
+			IPAddressReservation: current.IPAddressReservation,
+			Vnic:                 vnic,
+			Description:          current.Description,
+			Tags:                 current.Tags,
+		})
+		return err
+	}
+	if err := update(vnic); err != nil {
+		return nil, fmt.Errorf("Error moving IP address reservation %s to vNIC %s: %s", reservation.Name, failover.To, err)
+	}
+	if err := c.verifyIPAddressAssociation(current.Name, failover.To); err != nil {
+		if rollbackErr := update(current.Vnic); rollbackErr != nil {
+			return nil, fmt.Errorf("%s, and moving it back to vNIC %s failed: %s", err, current.Vnic, rollbackErr)
+		}
+		return nil, fmt.Errorf("%s, moved it back to vNIC %s", err, current.Vnic)
+	}
+	return failover, nil
+}
+
+// Checks that the IP address association is of the vNIC
+func (c *Client) verifyIPAddressAssociation(name, vnic string) error {
+	association, err := c.IPAddressAssociations().GetIPAddressAssociation(&GetIPAddressAssociationInput{Name: name})
+	if err != nil {
+		return fmt.Errorf("Error verifying IP address association %s: %s", name, err)
+	}
+	if association.Vnic != vnic {
+		return fmt.Errorf("IP address association %s is of vNIC %s instead of %s", name, association.Vnic, vnic)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestClient_FindPublicIPOwner(t *testing.T) {
+	client, _, closeServer := newStubPublicIPServer(t, nil)
+	defer closeServer()
+
+	expected := map[string]*PublicIPOwner{
+		"192.0.2.1": {
+			IPAddress:   "192.0.2.1",
+			Network:     PublicExposureNetworkShared,
+			Reservation: "web-ip",
+			Association: "web-ip-association",
+			VCable:      "vcable-1",
+			Instance:    "web/1",
+		},
+		"192.0.2.9": {
+			IPAddress:   "192.0.2.9",
+			Network:     PublicExposureNetworkShared,
+			Association: "batch-association",
+			VCable:      "vcable-3",
+		},
+		"198.51.100.1": {
+			IPAddress:   "198.51.100.1",
+			Network:     PublicExposureNetworkIP,
+			Reservation: "web-public",
+			Association: "web-public",
+			VirtualNIC:  "web1_eth0",
+			Instance:    "web/1",
+		},
+		"198.51.100.2": {IPAddress: "198.51.100.2", Network: PublicExposureNetworkIP, Reservation: "spare-public"},
+		"203.0.113.1":  nil,
+	}
+	for ip, owner := range expected {
+		got, err := client.FindPublicIPOwner(ip)
+		if err != nil {
+			t.Errorf("%s: %s", ip, err)
+			continue
+		}
+		if diff := pretty.Compare(got, owner); diff != "" {
+			t.Errorf("%s: Owner Diff: (-got +want)\n%s", ip, diff)
+		}
+	}
+	if _, err := client.FindPublicIPOwner("web-ip"); err == nil {
+		t.Errorf("Expected an error for a public IP address that isn't an IP address")
+	}
+}
+
+func TestClient_FailoverPublicIP(t *testing.T) {
+	client, requests, closeServer := newStubPublicIPServer(t, nil)
+	defer closeServer()
+
+	failover, err := client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "web-ip", VCable: "vcable-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PublicIPFailover{IPAddress: "192.0.2.1", Reservation: "web-ip", From: "vcable-1", To: "vcable-2", Association: "association-1"}
+	if diff := pretty.Compare(failover, expected); diff != "" {
+		t.Errorf("Failover Diff: (-got +want)\n%s", diff)
+	}
+
+	failover, err = client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "web-public", VirtualNIC: "web2_eth0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = &PublicIPFailover{IPAddress: "198.51.100.1", Reservation: "web-public", From: "web1_eth0", To: "web2_eth0", Association: "web-public"}
+	if diff := pretty.Compare(failover, expected); diff != "" {
+		t.Errorf("Failover Diff: (-got +want)\n%s", diff)
+	}
+
+	failover, err = client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "spare-public", VirtualNIC: "web2_eth0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = &PublicIPFailover{IPAddress: "198.51.100.2", Reservation: "spare-public", To: "web2_eth0", Association: "spare-public"}
+	if diff := pretty.Compare(failover, expected); diff != "" {
+		t.Errorf("Failover Diff: (-got +want)\n%s", diff)
+	}
+
+	expectedRequests := []string{
+		"DELETE /ip/association/Compute-test/test/web-ip-association",
+		"POST /ip/association/",
+		"PUT /network/v1/ipassociation/Compute-test/test/web-public",
+		"POST /network/v1/ipassociation/",
+	}
+	if diff := pretty.Compare(*requests, expectedRequests); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+
+	owner, err := client.FindPublicIPOwner("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.VCable != "vcable-2" || owner.Instance != "web/2" {
+		t.Errorf("Expected 192.0.2.1 to be of vcable-2 of web/2 after the failover, got %s", owner)
+	}
+
+	if _, err := client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "web-ip", VCable: "vcable-1", VirtualNIC: "web1_eth0"}); err == nil {
+		t.Errorf("Expected an error failing over to both a vcable and a vNIC")
+	}
+}
+
+func TestClient_FailoverPublicIP_Rollback(t *testing.T) {
+	// The new association of the shared network ends up with another vcable
+	misassociate := func(r *http.Request, object map[string]interface{}) int {
+		if r.Method == "POST" && r.URL.Path == "/ip/association/" && object["vcable"] == "/Compute-test/test/vcable-2" {
+			object["vcable"] = "/Compute-test/test/vcable-3"
+		}
+		return 0
+	}
+	client, requests, closeServer := newStubPublicIPServer(t, misassociate)
+	defer closeServer()
+
+	if _, err := client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "web-ip", VCable: "vcable-2"}); err == nil {
+		t.Fatal("Expected the failover to fail")
+	}
+	expectedRequests := []string{
+		"DELETE /ip/association/Compute-test/test/web-ip-association",
+		"POST /ip/association/",
+		"DELETE /ip/association/Compute-test/test/association-1",
+		"POST /ip/association/",
+	}
+	if diff := pretty.Compare(*requests, expectedRequests); diff != "" {
+		t.Errorf("Requests Diff: (-got +want)\n%s", diff)
+	}
+	owner, err := client.FindPublicIPOwner("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.VCable != "vcable-1" {
+		t.Errorf("Expected 192.0.2.1 to be of vcable-1 again, got %s", owner)
+	}
+
+	// Updating the association of IP networks to the standby vNIC fails
+	client, requests, closeServer = newStubPublicIPServer(t, func(r *http.Request, object map[string]interface{}) int {
+		if r.Method == "PUT" && object["vnic"] == "/Compute-test/test/web2_eth0" {
+			return http.StatusInternalServerError
+		}
+		return 0
+	})
+	defer closeServer()
+
+	if _, err := client.FailoverPublicIP(&FailoverPublicIPInput{Reservation: "web-public", VirtualNIC: "web2_eth0"}); err == nil {
+		t.Fatal("Expected the failover to fail")
+	}
+	owner, err = client.FindPublicIPOwner("198.51.100.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.VirtualNIC != "web1_eth0" {
+		t.Errorf("Expected 198.51.100.1 to stay with web1_eth0, got %s", owner)
+	}
+}
+
+// Returns a client of a stub server that stores IP reservations and associations, and records the requests that change
+// them. The fault function can change objects before they're stored, or return a status code to fail the request.
+func newStubPublicIPServer(t *testing.T, fault func(r *http.Request, object map[string]interface{}) int) (*Client, *[]string, func()) {
+	store := newStubObjectStore(t)
+	store.load("/ip/reservation", `{"result": [
+		{"name": "/Compute-test/test/web-ip", "ip": "192.0.2.1", "parentpool": "/oracle/public/ippool", "permanent": true, "used": true}]}`)
+	store.load("/ip/association", `{"result": [
+		{"name": "/Compute-test/test/web-ip-association", "reservation": "/Compute-test/test/web-ip",
+		 "parentpool": "ipreservation:/Compute-test/test/web-ip", "vcable": "/Compute-test/test/vcable-1"},
+		{"name": "/Compute-test/test/batch-association", "ip": "192.0.2.9",
+		 "parentpool": "ippool:/oracle/public/ippool", "vcable": "/Compute-test/test/vcable-3"}]}`)
+	store.load("/network/v1/ipreservation", `{"result": [
+		{"name": "/Compute-test/test/web-public", "ipAddress": "198.51.100.1", "ipAddressPool": "/oracle/public/public-ippool"},
+		{"name": "/Compute-test/test/spare-public", "ipAddress": "198.51.100.2", "ipAddressPool": "/oracle/public/public-ippool"}]}`)
+	store.load("/network/v1/ipassociation", `{"result": [
+		{"name": "/Compute-test/test/web-public", "ipAddressReservation": "/Compute-test/test/web-public", "vnic": "/Compute-test/test/web1_eth0"}]}`)
+	store.load("/instance", `{"result": [
+		{"name": "/Compute-test/test/web/1", "vcable_id": "/Compute-test/test/vcable-1", "networking": {
+			"eth1": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/web1_eth0"}}},
+		{"name": "/Compute-test/test/web/2", "vcable_id": "/Compute-test/test/vcable-2", "networking": {
+			"eth1": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/web2_eth0"}}}]}`)
+
+	created := 0
+	store.store = func(r *http.Request, object map[string]interface{}) int {
+		if r.Method == "POST" && object["name"] == nil {
+			// Like the API, name IP associations of the shared network
+			created++
+			object["name"] = fmt.Sprintf("/Compute-test/test/association-%d", created)
+			object["reservation"] = strings.TrimPrefix(object["parentpool"].(string), "ipreservation:")
+		}
+		if fault != nil {
+			return fault(r, object)
+		}
+		return 0
+	}
+
+	client, closeServer := store.newClient()
+	return client, &store.requests, closeServer
+}
@@ -0,0 +1,182 @@
+package compute
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+)
+
+const waitForRebootInstanceRequestPollInterval = 10 * time.Second
+const waitForRebootInstanceRequestTimeout = 3600 * time.Second
+
+// RebootInstanceRequestsClient is a client for the Reboot Instance Request functions of the Compute API.
+type RebootInstanceRequestsClient struct {
+	ResourceClient
+}
+
+// RebootInstanceRequests obtains a RebootInstanceRequestsClient which can be used to access to the
+// Reboot Instance Request functions of the Compute API
+func (c *Client) RebootInstanceRequests() *RebootInstanceRequestsClient {
+	return &RebootInstanceRequestsClient{
+		ResourceClient: ResourceClient{
+			Client:              c,
+			ResourceDescription: "reboot instance request",
+			ContainerPath:       "/rebootinstancerequest/",
+			ResourceRootPath:    "/rebootinstancerequest",
+		}}
+}
+
+// RebootInstanceRequestState defines the constant states a reboot instance request can be in
+type RebootInstanceRequestState string
+
+const (
+	// RebootInstanceRequestQueued - queued
+	RebootInstanceRequestQueued RebootInstanceRequestState = "queued"
+	// RebootInstanceRequestActive - active
+	RebootInstanceRequestActive RebootInstanceRequestState = "active"
+	// RebootInstanceRequestComplete - complete
+	RebootInstanceRequestComplete RebootInstanceRequestState = "complete"
+	// RebootInstanceRequestError - error
+	RebootInstanceRequestError RebootInstanceRequestState = "error"
+)
+
+// RebootInstanceRequestInfo describes an existing Reboot Instance Request.
+type RebootInstanceRequestInfo struct {
+	// Timestamp when this request was created.
+	CreationTime opc.Time `json:"creation_time"`
+	// A description of the reason this request entered "error" state.
+	ErrorReason string `json:"error_reason"`
+	// Whether a hard reset was requested instead of a soft restart.
+	Hard bool `json:"hard"`
+	// Name of the instance to reboot, in the form name/id.
+	Instance string `json:"instance"`
+	// The ID of the instance to reboot.
+	InstanceID string `json:"instance_id"`
+	// Name of the reboot instance request.
+	Name string `json:"name"`
+	// The state of the request.
+	State RebootInstanceRequestState `json:"state"`
+	// Uniform Resource Identifier
+	URI string `json:"uri"`
+}
+
+// CreateRebootInstanceRequestInput defines a Reboot Instance Request to be created.
+type CreateRebootInstanceRequestInput struct {
+	// Set to true to perform a hard reset of the instance, instead of a soft restart.
+	// A hard reset is equivalent to pulling the power, and should only be used when the
+	// instance is not responding.
+	// Optional
+	Hard bool `json:"hard"`
+	// Name of the instance to reboot, in the form name/id.
+	// Required
+	Instance string `json:"instance"`
+	// Time to wait between polls to check status
+	PollInterval time.Duration `json:"-"`
+	// Time to wait for the request to complete and the instance to be running again
+	Timeout time.Duration `json:"-"`
+}
+
+// CreateRebootInstanceRequest reboots an instance, waiting for the request to complete
+// and the instance to return to the running state.
+func (c *RebootInstanceRequestsClient) CreateRebootInstanceRequest(input *CreateRebootInstanceRequestInput) (*RebootInstanceRequestInfo, error) {
+	input.Instance = c.getQualifiedName(input.Instance)
+	name, id, err := c.splitInstanceName(input.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("Instance to reboot must be specified as name/id, got %q", input.Instance)
+	}
+
+	var requestInfo RebootInstanceRequestInfo
+	if err := c.createResource(input, &requestInfo); err != nil {
+		return nil, err
+	}
+
+	if input.PollInterval == 0 {
+		input.PollInterval = waitForRebootInstanceRequestPollInterval
+	}
+	if input.Timeout == 0 {
+		input.Timeout = waitForRebootInstanceRequestTimeout
+	}
+
+	getInput := &GetRebootInstanceRequestInput{
+		Name: requestInfo.Name,
+	}
+	info, err := c.WaitForRebootInstanceRequestComplete(getInput, input.PollInterval, input.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request completes once the reboot has been issued, wait for the instance to come back up
+	getInstanceInput := &GetInstanceInput{
+		Name: name,
+		ID:   id,
+	}
+	if _, err := c.Client.Instances().WaitForInstanceRunning(getInstanceInput, input.PollInterval, input.Timeout); err != nil {
+		return nil, fmt.Errorf("Error waiting for instance %s to restart: %s", input.Instance, err)
+	}
+
+	return info, nil
+}
+
+// GetRebootInstanceRequestInput describes the Reboot Instance Request to get
+type GetRebootInstanceRequestInput struct {
+	// Name of the reboot instance request.
+	// Required
+	Name string `json:"name"`
+}
+
+// GetRebootInstanceRequest retrieves the Reboot Instance Request with the given name.
+func (c *RebootInstanceRequestsClient) GetRebootInstanceRequest(input *GetRebootInstanceRequestInput) (*RebootInstanceRequestInfo, error) {
+	var requestInfo RebootInstanceRequestInfo
+	if err := c.getResource(input.Name, &requestInfo); err != nil {
+		return nil, err
+	}
+
+	return c.success(&requestInfo)
+}
+
+// DeleteRebootInstanceRequestInput describes the Reboot Instance Request to delete
+type DeleteRebootInstanceRequestInput struct {
+	// Name of the reboot instance request.
+	// Required
+	Name string `json:"name"`
+}
+
+// DeleteRebootInstanceRequest deletes the Reboot Instance Request with the given name.
+func (c *RebootInstanceRequestsClient) DeleteRebootInstanceRequest(input *DeleteRebootInstanceRequestInput) error {
+	return c.deleteResource(input.Name)
+}
+
+// WaitForRebootInstanceRequestComplete waits for a reboot instance request to be complete.
+func (c *RebootInstanceRequestsClient) WaitForRebootInstanceRequestComplete(input *GetRebootInstanceRequestInput, pollInterval, timeout time.Duration) (*RebootInstanceRequestInfo, error) {
+	var info *RebootInstanceRequestInfo
+	var getErr error
+	err := c.client.WaitFor("reboot instance request to be complete", pollInterval, timeout, func() (bool, error) {
+		info, getErr = c.GetRebootInstanceRequest(input)
+		if getErr != nil {
+			return false, getErr
+		}
+		switch s := info.State; s {
+		case RebootInstanceRequestError:
+			return false, fmt.Errorf("Error rebooting instance: %s", info.ErrorReason)
+		case RebootInstanceRequestComplete:
+			c.client.DebugLogString("Reboot Instance Request Complete")
+			return true, nil
+		case RebootInstanceRequestQueued:
+			c.client.DebugLogString("Reboot Instance Request Queuing")
+			return false, nil
+		case RebootInstanceRequestActive:
+			c.client.DebugLogString("Reboot Instance Request Active")
+			return false, nil
+		default:
+			c.client.DebugLogString(fmt.Sprintf("Unknown reboot instance request state: %s, waiting", s))
+			return false, nil
+		}
+	})
+	return info, err
+}
+
+func (c *RebootInstanceRequestsClient) success(info *RebootInstanceRequestInfo) (*RebootInstanceRequestInfo, error) {
+	c.unqualify(&info.Name, &info.Instance)
+	return info, nil
+}
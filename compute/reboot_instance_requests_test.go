@@ -0,0 +1,159 @@
+package compute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Test that the client can reboot an instance and wait for it to be running again.
+func TestRebootInstanceRequestsClient_CreateRebootInstanceRequest(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			expectedPath := "/rebootinstancerequest/"
+			if r.URL.Path != expectedPath {
+				t.Errorf("Wrong HTTP URL %v, expected %v", r.URL.Path, expectedPath)
+			}
+
+			request := &CreateRebootInstanceRequestInput{}
+			unmarshalRequestBody(t, r, request)
+
+			if request.Instance != "/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908" {
+				t.Errorf("Expected instance '/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908', was %s", request.Instance)
+			}
+			if !request.Hard {
+				t.Errorf("Expected a hard reset to be requested")
+			}
+
+			w.Write([]byte(exampleRebootInstanceRequestResponse("queued")))
+		case "GET":
+			switch r.URL.Path {
+			case "/rebootinstancerequest/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908/8f4a0f9a-6d2b-4a53-a1d8-50e5b2c3b7d1":
+				w.Write([]byte(exampleRebootInstanceRequestResponse("complete")))
+			case "/instance/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908":
+				w.Write([]byte(exampleRetrieveResponse))
+			default:
+				t.Errorf("Wrong HTTP URL %v", r.URL.Path)
+			}
+		default:
+			t.Errorf("Wrong HTTP method %s, expected POST or GET", r.Method)
+		}
+	})
+
+	defer server.Close()
+	client, err := getStubRebootInstanceRequestsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateRebootInstanceRequestInput{
+		Instance:     "name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908",
+		Hard:         true,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	info, err := client.CreateRebootInstanceRequest(input)
+	if err != nil {
+		t.Fatalf("Reboot instance request failed: %s", err)
+	}
+
+	if info.State != RebootInstanceRequestComplete {
+		t.Errorf("Expected state 'complete', was %s", info.State)
+	}
+	if info.Instance != "name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908" {
+		t.Errorf("Expected instance 'name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908', was %s", info.Instance)
+	}
+}
+
+// Test that the client can reboot an instance of another user, given by its qualified name.
+func TestRebootInstanceRequestsClient_CreateRebootInstanceRequestOtherUser(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			request := &CreateRebootInstanceRequestInput{}
+			unmarshalRequestBody(t, r, request)
+
+			if request.Instance != "/Compute-test/jane@example.com/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908" {
+				t.Errorf("Expected instance '/Compute-test/jane@example.com/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908', was %s", request.Instance)
+			}
+
+			w.Write([]byte(exampleRebootInstanceRequestResponse("queued")))
+		case "GET":
+			switch r.URL.Path {
+			case "/rebootinstancerequest/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908/8f4a0f9a-6d2b-4a53-a1d8-50e5b2c3b7d1":
+				w.Write([]byte(exampleRebootInstanceRequestResponse("complete")))
+			case "/instance/Compute-test/jane@example.com/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908":
+				w.Write([]byte(exampleRetrieveResponse))
+			default:
+				t.Errorf("Wrong HTTP URL %v", r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		default:
+			t.Errorf("Wrong HTTP method %s, expected POST or GET", r.Method)
+		}
+	})
+
+	defer server.Close()
+	client, err := getStubRebootInstanceRequestsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateRebootInstanceRequestInput{
+		Instance:     "/Compute-test/jane@example.com/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := client.CreateRebootInstanceRequest(input); err != nil {
+		t.Fatalf("Reboot instance request failed: %s", err)
+	}
+}
+
+// Test that the instance must be specified with its ID.
+func TestRebootInstanceRequestsClient_CreateRebootInstanceRequestMissingID(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected HTTP request %s %s", r.Method, r.URL.Path)
+	})
+
+	defer server.Close()
+	client, err := getStubRebootInstanceRequestsClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateRebootInstanceRequestInput{
+		Instance: "name",
+	}
+	if _, err := client.CreateRebootInstanceRequest(input); err == nil {
+		t.Fatal("Expected an error rebooting an instance without an ID")
+	}
+}
+
+func getStubRebootInstanceRequestsClient(server *httptest.Server) (*RebootInstanceRequestsClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RebootInstanceRequests(), nil
+}
+
+func exampleRebootInstanceRequestResponse(state string) string {
+	return `{
+  "name": "/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908/8f4a0f9a-6d2b-4a53-a1d8-50e5b2c3b7d1",
+  "hard": true,
+  "instance": "/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908",
+  "instance_id": "016e75e7-e911-42d1-bfe1-6a7f1b3f7908",
+  "state": "` + state + `",
+  "error_reason": "",
+  "creation_time": "2018-06-01T10:12:45Z",
+  "uri": "https://api.compute.example.com/rebootinstancerequest/Compute-test/test/name/016e75e7-e911-42d1-bfe1-6a7f1b3f7908/8f4a0f9a-6d2b-4a53-a1d8-50e5b2c3b7d1"
+}`
+}
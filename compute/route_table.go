@@ -0,0 +1,293 @@
+package compute
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-oracle-terraform/client"
+)
+
+// RouteTable is an in-memory model of the routes of the IP networks, with the vNIC sets of their next hops
+// and the instances of their vNICs, to predict how packets are routed without calling the API for every question.
+type RouteTable struct {
+	// The routes
+	Routes []RouteInfo
+	// The vNIC sets, by name
+	VirtualNICSets map[string]VirtualNICSet
+	// The instance of each vNIC, in the form name/id, by vNIC name
+	VirtualNICInstances map[string]string
+}
+
+// LoadRouteTable loads the routes of the owner of the client into a route table, along with the vNIC sets and the vNICs
+// of the owner's instances. The next-hop vNIC sets of the routes are loaded too, even if they're owned by other users.
+func (c *Client) LoadRouteTable() (*RouteTable, error) {
+	table := &RouteTable{
+		VirtualNICSets: map[string]VirtualNICSet{},
+	}
+
+	routes, err := c.Routes().ListRoutes()
+	if err != nil {
+		return nil, err
+	}
+	table.Routes = routes
+
+	vnicSets, err := c.VirtNICSets().ListVirtualNICSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, vnicSet := range vnicSets {
+		table.VirtualNICSets[vnicSet.Name] = vnicSet
+	}
+	for _, route := range routes {
+		if _, ok := table.VirtualNICSets[route.NextHopVnicSet]; ok || route.NextHopVnicSet == "" {
+			continue
+		}
+		vnicSet, err := c.VirtNICSets().GetVirtualNICSet(&GetVirtualNICSetInput{Name: route.NextHopVnicSet})
+		if err != nil {
+			if client.WasNotFoundError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Error loading vNIC set %s of route %s: %s", route.NextHopVnicSet, route.Name, err)
+		}
+		table.VirtualNICSets[route.NextHopVnicSet] = *vnicSet
+	}
+
+	instances, err := c.Instances().ListInstances()
+	if err != nil {
+		return nil, err
+	}
+	table.VirtualNICInstances, _ = instanceVirtualNICs(instances)
+	return table, nil
+}
+
+// EffectiveRoute is how packets to a destination are routed: over the routes with the longest IP address prefix
+// containing the destination, and of those, the ones with the lowest admin distance, load-balanced with ECMP.
+type EffectiveRoute struct {
+	// The destination IP address
+	Destination string
+	// The routes the packets are routed over. Empty if no route matches the destination.
+	Routes []string
+	// The IP address prefix of the routes
+	IPAddressPrefix string
+	// The admin distance of the routes
+	AdminDistance int
+	// The vNICs of the next-hop vNIC sets of the routes
+	NextHopVirtualNICs []string
+	// The instances of the next-hop vNICs, in the form name/id
+	NextHopInstances []string
+	// How the routes were chosen, one step per line
+	Trace []string
+}
+
+func (r *EffectiveRoute) trace(format string, args ...interface{}) {
+	r.Trace = append(r.Trace, fmt.Sprintf(format, args...))
+}
+
+// Reachable returns whether packets to the destination are routed to at least one vNIC
+func (r *EffectiveRoute) Reachable() bool {
+	return len(r.NextHopVirtualNICs) > 0
+}
+
+// A route with its parsed IP address prefix
+type parsedRoute struct {
+	*RouteInfo
+	prefix       *net.IPNet
+	prefixLength int
+}
+
+// Returns the routes with valid IP address prefixes, and the names of the others
+func (t *RouteTable) parseRoutes() ([]parsedRoute, []string) {
+	routes := []parsedRoute{}
+	invalid := []string{}
+	for i := range t.Routes {
+		prefix, err := ParseIPAddressPrefix(t.Routes[i].IPAddressPrefix)
+		if err != nil {
+			invalid = append(invalid, t.Routes[i].Name)
+			continue
+		}
+		ones, _ := prefix.Mask.Size()
+		routes = append(routes, parsedRoute{RouteInfo: &t.Routes[i], prefix: prefix, prefixLength: ones})
+	}
+	return routes, invalid
+}
+
+// EffectiveRoute returns how packets to the destination IP address are routed, and traces why the routes were chosen
+func (t *RouteTable) EffectiveRoute(destination string) (*EffectiveRoute, error) {
+	ip := net.ParseIP(destination).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%q isn't an IPv4 address", destination)
+	}
+
+	result := &EffectiveRoute{Destination: destination}
+	routes, invalid := t.parseRoutes()
+	for _, name := range invalid {
+		result.trace("Route %s is ignored, as its IP address prefix is invalid", name)
+	}
+	matching := []parsedRoute{}
+	for _, route := range routes {
+		if route.prefix.Contains(ip) {
+			matching = append(matching, route)
+		}
+	}
+	if len(matching) == 0 {
+		result.trace("No route matches %s", destination)
+		return result, nil
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		if matching[i].prefixLength != matching[j].prefixLength {
+			return matching[i].prefixLength > matching[j].prefixLength
+		}
+		if matching[i].AdminDistance != matching[j].AdminDistance {
+			return matching[i].AdminDistance < matching[j].AdminDistance
+		}
+		return matching[i].Name < matching[j].Name
+	})
+
+	best := matching[0]
+	result.IPAddressPrefix = best.prefix.String()
+	result.AdminDistance = best.AdminDistance
+	vnics := []string{}
+	for _, route := range matching {
+		switch {
+		case route.prefixLength < best.prefixLength:
+			result.trace("Route %s (%s) loses to the longer IP address prefix %s", route.Name, route.IPAddressPrefix, best.prefix)
+		case route.AdminDistance > best.AdminDistance:
+			result.trace("Route %s (%s, admin distance %d) loses to admin distance %d", route.Name, route.IPAddressPrefix, route.AdminDistance, best.AdminDistance)
+		default:
+			result.Routes = append(result.Routes, route.Name)
+			members := t.VirtualNICSets[route.NextHopVnicSet].VirtualNICs
+			result.trace("Route %s (%s, admin distance %d) matches, with next-hop vNIC set %s of vNICs %s",
+				route.Name, route.IPAddressPrefix, route.AdminDistance, route.NextHopVnicSet, describeList(members))
+			vnics = append(vnics, members...)
+		}
+	}
+	if len(result.Routes) > 1 {
+		result.trace("Packets are load-balanced over routes %s with ECMP", strings.Join(result.Routes, ", "))
+	}
+
+	result.NextHopVirtualNICs = uniqueSortedStrings(vnics)
+	instances := []string{}
+	for _, vnic := range result.NextHopVirtualNICs {
+		if instance, ok := t.VirtualNICInstances[vnic]; ok {
+			instances = append(instances, instance)
+		}
+	}
+	result.NextHopInstances = uniqueSortedStrings(instances)
+	if !result.Reachable() {
+		result.trace("Packets to %s are dropped, as the next-hop vNIC sets have no vNICs", destination)
+	}
+	return result, nil
+}
+
+// RouteIssueKind is the kind of problem with a route
+type RouteIssueKind string
+
+const (
+	// RouteIssueInvalid - the IP address prefix of the route is invalid
+	RouteIssueInvalid RouteIssueKind = "invalid"
+	// RouteIssueShadowed - other routes take precedence for all destinations of the route
+	RouteIssueShadowed RouteIssueKind = "shadowed"
+	// RouteIssueUnreachable - the next-hop vNIC set of the route has no vNICs of instances
+	RouteIssueUnreachable RouteIssueKind = "unreachable"
+)
+
+// RouteIssue is a problem with a route that keeps it from routing packets
+type RouteIssue struct {
+	// The name of the route
+	Route string
+	// The kind of problem
+	Kind RouteIssueKind
+	// The details of the problem
+	Reason string
+}
+
+func (i RouteIssue) String() string {
+	return fmt.Sprintf("Route %s is %s: %s", i.Route, i.Kind, i.Reason)
+}
+
+// Issues returns the problems with the routes of the route table, by route name. A route is shadowed if routes with the same
+// IP address prefix and a lower admin distance, or routes with longer IP address prefixes, take precedence for all of its
+// destinations. A route is unreachable if its next-hop vNIC set isn't loaded, or has no vNICs of the instances in the table.
+func (t *RouteTable) Issues() []RouteIssue {
+	routes, invalid := t.parseRoutes()
+	issues := []RouteIssue{}
+	for _, name := range invalid {
+		issues = append(issues, RouteIssue{Route: name, Kind: RouteIssueInvalid, Reason: "its IP address prefix isn't in CIDR notation"})
+	}
+
+	for _, route := range routes {
+		if reason := shadowingReason(route, routes); reason != "" {
+			issues = append(issues, RouteIssue{Route: route.Name, Kind: RouteIssueShadowed, Reason: reason})
+		}
+		if reason := t.unreachableReason(route); reason != "" {
+			issues = append(issues, RouteIssue{Route: route.Name, Kind: RouteIssueUnreachable, Reason: reason})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Route < issues[j].Route
+	})
+	return issues
+}
+
+// Returns why other routes take precedence for all destinations of the route, or nothing if they don't
+func shadowingReason(route parsedRoute, routes []parsedRoute) string {
+	first, last := ipv4Range(route.prefix)
+	type addressRange struct{ first, last uint64 }
+	covering := []addressRange{}
+	moreSpecific := []string{}
+	for _, other := range routes {
+		if other.Name == route.Name || !route.prefix.Contains(other.prefix.IP) {
+			continue
+		}
+		switch {
+		case other.prefixLength == route.prefixLength && other.AdminDistance < route.AdminDistance:
+			return fmt.Sprintf("route %s has the same IP address prefix %s and a lower admin distance of %d", other.Name, route.prefix, other.AdminDistance)
+		case other.prefixLength > route.prefixLength:
+			otherFirst, otherLast := ipv4Range(other.prefix)
+			covering = append(covering, addressRange{otherFirst, otherLast})
+			moreSpecific = append(moreSpecific, other.Name)
+		}
+	}
+
+	sort.Slice(covering, func(i, j int) bool { return covering[i].first < covering[j].first })
+	next := first
+	for _, r := range covering {
+		if r.first > next {
+			return ""
+		}
+		if r.last+1 > next {
+			next = r.last + 1
+		}
+	}
+	if next <= last {
+		return ""
+	}
+	sort.Strings(moreSpecific)
+	return fmt.Sprintf("routes %s with longer IP address prefixes cover all of %s", strings.Join(moreSpecific, ", "), route.prefix)
+}
+
+// Returns why the next-hop vNIC set of the route can't be reached, or nothing if it can
+func (t *RouteTable) unreachableReason(route parsedRoute) string {
+	vnicSet, ok := t.VirtualNICSets[route.NextHopVnicSet]
+	if !ok {
+		return fmt.Sprintf("its next-hop vNIC set %s doesn't exist", route.NextHopVnicSet)
+	}
+	if len(vnicSet.VirtualNICs) == 0 {
+		return fmt.Sprintf("its next-hop vNIC set %s has no vNICs", route.NextHopVnicSet)
+	}
+	for _, vnic := range vnicSet.VirtualNICs {
+		if _, ok := t.VirtualNICInstances[vnic]; ok {
+			return ""
+		}
+	}
+	return fmt.Sprintf("none of the vNICs of its next-hop vNIC set %s are of an instance", route.NextHopVnicSet)
+}
+
+func describeList(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,145 @@
+package compute
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRouteTable_EffectiveRoute(t *testing.T) {
+	table := loadStubRouteTable(t)
+
+	cases := []struct {
+		destination string
+		routes      []string
+		instances   []string
+	}{
+		{"203.0.113.1", []string{"default"}, []string{"nat/1"}},
+		{"10.2.0.1", []string{"onprem", "onprem-ecmp"}, []string{"vpn/1", "vpn/3"}},
+		{"10.1.200.1", []string{"lab-b"}, []string{"vpn/1"}},
+		{"192.168.1.1", []string{"stale"}, []string{}},
+	}
+	for _, c := range cases {
+		route, err := table.EffectiveRoute(c.destination)
+		if err != nil {
+			t.Errorf("%s: %s", c.destination, err)
+			continue
+		}
+		if diff := pretty.Compare(route.Routes, c.routes); diff != "" {
+			t.Errorf("%s: Routes Diff: (-got +want)\n%s", c.destination, diff)
+		}
+		if diff := pretty.Compare(route.NextHopInstances, c.instances); diff != "" {
+			t.Errorf("%s: Instances Diff: (-got +want)\n%s", c.destination, diff)
+		}
+	}
+
+	route, err := table.EffectiveRoute("10.2.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &EffectiveRoute{
+		Destination:        "10.2.0.1",
+		Routes:             []string{"onprem", "onprem-ecmp"},
+		IPAddressPrefix:    "10.0.0.0/8",
+		NextHopVirtualNICs: []string{"vpn1_eth0", "vpn3_eth0"},
+		NextHopInstances:   []string{"vpn/1", "vpn/3"},
+		Trace: []string{
+			"Route onprem (10.0.0.0/8, admin distance 0) matches, with next-hop vNIC set vpn-primary of vNICs vpn1_eth0",
+			"Route onprem-ecmp (10.0.0.0/8, admin distance 0) matches, with next-hop vNIC set vpn-secondary of vNICs vpn3_eth0",
+			"Route onprem-backup (10.0.0.0/8, admin distance 1) loses to admin distance 0",
+			"Route default (0.0.0.0/0) loses to the longer IP address prefix 10.0.0.0/8",
+			"Packets are load-balanced over routes onprem, onprem-ecmp with ECMP",
+		},
+	}
+	if diff := pretty.Compare(route, expected); diff != "" {
+		t.Errorf("Effective Route Diff: (-got +want)\n%s", diff)
+	}
+
+	table.Routes = table.Routes[1:]
+	route, err = table.EffectiveRoute("203.0.113.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(route.Routes) != 0 || route.Reachable() {
+		t.Errorf("Expected no route to 203.0.113.1 without a default route, got %+v", route)
+	}
+
+	if _, err := table.EffectiveRoute("10.0.0.0/8"); err == nil {
+		t.Errorf("Expected an error for a destination that isn't an IP address")
+	}
+}
+
+func TestRouteTable_Issues(t *testing.T) {
+	table := loadStubRouteTable(t)
+	table.Routes = append(table.Routes, RouteInfo{Name: "broken", IPAddressPrefix: "10.0.0.0", NextHopVnicSet: "nat"})
+
+	expected := []RouteIssue{
+		{Route: "broken", Kind: RouteIssueInvalid, Reason: "its IP address prefix isn't in CIDR notation"},
+		{Route: "lab", Kind: RouteIssueShadowed, Reason: "routes lab-a, lab-b with longer IP address prefixes cover all of 10.1.0.0/16"},
+		{Route: "lab", Kind: RouteIssueUnreachable, Reason: "its next-hop vNIC set lab has no vNICs"},
+		{Route: "onprem-backup", Kind: RouteIssueShadowed, Reason: "route onprem has the same IP address prefix 10.0.0.0/8 and a lower admin distance of 0"},
+		{Route: "orphan", Kind: RouteIssueUnreachable, Reason: "its next-hop vNIC set /Compute-test/other/gone doesn't exist"},
+		{Route: "stale", Kind: RouteIssueUnreachable, Reason: "none of the vNICs of its next-hop vNIC set stale are of an instance"},
+	}
+	if diff := pretty.Compare(table.Issues(), expected); diff != "" {
+		t.Errorf("Issues Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func loadStubRouteTable(t *testing.T) *RouteTable {
+	responses := map[string]string{
+		"/network/v1/route/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/default", "ipAddressPrefix": "0.0.0.0/0", "adminDistance": 0, "nextHopVnicSet": "/Compute-test/test/nat"},
+			{"name": "/Compute-test/test/onprem", "ipAddressPrefix": "10.0.0.0/8", "adminDistance": 0, "nextHopVnicSet": "/Compute-test/test/vpn-primary"},
+			{"name": "/Compute-test/test/onprem-backup", "ipAddressPrefix": "10.0.0.0/8", "adminDistance": 1, "nextHopVnicSet": "/Compute-test/test/vpn-backup"},
+			{"name": "/Compute-test/test/onprem-ecmp", "ipAddressPrefix": "10.0.0.0/8", "adminDistance": 0, "nextHopVnicSet": "/Compute-test/test/vpn-secondary"},
+			{"name": "/Compute-test/test/lab", "ipAddressPrefix": "10.1.0.0/16", "adminDistance": 2, "nextHopVnicSet": "/Compute-test/test/lab"},
+			{"name": "/Compute-test/test/lab-a", "ipAddressPrefix": "10.1.0.0/17", "adminDistance": 2, "nextHopVnicSet": "/Compute-test/test/vpn-primary"},
+			{"name": "/Compute-test/test/lab-b", "ipAddressPrefix": "10.1.128.0/17", "adminDistance": 2, "nextHopVnicSet": "/Compute-test/test/vpn-primary"},
+			{"name": "/Compute-test/test/orphan", "ipAddressPrefix": "172.16.0.0/12", "adminDistance": 0, "nextHopVnicSet": "/Compute-test/other/gone"},
+			{"name": "/Compute-test/test/stale", "ipAddressPrefix": "192.168.0.0/16", "adminDistance": 0, "nextHopVnicSet": "/Compute-test/test/stale"}]}`,
+		"/network/v1/vnicset/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/nat", "vnics": ["/Compute-test/test/nat_eth0"]},
+			{"name": "/Compute-test/test/vpn-primary", "vnics": ["/Compute-test/test/vpn1_eth0"]},
+			{"name": "/Compute-test/test/vpn-backup", "vnics": ["/Compute-test/test/vpn2_eth0"]},
+			{"name": "/Compute-test/test/vpn-secondary", "vnics": ["/Compute-test/test/vpn3_eth0"]},
+			{"name": "/Compute-test/test/lab", "vnics": []},
+			{"name": "/Compute-test/test/stale", "vnics": ["/Compute-test/test/deleted_eth0"]}]}`,
+		"/instance/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/nat/1", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/nat_eth0"}}},
+			{"name": "/Compute-test/test/vpn/1", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/vpn1_eth0"}}},
+			{"name": "/Compute-test/test/vpn/2", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/vpn2_eth0"}}},
+			{"name": "/Compute-test/test/vpn/3", "networking": {"eth0": {"ipnetwork": "/Compute-test/test/private", "vnic": "/Compute-test/test/vpn3_eth0"}}}]}`,
+	}
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		// The next-hop vNIC set of another user has been deleted
+		if r.Method == "GET" && r.URL.Path == "/network/v1/vnicset/Compute-test/other/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if r.Method != "GET" || !ok {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	})
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := client.LoadRouteTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
@@ -1,5 +1,7 @@
 package compute
 
+import "fmt"
+
 const (
 	routesDescription   = "IP Network Route"
 	routesContainerPath = "/network/v1/route/"
@@ -73,6 +75,9 @@ type CreateRouteInput struct {
 
 // CreateRoute creates the requested route
 func (c *RoutesClient) CreateRoute(input *CreateRouteInput) (*RouteInfo, error) {
+	if _, err := ParseIPAddressPrefix(input.IPAddressPrefix); err != nil {
+		return nil, fmt.Errorf("Invalid IP address prefix of route %s: %s", input.Name, err)
+	}
 	input.Name = c.getQualifiedName(input.Name)
 	input.NextHopVnicSet = c.getQualifiedName(input.NextHopVnicSet)
 
@@ -134,6 +139,9 @@ type UpdateRouteInput struct {
 
 // UpdateRoute updates the specified route
 func (c *RoutesClient) UpdateRoute(input *UpdateRouteInput) (*RouteInfo, error) {
+	if _, err := ParseIPAddressPrefix(input.IPAddressPrefix); err != nil {
+		return nil, fmt.Errorf("Invalid IP address prefix of route %s: %s", input.Name, err)
+	}
 	input.Name = c.getQualifiedName(input.Name)
 	input.NextHopVnicSet = c.getQualifiedName(input.NextHopVnicSet)
 
@@ -157,6 +165,26 @@ func (c *RoutesClient) DeleteRoute(input *DeleteRouteInput) error {
 	return c.deleteResource(input.Name)
 }
 
+// RouteList represents the list of routes returned by the service
+type RouteList struct {
+	Result []RouteInfo `json:"result"`
+}
+
+// ListRoutes retrieves the routes of the client's owner
+func (c *RoutesClient) ListRoutes() ([]RouteInfo, error) {
+	var list RouteList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *RoutesClient) success(info *RouteInfo) (*RouteInfo, error) {
 	c.unqualify(&info.Name)
 	c.unqualify(&info.NextHopVnicSet)
@@ -171,8 +171,13 @@ func TestRoutesClient_CreateRoute(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if route.NextHopVnicSet != "test-vnic-set" {
-		t.Fatalf("Incorrect response 'NextHopVnicSet'. Got: %q Expected: %q", route.NextHopVnicSet, "test-vnic-set")
+	// Names of objects of other users, or of other identity domains, stay qualified
+	if route.Name != "/Compute-acme/jack.jones@example.com/test-route" {
+		t.Fatalf("Incorrect response 'Name'. Got: %q Expected: %q", route.Name, "/Compute-acme/jack.jones@example.com/test-route")
+	}
+
+	if route.NextHopVnicSet != "/Compute-acme/jack.jones@example.com/test-vnic-set" {
+		t.Fatalf("Incorrect response 'NextHopVnicSet'. Got: %q Expected: %q", route.NextHopVnicSet, "/Compute-acme/jack.jones@example.com/test-vnic-set")
 	}
 
 	if route.IPAddressPrefix != _RouteTestIPAddressPrefix {
@@ -184,6 +184,26 @@ func (c *SecRulesClient) DeleteSecRule(deleteInput *DeleteSecRuleInput) error {
 	return c.deleteResource(deleteInput.Name)
 }
 
+// SecRuleList represents the list of sec rules returned by the service
+type SecRuleList struct {
+	Result []SecRuleInfo `json:"result"`
+}
+
+// ListSecRules retrieves the sec rules of the client's owner.
+func (c *SecRulesClient) ListSecRules() ([]SecRuleInfo, error) {
+	var list SecRuleList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *SecRulesClient) success(ruleInfo *SecRuleInfo) (*SecRuleInfo, error) {
 	ruleInfo.Name = c.getUnqualifiedName(ruleInfo.Name)
 	ruleInfo.SourceList = c.unqualifyListName(ruleInfo.SourceList)
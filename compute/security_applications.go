@@ -105,6 +105,26 @@ const (
 	Unreachable SecurityApplicationICMPType = "unreachable"
 )
 
+// SecurityApplicationList represents the list of security applications returned by the service
+type SecurityApplicationList struct {
+	Result []SecurityApplicationInfo `json:"result"`
+}
+
+// ListSecurityApplications retrieves the security applications of the client's owner.
+func (c *SecurityApplicationsClient) ListSecurityApplications() ([]SecurityApplicationInfo, error) {
+	var list SecurityApplicationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *SecurityApplicationsClient) success(result *SecurityApplicationInfo) (*SecurityApplicationInfo, error) {
 	c.unqualify(&result.Name)
 	return result, nil
@@ -139,6 +159,9 @@ type CreateSecurityApplicationInput struct {
 
 // CreateSecurityApplication creates a new security application.
 func (c *SecurityApplicationsClient) CreateSecurityApplication(input *CreateSecurityApplicationInput) (*SecurityApplicationInfo, error) {
+	if err := validateSecurityApplication(input); err != nil {
+		return nil, err
+	}
 	input.Name = c.getQualifiedName(input.Name)
 
 	var appInfo SecurityApplicationInfo
@@ -89,6 +89,26 @@ func (c *SecurityAssociationsClient) DeleteSecurityAssociation(deleteInput *Dele
 	return c.deleteResource(deleteInput.Name)
 }
 
+// SecurityAssociationList represents the list of security associations returned by the service
+type SecurityAssociationList struct {
+	Result []SecurityAssociationInfo `json:"result"`
+}
+
+// ListSecurityAssociations retrieves the security associations of the client's owner.
+func (c *SecurityAssociationsClient) ListSecurityAssociations() ([]SecurityAssociationInfo, error) {
+	var list SecurityAssociationList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *SecurityAssociationsClient) success(assocInfo *SecurityAssociationInfo) (*SecurityAssociationInfo, error) {
 	c.unqualify(&assocInfo.Name, &assocInfo.SecList, &assocInfo.VCable)
 	return assocInfo, nil
@@ -107,6 +107,26 @@ func (c *SecurityIPListsClient) DeleteSecurityIPList(deleteInput *DeleteSecurity
 	return c.deleteResource(deleteInput.Name)
 }
 
+// SecurityIPListList represents the list of security IP lists returned by the service
+type SecurityIPListList struct {
+	Result []SecurityIPListInfo `json:"result"`
+}
+
+// ListSecurityIPLists gets the security IP lists of the client's owner.
+func (c *SecurityIPListsClient) ListSecurityIPLists() ([]SecurityIPListInfo, error) {
+	var list SecurityIPListList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *SecurityIPListsClient) success(listInfo *SecurityIPListInfo) (*SecurityIPListInfo, error) {
 	c.unqualify(&listInfo.Name)
 	return listInfo, nil
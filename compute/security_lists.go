@@ -129,6 +129,26 @@ func (c *SecurityListsClient) DeleteSecurityList(deleteInput *DeleteSecurityList
 	return c.deleteResource(deleteInput.Name)
 }
 
+// SecurityListList represents the list of security lists returned by the service
+type SecurityListList struct {
+	Result []SecurityListInfo `json:"result"`
+}
+
+// ListSecurityLists retrieves the security lists of the client's owner.
+func (c *SecurityListsClient) ListSecurityLists() ([]SecurityListInfo, error) {
+	var list SecurityListList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *SecurityListsClient) success(listInfo *SecurityListInfo) (*SecurityListInfo, error) {
 	c.unqualify(&listInfo.Name)
 	return listInfo, nil
@@ -91,6 +91,9 @@ type CreateSecurityProtocolInput struct {
 // CreateSecurityProtocol creates a new Security Protocol from an SecurityProtocolsClient and an input struct.
 // Returns a populated Info struct for the Security Protocol, and any errors
 func (c *SecurityProtocolsClient) CreateSecurityProtocol(input *CreateSecurityProtocolInput) (*SecurityProtocolInfo, error) {
+	if err := validateSecurityProtocol(input.Name, input.IPProtocol, input.DstPortSet, input.SrcPortSet); err != nil {
+		return nil, err
+	}
 	input.Name = c.getQualifiedName(input.Name)
 
 	var ipInfo SecurityProtocolInfo
@@ -164,6 +167,9 @@ type UpdateSecurityProtocolInput struct {
 
 // UpdateSecurityProtocol update the security protocol
 func (c *SecurityProtocolsClient) UpdateSecurityProtocol(updateInput *UpdateSecurityProtocolInput) (*SecurityProtocolInfo, error) {
+	if err := validateSecurityProtocol(updateInput.Name, updateInput.IPProtocol, updateInput.DstPortSet, updateInput.SrcPortSet); err != nil {
+		return nil, err
+	}
 	updateInput.Name = c.getQualifiedName(updateInput.Name)
 	var ipInfo SecurityProtocolInfo
 	if err := c.updateResource(updateInput.Name, updateInput, &ipInfo); err != nil {
@@ -185,6 +191,26 @@ func (c *SecurityProtocolsClient) DeleteSecurityProtocol(input *DeleteSecurityPr
 	return c.deleteResource(input.Name)
 }
 
+// SecurityProtocolList represents the list of security protocols returned by the service
+type SecurityProtocolList struct {
+	Result []SecurityProtocolInfo `json:"result"`
+}
+
+// ListSecurityProtocols returns the SecurityProtocolInfo structs of the client's owner
+func (c *SecurityProtocolsClient) ListSecurityProtocols() ([]SecurityProtocolInfo, error) {
+	var list SecurityProtocolList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 // Unqualifies any qualified fields in the SecurityProtocolInfo struct
 func (c *SecurityProtocolsClient) success(info *SecurityProtocolInfo) (*SecurityProtocolInfo, error) {
 	c.unqualify(&info.Name)
@@ -261,6 +261,26 @@ func (c *SecurityRuleClient) DeleteSecurityRule(input *DeleteSecurityRuleInput)
 	return c.deleteResource(input.Name)
 }
 
+// SecurityRuleList represents the list of security rules returned by the service
+type SecurityRuleList struct {
+	Result []SecurityRuleInfo `json:"result"`
+}
+
+// ListSecurityRules returns the SecurityRuleInfo structs of the client's owner
+func (c *SecurityRuleClient) ListSecurityRules() ([]SecurityRuleInfo, error) {
+	var list SecurityRuleList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 // Unqualifies any qualified fields in the IPNetworkExchangeInfo struct
 func (c *SecurityRuleClient) success(info *SecurityRuleInfo) (*SecurityRuleInfo, error) {
 	c.unqualify(&info.Name, &info.ACL, &info.SrcVnicSet, &info.DstVnicSet)
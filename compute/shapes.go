@@ -0,0 +1,163 @@
+package compute
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+)
+
+// ShapesClient is a client for the Shape functions of the Compute API.
+type ShapesClient struct {
+	ResourceClient
+}
+
+// Shapes obtains a ShapesClient which can be used to access to the
+// Shape functions of the Compute API
+func (c *Client) Shapes() *ShapesClient {
+	return &ShapesClient{
+		ResourceClient: ResourceClient{
+			Client:              c,
+			ResourceDescription: "shape",
+			ContainerPath:       "/shape/",
+			ResourceRootPath:    "/shape",
+		}}
+}
+
+// ShapeInfo describes an existing Shape.
+type ShapeInfo struct {
+	// Number of virtual CPUs provided by the shape. Fractional values are possible.
+	CPUs float64 `json:"cpus"`
+	// Number of GPUs provided by the shape.
+	GPUs int `json:"gpus"`
+	// IO share allocated to the shape.
+	IO int `json:"io"`
+	// Whether the root disk of instances launched with this shape is an SSD.
+	IsRootSSD bool `json:"is_root_ssd"`
+	// The name of the shape, such as oc3.
+	Name string `json:"name"`
+	// The IOPS limit for network storage attached to instances of this shape.
+	NDSIOPSLimit int `json:"nds_iops_limit"`
+	// Placement requirements of the shape.
+	PlacementRequirements []string `json:"placement_requirements"`
+	// Size of the memory, in MB.
+	RAM int `json:"ram"`
+	// Size of the root disk, for shapes with a local root disk.
+	RootDiskSize opc.ByteSize `json:"root_disk_size"`
+	// Size of the local SSD data disk, for high I/O shapes.
+	SSDDataSize opc.ByteSize `json:"ssd_data_size"`
+	// Uniform Resource Identifier
+	URI string `json:"uri"`
+}
+
+// OCPUs returns the number of Oracle CPUs provided by the shape.
+// One OCPU is a physical core with hyper-threading enabled, which is reported by the API as two CPUs.
+func (s *ShapeInfo) OCPUs() float64 {
+	return s.CPUs / 2
+}
+
+// Memory returns the size of the memory provided by the shape.
+func (s *ShapeInfo) Memory() opc.ByteSize {
+	return opc.ByteSize(s.RAM) * opc.MB
+}
+
+// ShapesInfo specifies a list of shapes
+type ShapesInfo struct {
+	Shapes []ShapeInfo `json:"result"`
+}
+
+// ListShapes retrieves all of the shapes available in the site.
+func (c *ShapesClient) ListShapes() ([]ShapeInfo, error) {
+	resp, err := c.executeRequest("GET", c.ContainerPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var shapesInfo ShapesInfo
+	if err := c.unmarshalResponseBody(resp, &shapesInfo); err != nil {
+		return nil, err
+	}
+	return shapesInfo.Shapes, nil
+}
+
+// GetShapeInput describes the Shape to get
+type GetShapeInput struct {
+	// The name of the shape, such as oc3.
+	// Required
+	Name string
+}
+
+// GetShape retrieves the Shape with the given name.
+// Shapes are site-wide objects, so the name isn't qualified with the identity domain or user.
+func (c *ShapesClient) GetShape(input *GetShapeInput) (*ShapeInfo, error) {
+	resp, err := c.executeRequest("GET", fmt.Sprintf("%s/%s", c.ResourceRootPath, input.Name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var shapeInfo ShapeInfo
+	if err := c.unmarshalResponseBody(resp, &shapeInfo); err != nil {
+		return nil, err
+	}
+	return &shapeInfo, nil
+}
+
+// SelectShapeInput describes the minimum resources a Shape must provide
+type SelectShapeInput struct {
+	// Minimum number of Oracle CPUs.
+	// Optional
+	MinOCPUs float64
+	// Minimum size of the memory, in MB.
+	// Optional
+	MinRAM int
+	// Minimum number of GPUs. Shapes with GPUs are only selected if GPUs are requested.
+	// Optional
+	MinGPUs int
+}
+
+// SelectShape returns the cheapest shape providing at least the requested resources.
+// The API doesn't expose pricing, so shapes are ranked by OCPUs, then memory, then GPUs,
+// which matches how the metered shapes are priced.
+func (c *ShapesClient) SelectShape(input *SelectShapeInput) (*ShapeInfo, error) {
+	shapes, err := c.ListShapes()
+	if err != nil {
+		return nil, err
+	}
+
+	shape := selectShape(shapes, input)
+	if shape == nil {
+		return nil, fmt.Errorf("No shape found with at least %v OCPUs, %d MB of memory and %d GPUs", input.MinOCPUs, input.MinRAM, input.MinGPUs)
+	}
+	return shape, nil
+}
+
+func selectShape(shapes []ShapeInfo, input *SelectShapeInput) *ShapeInfo {
+	candidates := []ShapeInfo{}
+	for _, shape := range shapes {
+		if shape.OCPUs() < input.MinOCPUs || shape.RAM < input.MinRAM || shape.GPUs < input.MinGPUs {
+			continue
+		}
+		if input.MinGPUs == 0 && shape.GPUs > 0 {
+			continue
+		}
+		candidates = append(candidates, shape)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.CPUs != b.CPUs {
+			return a.CPUs < b.CPUs
+		}
+		if a.RAM != b.RAM {
+			return a.RAM < b.RAM
+		}
+		if a.GPUs != b.GPUs {
+			return a.GPUs < b.GPUs
+		}
+		return a.Name < b.Name
+	})
+	return &candidates[0]
+}
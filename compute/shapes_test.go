@@ -0,0 +1,177 @@
+package compute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+)
+
+// Test that the client can list the shapes available in the site.
+func TestShapesClient_ListShapes(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Wrong HTTP method %s, expected GET", r.Method)
+		}
+
+		expectedPath := "/shape/"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Wrong HTTP URL %v, expected %v", r.URL.Path, expectedPath)
+		}
+
+		w.Write([]byte(exampleListShapesResponse))
+	})
+
+	defer server.Close()
+	client, err := getStubShapesClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	shapes, err := client.ListShapes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shapes) != 5 {
+		t.Fatalf("Expected 5 shapes, got %d", len(shapes))
+	}
+	if shapes[0].Name != "oc3" || shapes[0].OCPUs() != 1 || shapes[0].Memory() != 7680*opc.MB || shapes[0].SSDDataSize != 400*opc.GB {
+		t.Errorf("Unexpected shape: %+v", shapes[0])
+	}
+}
+
+// Test that the cheapest shape providing the requested resources is selected.
+func TestShapesClient_SelectShape(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(exampleListShapesResponse))
+	})
+
+	defer server.Close()
+	client, err := getStubShapesClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	tests := []struct {
+		input    SelectShapeInput
+		expected string
+	}{
+		{SelectShapeInput{}, "oc3"},
+		{SelectShapeInput{MinOCPUs: 1, MinRAM: 8192}, "oc1m"},
+		{SelectShapeInput{MinOCPUs: 2}, "oc4"},
+		{SelectShapeInput{MinOCPUs: 2, MinRAM: 16384}, "oc8"},
+		{SelectShapeInput{MinGPUs: 1}, "ocg1"},
+	}
+	for _, test := range tests {
+		input := test.input
+		shape, err := client.SelectShape(&input)
+		if err != nil {
+			t.Fatalf("Error selecting shape for %+v: %s", test.input, err)
+		}
+		if shape.Name != test.expected {
+			t.Errorf("Expected shape %s for %+v, got %s", test.expected, test.input, shape.Name)
+		}
+	}
+
+	if _, err := client.SelectShape(&SelectShapeInput{MinOCPUs: 64}); err == nil {
+		t.Errorf("Expected an error when no shape fits")
+	}
+}
+
+// Test that an unknown shape is rejected before the launch plan is submitted.
+func TestInstanceClient_CreateInstanceUnknownShape(t *testing.T) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Wrong HTTP method %s, expected GET", r.Method)
+		}
+
+		expectedPath := "/shape/oc99"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Wrong HTTP URL %v, expected %v", r.URL.Path, expectedPath)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	defer server.Close()
+	iv, err := getStubInstancesClient(server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateInstanceInput{
+		Name:          "name",
+		Shape:         "oc99",
+		ImageList:     "imagelist",
+		ValidateShape: true,
+	}
+	if _, err := iv.CreateInstance(input); err == nil {
+		t.Fatal("Expected an error creating an instance with an unknown shape")
+	}
+}
+
+func getStubShapesClient(server *httptest.Server) (*ShapesClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Shapes(), nil
+}
+
+var exampleListShapesResponse = `
+{
+  "result": [
+    {
+      "name": "oc3",
+      "cpus": 2.0,
+      "ram": 7680,
+      "gpus": 0,
+      "io": 200,
+      "is_root_ssd": false,
+      "nds_iops_limit": 0,
+      "placement_requirements": [],
+      "root_disk_size": 0,
+      "ssd_data_size": 429496729600,
+      "uri": "https://api.compute.example.com/shape/oc3"
+    },
+    {
+      "name": "oc4",
+      "cpus": 4.0,
+      "ram": 15360,
+      "gpus": 0,
+      "io": 400,
+      "uri": "https://api.compute.example.com/shape/oc4"
+    },
+    {
+      "name": "oc1m",
+      "cpus": 2.0,
+      "ram": 15360,
+      "gpus": 0,
+      "io": 200,
+      "uri": "https://api.compute.example.com/shape/oc1m"
+    },
+    {
+      "name": "ocg1",
+      "cpus": 4.0,
+      "ram": 15360,
+      "gpus": 1,
+      "io": 400,
+      "uri": "https://api.compute.example.com/shape/ocg1"
+    },
+    {
+      "name": "oc8",
+      "cpus": 32.0,
+      "ram": 245760,
+      "gpus": 0,
+      "io": 800,
+      "uri": "https://api.compute.example.com/shape/oc8"
+    }
+  ]
+}
+`
@@ -0,0 +1,410 @@
+package compute
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// SharedNetworkModel is an in-memory model of the security lists, sec rules, security applications,
+// security IP lists and instances of the shared network, to analyze which flows are permitted
+// without calling the API for every question.
+type SharedNetworkModel struct {
+	// The security lists, by name
+	SecurityLists map[string]SecurityListInfo
+	// The sec rules
+	SecRules []SecRuleInfo
+	// The security applications, by name
+	SecurityApplications map[string]SecurityApplicationInfo
+	// The security IP lists, by name
+	SecurityIPLists map[string]SecurityIPListInfo
+	// The names of the security lists of each instance, by instance name/id
+	InstanceSecurityLists map[string][]string
+	// The IP address of each instance on the shared network, by instance name/id
+	InstanceIPAddresses map[string]string
+	// The instance of each vcable, in the form name/id, by vcable name
+	VCableInstances map[string]string
+}
+
+// LoadSharedNetworkModel loads the shared network objects of the owner of the client into a model.
+// The security applications, security IP lists and security lists the sec rules refer to are loaded too,
+// including the predefined ones under /oracle/public, such as /oracle/public/ssh and /oracle/public/public-internet.
+func (c *Client) LoadSharedNetworkModel() (*SharedNetworkModel, error) {
+	model := &SharedNetworkModel{
+		SecurityLists:         map[string]SecurityListInfo{},
+		SecurityApplications:  map[string]SecurityApplicationInfo{},
+		SecurityIPLists:       map[string]SecurityIPListInfo{},
+		InstanceSecurityLists: map[string][]string{},
+		InstanceIPAddresses:   map[string]string{},
+		VCableInstances:       map[string]string{},
+	}
+
+	securityLists, err := c.SecurityLists().ListSecurityLists()
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range securityLists {
+		model.SecurityLists[list.Name] = list
+	}
+
+	secRules, err := c.SecRules().ListSecRules()
+	if err != nil {
+		return nil, err
+	}
+	model.SecRules = secRules
+
+	applications, err := c.SecurityApplications().ListSecurityApplications()
+	if err != nil {
+		return nil, err
+	}
+	for _, application := range applications {
+		model.SecurityApplications[application.Name] = application
+	}
+
+	ipLists, err := c.SecurityIPLists().ListSecurityIPLists()
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range ipLists {
+		model.SecurityIPLists[list.Name] = list
+	}
+
+	instances, err := c.Instances().ListInstances()
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		key := fmt.Sprintf(cmpQualifiedName, instance.Name, instance.ID)
+		model.VCableInstances[instance.VCableID] = key
+		model.InstanceIPAddresses[key] = instance.IPAddress
+		lists := []string{}
+		for _, info := range instance.Networking {
+			if info.IPNetwork == "" {
+				for _, list := range info.SecLists {
+					lists = append(lists, c.getUnqualifiedName(list))
+				}
+			}
+		}
+		model.InstanceSecurityLists[key] = lists
+	}
+
+	associations, err := c.SecurityAssociations().ListSecurityAssociations()
+	if err != nil {
+		return nil, err
+	}
+	for _, association := range associations {
+		if instance, ok := model.VCableInstances[association.VCable]; ok {
+			model.InstanceSecurityLists[instance] = append(model.InstanceSecurityLists[instance], association.SecList)
+		}
+	}
+	for instance, lists := range model.InstanceSecurityLists {
+		model.InstanceSecurityLists[instance] = uniqueSortedStrings(lists)
+	}
+
+	if err := c.loadSecRuleReferences(model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// Loads the objects the sec rules refer to that aren't in the model yet
+func (c *Client) loadSecRuleReferences(model *SharedNetworkModel) error {
+	for _, rule := range model.SecRules {
+		if _, ok := model.SecurityApplications[rule.Application]; !ok && rule.Application != "" {
+			application, err := c.SecurityApplications().GetSecurityApplication(&GetSecurityApplicationInput{Name: rule.Application})
+			if err != nil {
+				return fmt.Errorf("Error loading security application %s of sec rule %s: %s", rule.Application, rule.Name, err)
+			}
+			model.SecurityApplications[rule.Application] = *application
+		}
+
+		for _, listName := range []string{rule.SourceList, rule.DestinationList} {
+			list, err := ParseObjectName(listName)
+			if err != nil {
+				return fmt.Errorf("Sec rule %s has an invalid list %q: %s", rule.Name, listName, err)
+			}
+			name := securityListKey(list)
+			switch list.ListType {
+			case SecurityIPListType:
+				if _, ok := model.SecurityIPLists[name]; ok {
+					continue
+				}
+				ipList, err := c.SecurityIPLists().GetSecurityIPList(&GetSecurityIPListInput{Name: name})
+				if err != nil {
+					return fmt.Errorf("Error loading security IP list %s of sec rule %s: %s", name, rule.Name, err)
+				}
+				model.SecurityIPLists[name] = *ipList
+			default:
+				if _, ok := model.SecurityLists[name]; ok {
+					continue
+				}
+				securityList, err := c.SecurityLists().GetSecurityList(&GetSecurityListInput{Name: name})
+				if err != nil {
+					return fmt.Errorf("Error loading security list %s of sec rule %s: %s", name, rule.Name, err)
+				}
+				model.SecurityLists[name] = *securityList
+			}
+		}
+	}
+	return nil
+}
+
+// NetworkEndpoint is the source or destination of a flow: either an instance, or a host outside of the shared network
+type NetworkEndpoint struct {
+	// The name of an instance, in the form name/id
+	Instance string
+	// The IP address of a host, such as a host on the internet
+	IPAddress string
+}
+
+func (e NetworkEndpoint) String() string {
+	if e.Instance != "" {
+		return fmt.Sprintf("instance %s", e.Instance)
+	}
+	return fmt.Sprintf("host %s", e.IPAddress)
+}
+
+// ReachabilityQuery asks whether a flow from a source to a destination is permitted
+type ReachabilityQuery struct {
+	// The source of the flow
+	// Required
+	Source NetworkEndpoint
+	// The destination of the flow. At least one of the source and destination must be an instance.
+	// Required
+	Destination NetworkEndpoint
+	// The protocol of the flow, such as tcp
+	// Required
+	Protocol SecurityApplicationProtocol
+	// The destination port of the flow, for tcp and udp
+	// Optional
+	Port int
+}
+
+// ReachabilityVerdict is the answer to a ReachabilityQuery
+type ReachabilityVerdict struct {
+	// Whether the flow is permitted
+	Allowed bool
+	// The sec rule that permits the flow, if a rule does
+	SecRule string
+	// The security list that decides the flow, if the instances share it or no rule permits the flow
+	SecurityList string
+	// How the verdict was reached, one step per line
+	Explanation []string
+}
+
+func (v *ReachabilityVerdict) explain(format string, args ...interface{}) {
+	v.Explanation = append(v.Explanation, fmt.Sprintf(format, args...))
+}
+
+// CanReach answers whether the flow of a query is permitted, and explains which sec rule or policy decides it.
+// Instances in the same security list can always reach each other. Otherwise an inbound flow to an instance
+// is permitted by an enabled sec rule from a security list of the source, or a security IP list containing
+// the source, to a security list of the destination, with a matching security application. Failing that,
+// the inbound policy of the destination's security lists applies, and the outbound CIDR policy of the
+// source's security lists applies to flows to hosts outside of the shared network.
+// ICMP types and codes aren't modeled, so an icmp security application matches all ICMP flows.
+func (m *SharedNetworkModel) CanReach(query *ReachabilityQuery) (*ReachabilityVerdict, error) {
+	if query.Protocol == "" {
+		return nil, fmt.Errorf("The protocol of the flow needs to be specified")
+	}
+	if query.Source.Instance == "" && query.Destination.Instance == "" {
+		return nil, fmt.Errorf("At least one of the source and destination of the flow needs to be an instance")
+	}
+	source, err := m.resolveEndpoint(query.Source)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := m.resolveEndpoint(query.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &ReachabilityVerdict{}
+	for _, endpoint := range []*resolvedEndpoint{source, destination} {
+		if endpoint.instance != "" {
+			verdict.explain("%s has IP address %s and is in security lists %s", endpoint, endpoint.ip, strings.Join(endpoint.lists, ", "))
+		}
+	}
+
+	if source.instance != "" && destination.instance != "" {
+		for _, list := range source.lists {
+			if containsString(destination.lists, list) {
+				verdict.Allowed = true
+				verdict.SecurityList = list
+				verdict.explain("Both instances are in security list %s, whose members can always reach each other", list)
+				return verdict, nil
+			}
+		}
+	}
+
+	for _, rule := range m.SecRules {
+		if !m.matchesList(rule.SourceList, source) || !m.matchesList(rule.DestinationList, destination) {
+			continue
+		}
+		if rule.Disabled {
+			verdict.explain("Sec rule %s from %s to %s doesn't apply, as it's disabled", rule.Name, rule.SourceList, rule.DestinationList)
+			continue
+		}
+		if !strings.EqualFold(rule.Action, "PERMIT") {
+			verdict.explain("Sec rule %s from %s to %s doesn't apply, as its action is %s", rule.Name, rule.SourceList, rule.DestinationList, rule.Action)
+			continue
+		}
+		application, ok := m.SecurityApplications[rule.Application]
+		if !ok {
+			verdict.explain("Sec rule %s from %s to %s doesn't apply, as its security application %s isn't loaded", rule.Name, rule.SourceList, rule.DestinationList, rule.Application)
+			continue
+		}
+		matches, err := securityApplicationMatches(&application, query.Protocol, query.Port)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			verdict.explain("Sec rule %s from %s to %s doesn't apply, as security application %s is %s", rule.Name, rule.SourceList, rule.DestinationList, application.Name, describeSecurityApplication(&application))
+			continue
+		}
+		verdict.Allowed = true
+		verdict.SecRule = rule.Name
+		verdict.explain("Sec rule %s permits %s from %s to %s", rule.Name, describeSecurityApplication(&application), rule.SourceList, rule.DestinationList)
+		return verdict, nil
+	}
+
+	if destination.instance != "" {
+		verdict.explain("No sec rule permits the flow, so the inbound policy of the security lists of %s applies", destination)
+		m.applyPolicy(verdict, destination.lists, func(list *SecurityListInfo) SecurityListPolicy { return list.Policy })
+	} else {
+		verdict.explain("No sec rule permits the flow, so the outbound CIDR policy of the security lists of %s applies", source)
+		m.applyPolicy(verdict, source.lists, func(list *SecurityListInfo) SecurityListPolicy { return list.OutboundCIDRPolicy })
+	}
+	return verdict, nil
+}
+
+// Permits the flow if the policy of any of the security lists permits it
+func (m *SharedNetworkModel) applyPolicy(verdict *ReachabilityVerdict, lists []string, policy func(*SecurityListInfo) SecurityListPolicy) {
+	for _, name := range lists {
+		list, ok := m.SecurityLists[name]
+		if !ok {
+			verdict.explain("Security list %s isn't loaded", name)
+			continue
+		}
+		p := policy(&list)
+		permit := strings.EqualFold(string(p), string(SecurityListPolicyPermit))
+		verdict.explain("Security list %s has policy %s", name, strings.ToLower(string(p)))
+		if verdict.SecurityList == "" || (permit && !verdict.Allowed) {
+			verdict.SecurityList = name
+			verdict.Allowed = permit
+		}
+	}
+}
+
+// An endpoint with its security lists, if it's an instance, and its IP address
+type resolvedEndpoint struct {
+	NetworkEndpoint
+	instance string
+	ip       net.IP
+	lists    []string
+}
+
+func (m *SharedNetworkModel) resolveEndpoint(endpoint NetworkEndpoint) (*resolvedEndpoint, error) {
+	resolved := &resolvedEndpoint{NetworkEndpoint: endpoint}
+	address := endpoint.IPAddress
+	if endpoint.Instance != "" {
+		lists, ok := m.InstanceSecurityLists[endpoint.Instance]
+		if !ok {
+			return nil, fmt.Errorf("Instance %s isn't in the shared network model", endpoint.Instance)
+		}
+		resolved.instance = endpoint.Instance
+		resolved.lists = lists
+		if address == "" {
+			address = m.InstanceIPAddresses[endpoint.Instance]
+		}
+	}
+	if address != "" {
+		if resolved.ip = net.ParseIP(address); resolved.ip == nil {
+			return nil, fmt.Errorf("Invalid IP address %q of %s", address, endpoint)
+		}
+	} else if resolved.instance == "" {
+		return nil, fmt.Errorf("Either an instance or an IP address needs to be specified")
+	}
+	return resolved, nil
+}
+
+// Whether the source or destination list of a sec rule contains the endpoint
+func (m *SharedNetworkModel) matchesList(listName string, endpoint *resolvedEndpoint) bool {
+	list, err := ParseObjectName(listName)
+	if err != nil {
+		return false
+	}
+	name := securityListKey(list)
+	if list.ListType == SecurityIPListType {
+		if endpoint.ip == nil {
+			return false
+		}
+		ipList, ok := m.SecurityIPLists[name]
+		return ok && ipListContains(ipList.SecIPEntries, endpoint.ip)
+	}
+	return containsString(endpoint.lists, name)
+}
+
+// Returns the name of a security list or security IP list without its list type prefix
+func securityListKey(list ObjectName) string {
+	list.ListType = ""
+	return list.String()
+}
+
+func ipListContains(entries []string, ip net.IP) bool {
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+				return true
+			}
+		} else if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func securityApplicationMatches(application *SecurityApplicationInfo, protocol SecurityApplicationProtocol, port int) (bool, error) {
+	if application.Protocol == All {
+		return true, nil
+	}
+	if !strings.EqualFold(string(application.Protocol), string(protocol)) {
+		return false, nil
+	}
+	if application.Protocol != TCP && application.Protocol != UDP {
+		return true, nil
+	}
+	ports, err := application.Ports()
+	if err != nil {
+		return false, err
+	}
+	return ports.Contains(port), nil
+}
+
+func describeSecurityApplication(application *SecurityApplicationInfo) string {
+	if application.DPort == "" || (application.Protocol != TCP && application.Protocol != UDP) {
+		return string(application.Protocol)
+	}
+	return fmt.Sprintf("%s port %s", application.Protocol, application.DPort)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueSortedStrings(values []string) []string {
+	unique := []string{}
+	for _, v := range values {
+		if !containsString(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
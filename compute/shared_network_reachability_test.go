@@ -0,0 +1,199 @@
+package compute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestSharedNetworkModel_CanReach(t *testing.T) {
+	model := loadStubSharedNetworkModel(t)
+
+	cases := []struct {
+		name         string
+		query        ReachabilityQuery
+		allowed      bool
+		secRule      string
+		securityList string
+	}{
+		{
+			name:    "rule between security lists",
+			query:   ReachabilityQuery{Source: NetworkEndpoint{Instance: "web/1"}, Destination: NetworkEndpoint{Instance: "db/2"}, Protocol: TCP, Port: 5432},
+			allowed: true,
+			secRule: "web-to-db-pgsql",
+		},
+		{
+			name:         "port outside of the security application",
+			query:        ReachabilityQuery{Source: NetworkEndpoint{Instance: "web/1"}, Destination: NetworkEndpoint{Instance: "db/2"}, Protocol: TCP, Port: 22},
+			securityList: "db",
+		},
+		{
+			name:    "predefined security IP list and application",
+			query:   ReachabilityQuery{Source: NetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: NetworkEndpoint{Instance: "web/1"}, Protocol: TCP, Port: 443},
+			allowed: true,
+			secRule: "internet-to-web-https",
+		},
+		{
+			name:    "port range",
+			query:   ReachabilityQuery{Source: NetworkEndpoint{IPAddress: "203.0.113.20"}, Destination: NetworkEndpoint{Instance: "web/1"}, Protocol: TCP, Port: 5905},
+			allowed: true,
+			secRule: "office-to-web-vnc",
+		},
+		{
+			name:         "disabled rule",
+			query:        ReachabilityQuery{Source: NetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: NetworkEndpoint{Instance: "db/2"}, Protocol: TCP, Port: 22},
+			securityList: "db",
+		},
+		{
+			name:         "shared security list through an association",
+			query:        ReachabilityQuery{Source: NetworkEndpoint{Instance: "batch/3"}, Destination: NetworkEndpoint{Instance: "db/2"}, Protocol: UDP, Port: 9999},
+			allowed:      true,
+			securityList: "db",
+		},
+		{
+			name:         "outbound CIDR policy deny",
+			query:        ReachabilityQuery{Source: NetworkEndpoint{Instance: "db/2"}, Destination: NetworkEndpoint{IPAddress: "192.0.2.1"}, Protocol: TCP, Port: 443},
+			securityList: "db",
+		},
+		{
+			name:         "outbound CIDR policy permit",
+			query:        ReachabilityQuery{Source: NetworkEndpoint{Instance: "web/1"}, Destination: NetworkEndpoint{IPAddress: "192.0.2.1"}, Protocol: TCP, Port: 443},
+			allowed:      true,
+			securityList: "web",
+		},
+	}
+	for _, c := range cases {
+		verdict, err := model.CanReach(&c.query)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if verdict.Allowed != c.allowed || verdict.SecRule != c.secRule || verdict.SecurityList != c.securityList {
+			t.Errorf("%s: unexpected verdict %+v", c.name, verdict)
+		}
+	}
+}
+
+// Test that a blocked flow explains which rules didn't apply and which policy blocked it.
+func TestSharedNetworkModel_CanReachExplanation(t *testing.T) {
+	model := loadStubSharedNetworkModel(t)
+
+	query := &ReachabilityQuery{
+		Source:      NetworkEndpoint{IPAddress: "198.51.100.7"},
+		Destination: NetworkEndpoint{Instance: "db/2"},
+		Protocol:    TCP,
+		Port:        22,
+	}
+	verdict, err := model.CanReach(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"instance db/2 has IP address 10.0.0.2 and is in security lists db",
+		"Sec rule internet-to-db-ssh from seciplist:/oracle/public/public-internet to seclist:db doesn't apply, as it's disabled",
+		"No sec rule permits the flow, so the inbound policy of the security lists of instance db/2 applies",
+		"Security list db has policy deny",
+	}
+	if diff := pretty.Compare(verdict.Explanation, expected); diff != "" {
+		t.Errorf("Explanation Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestSharedNetworkModel_CanReachInvalid(t *testing.T) {
+	model := loadStubSharedNetworkModel(t)
+
+	invalid := []ReachabilityQuery{
+		{Source: NetworkEndpoint{IPAddress: "198.51.100.7"}, Destination: NetworkEndpoint{IPAddress: "192.0.2.1"}, Protocol: TCP},
+		{Source: NetworkEndpoint{Instance: "web/1"}, Destination: NetworkEndpoint{Instance: "missing/4"}, Protocol: TCP},
+		{Source: NetworkEndpoint{IPAddress: "not an address"}, Destination: NetworkEndpoint{Instance: "web/1"}, Protocol: TCP},
+		{Source: NetworkEndpoint{Instance: "web/1"}, Destination: NetworkEndpoint{Instance: "db/2"}},
+	}
+	for _, query := range invalid {
+		if _, err := model.CanReach(&query); err == nil {
+			t.Errorf("Expected an error for query %+v", query)
+		}
+	}
+}
+
+func loadStubSharedNetworkModel(t *testing.T) *SharedNetworkModel {
+	server, client := newStubResponseServer(t, stubSharedNetworkResponses())
+	defer server.Close()
+
+	model, err := client.LoadSharedNetworkModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"web/1":   {"web"},
+		"db/2":    {"db"},
+		"batch/3": {"db"},
+	}
+	if diff := pretty.Compare(model.InstanceSecurityLists, expected); diff != "" {
+		t.Fatalf("Instance Security Lists Diff: (-got +want)\n%s", diff)
+	}
+	return model
+}
+
+// The stub responses to the requests of LoadSharedNetworkModel
+func stubSharedNetworkResponses() map[string]string {
+	return map[string]string{
+		"/seclist/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web", "policy": "DENY", "outbound_cidr_policy": "PERMIT"},
+			{"name": "/Compute-test/test/db", "policy": "DENY", "outbound_cidr_policy": "DENY"}]}`,
+		"/secrule/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web-to-db-pgsql", "action": "PERMIT", "application": "/Compute-test/test/pgsql",
+			 "src_list": "seclist:/Compute-test/test/web", "dst_list": "seclist:/Compute-test/test/db"},
+			{"name": "/Compute-test/test/internet-to-web-https", "action": "PERMIT", "application": "/oracle/public/https",
+			 "src_list": "seciplist:/oracle/public/public-internet", "dst_list": "seclist:/Compute-test/test/web"},
+			{"name": "/Compute-test/test/office-to-web-vnc", "action": "PERMIT", "application": "/Compute-test/test/vnc",
+			 "src_list": "seciplist:/Compute-test/test/office", "dst_list": "seclist:/Compute-test/test/web"},
+			{"name": "/Compute-test/test/internet-to-db-ssh", "action": "PERMIT", "application": "/oracle/public/ssh", "disabled": true,
+			 "src_list": "seciplist:/oracle/public/public-internet", "dst_list": "seclist:/Compute-test/test/db"}]}`,
+		"/secapplication/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/pgsql", "protocol": "tcp", "dport": "5432"},
+			{"name": "/Compute-test/test/vnc", "protocol": "tcp", "dport": "5900-5999"}]}`,
+		"/secapplication/oracle/public/https": `{"name": "/oracle/public/https", "protocol": "tcp", "dport": "443"}`,
+		"/secapplication/oracle/public/ssh":   `{"name": "/oracle/public/ssh", "protocol": "tcp", "dport": "22"}`,
+		"/seciplist/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/office", "secipentries": ["203.0.113.0/24"]}]}`,
+		"/seciplist/oracle/public/public-internet": `{"name": "/oracle/public/public-internet", "secipentries": ["0.0.0.0/0"]}`,
+		"/instance/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/web/1", "ip": "10.0.0.1", "vcable_id": "/Compute-test/test/vcable-1",
+			 "networking": {"eth0": {"seclists": ["/Compute-test/test/web"]}}},
+			{"name": "/Compute-test/test/db/2", "ip": "10.0.0.2", "vcable_id": "/Compute-test/test/vcable-2",
+			 "networking": {"eth0": {"seclists": ["/Compute-test/test/db"]}}},
+			{"name": "/Compute-test/test/batch/3", "ip": "10.0.0.3", "vcable_id": "/Compute-test/test/vcable-3",
+			 "networking": {"eth0": {"seclists": []}, "eth1": {"ipnetwork": "/Compute-test/test/private", "seclists": ["/Compute-test/test/web"]}}}]}`,
+		"/secassociation/Compute-test/test/": `{"result": [
+			{"name": "/Compute-test/test/batch-db", "seclist": "/Compute-test/test/db", "vcable": "/Compute-test/test/vcable-3"}]}`,
+	}
+}
+
+// Returns a server that responds to GET requests with the responses by path, and a client of the server
+func newStubResponseServer(t *testing.T, responses map[string]string) (*httptest.Server, *Client) {
+	server := newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if r.Method != "GET" || !ok {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	})
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return server, client
+}
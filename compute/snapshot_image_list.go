@@ -0,0 +1,175 @@
+package compute
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotToImageListOptions specifies how an instance is snapshotted and published to an Image List
+type SnapshotToImageListOptions struct {
+	// The name of the machine image created by the snapshot. Generated if not specified.
+	// Optional
+	MachineImage string
+	// The account of the associated Object Storage Classic instance.
+	// Optional
+	Account string
+	// Set to shutdown to snapshot the instance while it is shut down, rather than while it is running.
+	// The instance is shut down, snapshotted and started again.
+	// Optional
+	Delay SnapshotDelay
+	// Attributes of the new image list entry, passed to instances launched from it.
+	// Optional
+	Attributes map[string]interface{}
+	// If true, the new entry is made the default entry of the Image List
+	// Optional
+	Promote bool
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for each of the snapshot, shutdown and restart to complete
+	Timeout time.Duration
+}
+
+// SnapshotToImageListOutput details the resources created from an instance snapshot
+type SnapshotToImageListOutput struct {
+	// The completed snapshot
+	Snapshot *Snapshot
+	// The image list entry the snapshot's machine image was published as
+	ImageListEntry *ImageListEntryInfo
+}
+
+// SnapshotToImageList snapshots an instance, given as name/id, and publishes the resulting machine image
+// as a new entry of an existing Image List, so new instances can be launched from it.
+// If publishing the machine image fails, the snapshot and machine image are deleted again.
+func (c *SnapshotsClient) SnapshotToImageList(instance, imageList string, opts *SnapshotToImageListOptions) (*SnapshotToImageListOutput, error) {
+	if opts == nil {
+		opts = &SnapshotToImageListOptions{}
+	}
+	if imageList == "" {
+		return nil, fmt.Errorf("An image list needs to be specified")
+	}
+
+	instance = c.getQualifiedName(instance)
+	name, id, err := c.splitInstanceName(instance)
+	if err != nil {
+		return nil, fmt.Errorf("Instance to snapshot must be specified as name/id, got %q", instance)
+	}
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = waitForSnapshotCompletePollInterval
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = waitForSnapshotCompleteTimeout
+	}
+
+	createInput := &CreateSnapshotInput{
+		Account:      opts.Account,
+		Delay:        opts.Delay,
+		Instance:     instance,
+		MachineImage: opts.MachineImage,
+		PollInterval: opts.PollInterval,
+		Timeout:      opts.Timeout,
+	}
+	snapshot, err := c.CreateSnapshot(createInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.Delay == SnapshotDelayShutdown {
+		if snapshot, err = c.snapshotShutdownInstance(snapshot, name, id, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	publishInput := &PublishImageListEntryInput{
+		Name:          imageList,
+		MachineImages: []string{snapshot.MachineImage},
+		Attributes:    opts.Attributes,
+		Promote:       opts.Promote,
+	}
+	entry, err := c.Client.ImageList().PublishImageListEntry(publishInput)
+	if err != nil {
+		deleteInput := &DeleteSnapshotInput{
+			Snapshot:     snapshot.Name,
+			MachineImage: snapshot.MachineImage,
+			PollInterval: opts.PollInterval,
+			Timeout:      opts.Timeout,
+		}
+		if deleteErr := c.DeleteSnapshot(c.Client.MachineImages(), deleteInput); deleteErr != nil {
+			return nil, fmt.Errorf("Error publishing snapshot %s to image list %s: %s (error cleaning up: %s)", snapshot.Name, imageList, err, deleteErr)
+		}
+		return nil, fmt.Errorf("Error publishing snapshot %s to image list %s: %s", snapshot.Name, imageList, err)
+	}
+
+	return &SnapshotToImageListOutput{
+		Snapshot:       snapshot,
+		ImageListEntry: entry,
+	}, nil
+}
+
+// A delayed snapshot is only taken once the instance is shut down. Shuts the instance down,
+// waits for the snapshot to complete and starts the instance again. A snapshot that doesn't complete is deleted.
+func (c *SnapshotsClient) snapshotShutdownInstance(snapshot *Snapshot, name, id string, opts *SnapshotToImageListOptions) (*Snapshot, error) {
+	instancesClient := c.Client.Instances()
+
+	shutdownInput := &UpdateInstanceInput{
+		Name:         name,
+		ID:           id,
+		DesiredState: InstanceDesiredShutdown,
+		PollInterval: opts.PollInterval,
+		Timeout:      opts.Timeout,
+	}
+	if _, err := instancesClient.UpdateInstance(shutdownInput); err != nil {
+		return nil, fmt.Errorf("Error shutting down instance %s/%s for snapshot: %s", name, id, err)
+	}
+
+	restartInput := &UpdateInstanceInput{
+		Name:         name,
+		ID:           id,
+		DesiredState: InstanceDesiredRunning,
+		PollInterval: opts.PollInterval,
+		Timeout:      opts.Timeout,
+	}
+
+	complete, err := c.waitForDelayedSnapshotComplete(&GetSnapshotInput{Name: snapshot.Name}, opts.PollInterval, opts.Timeout)
+	if err != nil {
+		// Don't leave the instance shut down, nor the snapshot that didn't complete behind
+		err = fmt.Errorf("Error waiting for snapshot %s: %s", snapshot.Name, err)
+		if _, restartErr := instancesClient.UpdateInstance(restartInput); restartErr != nil {
+			err = fmt.Errorf("%s (error restarting instance %s/%s: %s)", err, name, id, restartErr)
+		}
+		if deleteErr := c.deleteResource(snapshot.Name); deleteErr != nil {
+			err = fmt.Errorf("%s (error cleaning up: %s)", err, deleteErr)
+		}
+		return nil, err
+	}
+
+	if _, err := instancesClient.UpdateInstance(restartInput); err != nil {
+		return nil, fmt.Errorf("Error restarting instance %s/%s after snapshot: %s", name, id, err)
+	}
+
+	return complete, nil
+}
+
+// WaitForSnapshotComplete returns as soon as a delayed snapshot is active,
+// whereas this waits for the snapshot itself to be complete.
+func (c *SnapshotsClient) waitForDelayedSnapshotComplete(input *GetSnapshotInput, pollInterval, timeout time.Duration) (*Snapshot, error) {
+	var info *Snapshot
+	var getErr error
+	err := c.client.WaitFor("delayed snapshot to be complete", pollInterval, timeout, func() (bool, error) {
+		info, getErr = c.GetSnapshot(input)
+		if getErr != nil {
+			return false, getErr
+		}
+		switch s := info.State; s {
+		case SnapshotError:
+			return false, fmt.Errorf("Error creating snapshot: %s", info.ErrorReason)
+		case SnapshotComplete:
+			c.client.DebugLogString("Snapshot Complete")
+			return true, nil
+		default:
+			c.client.DebugLogString(fmt.Sprintf("Snapshot state: %s, waiting", s))
+			return false, nil
+		}
+	})
+	return info, err
+}
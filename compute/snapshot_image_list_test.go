@@ -0,0 +1,242 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Test that a delayed snapshot shuts the instance down, waits for the snapshot,
+// restarts the instance and publishes the machine image.
+func TestSnapshotsClient_SnapshotToImageListDelayShutdown(t *testing.T) {
+	server := newSnapshotImageListServer(t, false)
+	defer server.Close()
+
+	client, err := getStubSnapshotsClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	opts := &SnapshotToImageListOptions{
+		MachineImage: "golden-image",
+		Delay:        SnapshotDelayShutdown,
+		Promote:      true,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	output, err := client.SnapshotToImageList("web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4", "golden", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Snapshot.State != SnapshotComplete {
+		t.Errorf("Expected the snapshot to be complete, got %s", output.Snapshot.State)
+	}
+	if output.ImageListEntry.Version != 2 {
+		t.Errorf("Expected image list entry version 2, got %d", output.ImageListEntry.Version)
+	}
+
+	expected := []string{
+		"POST /snapshot/ delay=shutdown",
+		"PUT /instance/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4 desired_state=shutdown",
+		"PUT /instance/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4 desired_state=running",
+		"POST /imagelist/Compute-test/test/golden/entry/ machineimages=/Compute-test/test/golden-image",
+		"PUT /imagelist/Compute-test/test/golden default=2",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Snapshot Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that the snapshot and its machine image are deleted when publishing fails.
+func TestSnapshotsClient_SnapshotToImageListCleanup(t *testing.T) {
+	server := newSnapshotImageListServer(t, true)
+	defer server.Close()
+
+	client, err := getStubSnapshotsClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	opts := &SnapshotToImageListOptions{
+		MachineImage: "golden-image",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := client.SnapshotToImageList("web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4", "golden", opts); err == nil {
+		t.Fatal("Expected an error publishing the snapshot")
+	}
+
+	expected := []string{
+		"POST /snapshot/ delay=",
+		"POST /imagelist/Compute-test/test/golden/entry/ machineimages=/Compute-test/test/golden-image",
+		"DELETE /snapshot/Compute-test/test/web-snapshot",
+		"DELETE /machineimage/Compute-test/test/golden-image",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Snapshot Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that the instance is restarted and the snapshot deleted when a delayed snapshot fails.
+func TestSnapshotsClient_SnapshotToImageListDelayShutdownFailed(t *testing.T) {
+	server := newSnapshotImageListServer(t, false)
+	server.failSnapshot = true
+	defer server.Close()
+
+	client, err := getStubSnapshotsClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	opts := &SnapshotToImageListOptions{
+		MachineImage: "golden-image",
+		Delay:        SnapshotDelayShutdown,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := client.SnapshotToImageList("web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4", "golden", opts); err == nil {
+		t.Fatal("Expected an error waiting for the snapshot")
+	}
+
+	expected := []string{
+		"POST /snapshot/ delay=shutdown",
+		"PUT /instance/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4 desired_state=shutdown",
+		"PUT /instance/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4 desired_state=running",
+		"DELETE /snapshot/Compute-test/test/web-snapshot",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Snapshot Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+type snapshotImageListServer struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	delay   SnapshotDelay
+	state   InstanceState
+	entries int
+	changed []string
+	// Whether a delayed snapshot fails once the instance is shut down
+	failSnapshot bool
+}
+
+func (s *snapshotImageListServer) Close() {
+	s.server.Close()
+}
+
+func (s *snapshotImageListServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves an instance, its snapshot and an image list. A delayed snapshot completes once the instance is shut down.
+func newSnapshotImageListServer(t *testing.T, failEntry bool) *snapshotImageListServer {
+	s := &snapshotImageListServer{
+		state:   InstanceRunning,
+		entries: 1,
+	}
+	instancePath := "/instance/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4"
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/snapshot/":
+			var body CreateSnapshotInput
+			unmarshalRequestBody(t, r, &body)
+			s.delay = body.Delay
+			change = fmt.Sprintf("%s delay=%s", change, body.Delay)
+			w.Write([]byte(s.snapshotResponse()))
+		case r.Method == "GET" && r.URL.Path == "/snapshot/Compute-test/test/web-snapshot":
+			w.Write([]byte(s.snapshotResponse()))
+		case r.Method == "PUT" && r.URL.Path == instancePath:
+			var body UpdateInstanceInput
+			unmarshalRequestBody(t, r, &body)
+			s.state = InstanceState(body.DesiredState)
+			change = fmt.Sprintf("%s desired_state=%s", change, body.DesiredState)
+			w.Write([]byte(s.instanceResponse()))
+		case r.Method == "GET" && r.URL.Path == instancePath:
+			w.Write([]byte(s.instanceResponse()))
+		case r.Method == "GET" && r.URL.Path == "/imagelist/Compute-test/test/golden":
+			w.Write([]byte(s.imageListResponse(1)))
+		case r.Method == "PUT" && r.URL.Path == "/imagelist/Compute-test/test/golden":
+			var body UpdateImageListInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s default=%d", change, body.Default)
+			w.Write([]byte(s.imageListResponse(body.Default)))
+		case r.Method == "POST" && r.URL.Path == "/imagelist/Compute-test/test/golden/entry/":
+			var body CreateImageListEntryInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s machineimages=%s", change, strings.Join(body.MachineImages, ","))
+			if failEntry {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				s.entries++
+				w.Write([]byte(fmt.Sprintf(`{"imagelist": "/Compute-test/test/golden", "version": %d, "machineimages": ["/Compute-test/test/golden-image"]}`, body.Version)))
+			}
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
+
+func (s *snapshotImageListServer) snapshotResponse() string {
+	state := SnapshotComplete
+	if s.delay == SnapshotDelayShutdown && s.state == InstanceRunning {
+		state = SnapshotActive
+	} else if s.delay == SnapshotDelayShutdown && s.failSnapshot {
+		state = SnapshotError
+	}
+	return fmt.Sprintf(`{
+  "name": "/Compute-test/test/web-snapshot",
+  "instance": "/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4",
+  "machineimage": "/Compute-test/test/golden-image",
+  "delay": "%s",
+  "state": "%s"
+}`, s.delay, state)
+}
+
+func (s *snapshotImageListServer) imageListResponse(current int) string {
+	entries := []string{}
+	for version := 1; version <= s.entries; version++ {
+		entries = append(entries, fmt.Sprintf(`{"version": %d}`, version))
+	}
+	return fmt.Sprintf(`{"name": "/Compute-test/test/golden", "default": %d, "entries": [%s]}`, current, strings.Join(entries, ", "))
+}
+
+func (s *snapshotImageListServer) instanceResponse() string {
+	return fmt.Sprintf(`{
+  "name": "/Compute-test/test/web/6e6e0eb5-2c4f-4e1f-8b0a-21e2a5e5c0b4",
+  "desired_state": "%s",
+  "state": "%s"
+}`, s.state, s.state)
+}
+
+func getStubSnapshotsClient(server *httptest.Server) (*SnapshotsClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Snapshots(), nil
+}
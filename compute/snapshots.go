@@ -3,6 +3,8 @@ package compute
 import (
 	"fmt"
 	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForSnapshotCompletePollInterval = 30 * time.Second
@@ -52,7 +54,7 @@ type Snapshot struct {
 	// Shows the default account for your identity domain.
 	Account string `json:"account"`
 	// Timestamp when this request was created.
-	CreationTime string `json:"creation_time"`
+	CreationTime opc.Time `json:"creation_time"`
 	// Snapshot of the instance is not taken immediately.
 	Delay SnapshotDelay `json:"delay"`
 	// A description of the reason this request entered "error" state.
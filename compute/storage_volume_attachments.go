@@ -1,6 +1,7 @@
 package compute
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
@@ -11,6 +12,13 @@ const waitForVolumeAttachmentDeleteTimeout = 30 * time.Second
 const waitForVolumeAttachmentReadyPollInterval = 1 * time.Second
 const waitForVolumeAttachmentReadyTimeout = 30 * time.Second
 
+const (
+	// BootStorageAttachmentIndex is the attachment index reserved for the boot volume of an instance
+	BootStorageAttachmentIndex = 1
+	// MaxStorageAttachmentIndex is the highest attachment index of an instance
+	MaxStorageAttachmentIndex = 10
+)
+
 // StorageAttachmentsClient is a client for the Storage Attachment functions of the Compute API.
 type StorageAttachmentsClient struct {
 	ResourceClient
@@ -198,3 +206,125 @@ func (c *StorageAttachmentsClient) waitForStorageAttachmentToBeDeleted(name stri
 		return false, nil
 	})
 }
+
+// StorageAttachmentDevicePath returns the device path the volume attached at the given index is exposed as
+// to the instance. An attachment with index 1 is exposed as /dev/xvdb, index 2 as /dev/xvdc, and so on.
+func StorageAttachmentDevicePath(index int) (string, error) {
+	if index < BootStorageAttachmentIndex || index > MaxStorageAttachmentIndex {
+		return "", fmt.Errorf("Storage attachment index must be between %d and %d, got %d", BootStorageAttachmentIndex, MaxStorageAttachmentIndex, index)
+	}
+	return fmt.Sprintf("/dev/xvd%c", 'a'+index), nil
+}
+
+// AttachStorageVolumeInput specifies the storage volume to attach at the next free index of an instance
+type AttachStorageVolumeInput struct {
+	// Name of the instance, in the form name/id
+	// Required
+	Instance string
+	// Multipart name of the volume that you want to attach.
+	// Required
+	StorageVolumeName string
+	// Time to wait between polls to check volume attachment status
+	PollInterval time.Duration
+	// Time to wait for storage volume attachment
+	Timeout time.Duration
+}
+
+// AttachStorageVolumeOutput details an attachment made at the next free index
+type AttachStorageVolumeOutput struct {
+	// The new storage attachment
+	Attachment *StorageAttachmentInfo
+	// The device path the volume is exposed as to the instance, such as /dev/xvdc
+	DevicePath string
+}
+
+// AttachStorageVolume attaches a storage volume to an instance at the lowest index the instance isn't using,
+// and waits for it to be attached. Index 1 is reserved for the boot volume and is never picked.
+func (c *StorageAttachmentsClient) AttachStorageVolume(input *AttachStorageVolumeInput) (*AttachStorageVolumeOutput, error) {
+	instance, err := c.getStorageAttachmentInstance(input.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[int]bool{}
+	for _, attachment := range instance.Storage {
+		used[attachment.Index] = true
+	}
+	index := 0
+	for i := BootStorageAttachmentIndex + 1; i <= MaxStorageAttachmentIndex; i++ {
+		if !used[i] {
+			index = i
+			break
+		}
+	}
+	if index == 0 {
+		return nil, fmt.Errorf("Instance %s has no free storage attachment index", input.Instance)
+	}
+
+	createInput := &CreateStorageAttachmentInput{
+		Index:             index,
+		InstanceName:      input.Instance,
+		StorageVolumeName: input.StorageVolumeName,
+		PollInterval:      input.PollInterval,
+		Timeout:           input.Timeout,
+	}
+	attachment, err := c.CreateStorageAttachment(createInput)
+	if err != nil {
+		return nil, err
+	}
+
+	devicePath, err := StorageAttachmentDevicePath(index)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachStorageVolumeOutput{
+		Attachment: attachment,
+		DevicePath: devicePath,
+	}, nil
+}
+
+// DetachAllStorageVolumesInput specifies the instance to detach the storage volumes of
+type DetachAllStorageVolumesInput struct {
+	// Name of the instance, in the form name/id
+	// Required
+	Instance string
+	// Time to wait between polls to check volume attachment status
+	PollInterval time.Duration
+	// Time to wait for each storage volume to be detached
+	Timeout time.Duration
+}
+
+// DetachAllStorageVolumes detaches all storage volumes from an instance, except for the boot volume.
+// Returns the names of the storage volumes that were detached.
+func (c *StorageAttachmentsClient) DetachAllStorageVolumes(input *DetachAllStorageVolumesInput) ([]string, error) {
+	instance, err := c.getStorageAttachmentInstance(input.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	detached := []string{}
+	for _, attachment := range instance.Storage {
+		if attachment.Index == BootStorageAttachmentIndex {
+			continue
+		}
+		deleteInput := &DeleteStorageAttachmentInput{
+			Name:         attachment.Name,
+			PollInterval: input.PollInterval,
+			Timeout:      input.Timeout,
+		}
+		if err := c.DeleteStorageAttachment(deleteInput); err != nil {
+			return detached, fmt.Errorf("Error detaching storage volume %s from instance %s: %s", attachment.StorageVolumeName, input.Instance, err)
+		}
+		detached = append(detached, attachment.StorageVolumeName)
+	}
+	return detached, nil
+}
+
+// Retrieves the instance with the given name/id, to inspect its current storage attachments
+func (c *StorageAttachmentsClient) getStorageAttachmentInstance(name string) (*InstanceInfo, error) {
+	instanceName, id, err := c.splitInstanceName(name)
+	if err != nil {
+		return nil, fmt.Errorf("Instance must be specified as name/id, got %q", name)
+	}
+	return c.Client.Instances().GetInstance(&GetInstanceInput{Name: instanceName, ID: id})
+}
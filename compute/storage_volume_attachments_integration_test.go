@@ -47,7 +47,7 @@ func TestAccStorageAttachmentsLifecycle(t *testing.T) {
 
 	createStorageVolumeInput := &CreateStorageVolumeInput{
 		Name:       volumeName,
-		Size:       "10",
+		Size:       10 * opc.GB,
 		Properties: []string{"/oracle/public/storage/default"},
 	}
 	_, err = storageVolumesClient.CreateStorageVolume(createStorageVolumeInput)
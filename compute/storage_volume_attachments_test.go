@@ -5,10 +5,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/helper"
+	"github.com/kylelemons/godebug/pretty"
 )
 
 func TestAccStorageAttachmentsClient_WaitForStorageDetachmentSuccessful(t *testing.T) {
@@ -90,6 +93,176 @@ func TestAccStorageAttachmentsClient_WaitForStorageAttachmentToBeFullyAttachedTi
 	}
 }
 
+func TestStorageAttachmentDevicePath(t *testing.T) {
+	expected := map[int]string{
+		1:  "/dev/xvdb",
+		2:  "/dev/xvdc",
+		10: "/dev/xvdk",
+	}
+	for index, path := range expected {
+		devicePath, err := StorageAttachmentDevicePath(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if devicePath != path {
+			t.Errorf("Expected device path %s for index %d, got %s", path, index, devicePath)
+		}
+	}
+
+	for _, index := range []int{0, 11} {
+		if _, err := StorageAttachmentDevicePath(index); err == nil {
+			t.Errorf("Expected an error for index %d", index)
+		}
+	}
+}
+
+// Test that a volume is attached at the lowest index not used by the instance.
+func TestStorageAttachmentsClient_AttachStorageVolume(t *testing.T) {
+	server := newStorageAttachmentIndexServer(t, []int{1, 2, 4})
+	defer server.Close()
+
+	client, err := getStubStorageAttachmentsClient(server.server)
+	if err != nil {
+		t.Fatalf("error getting stub client: %s", err)
+	}
+
+	input := &AttachStorageVolumeInput{
+		Instance:          "app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a",
+		StorageVolumeName: "data",
+		PollInterval:      1 * time.Second,
+		Timeout:           10 * time.Second,
+	}
+	output, err := client.AttachStorageVolume(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.DevicePath != "/dev/xvdd" {
+		t.Errorf("Expected device path /dev/xvdd, got %s", output.DevicePath)
+	}
+
+	expected := []string{
+		"POST /storage/attachment/ index=3 volume=/Compute-test/test/data",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Attach Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that attaching fails when all indexes are in use.
+func TestStorageAttachmentsClient_AttachStorageVolumeNoFreeIndex(t *testing.T) {
+	server := newStorageAttachmentIndexServer(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	defer server.Close()
+
+	client, err := getStubStorageAttachmentsClient(server.server)
+	if err != nil {
+		t.Fatalf("error getting stub client: %s", err)
+	}
+
+	input := &AttachStorageVolumeInput{
+		Instance:          "app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a",
+		StorageVolumeName: "data",
+	}
+	if _, err := client.AttachStorageVolume(input); err == nil {
+		t.Fatal("Expected an error attaching to an instance without a free index")
+	}
+	if len(server.changes()) != 0 {
+		t.Errorf("Expected no changes, got %v", server.changes())
+	}
+}
+
+// Test that all volumes but the boot volume are detached.
+func TestStorageAttachmentsClient_DetachAllStorageVolumes(t *testing.T) {
+	server := newStorageAttachmentIndexServer(t, []int{1, 2, 4})
+	defer server.Close()
+
+	client, err := getStubStorageAttachmentsClient(server.server)
+	if err != nil {
+		t.Fatalf("error getting stub client: %s", err)
+	}
+
+	input := &DetachAllStorageVolumesInput{
+		Instance:     "app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	detached, err := client.DetachAllStorageVolumes(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(detached, []string{"volume-2", "volume-4"}); diff != "" {
+		t.Errorf("Detached Volumes Diff: (-got +want)\n%s", diff)
+	}
+
+	expected := []string{
+		"DELETE /storage/attachment/Compute-test/test/app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a/attachment-2",
+		"DELETE /storage/attachment/Compute-test/test/app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a/attachment-4",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Detach Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+type storageAttachmentIndexServer struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	deleted map[string]bool
+	changed []string
+}
+
+func (s *storageAttachmentIndexServer) Close() {
+	s.server.Close()
+}
+
+func (s *storageAttachmentIndexServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves an instance with volumes attached at the given indexes
+func newStorageAttachmentIndexServer(t *testing.T, indexes []int) *storageAttachmentIndexServer {
+	s := &storageAttachmentIndexServer{
+		deleted: map[string]bool{},
+	}
+	instanceName := "/Compute-test/test/app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a"
+	attachments := []string{}
+	for _, index := range indexes {
+		attachments = append(attachments, fmt.Sprintf(`{"index": %d, "name": "%s/attachment-%d", "storage_volume_name": "/Compute-test/test/volume-%d"}`, index, instanceName, index, index))
+	}
+
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/instance"+instanceName:
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "state": "running", "storage_attachments": [%s]}`, instanceName, strings.Join(attachments, ", "))))
+		case r.Method == "POST" && r.URL.Path == "/storage/attachment/":
+			var body CreateStorageAttachmentInput
+			unmarshalRequestBody(t, r, &body)
+			if body.InstanceName != instanceName {
+				t.Errorf("Expected instance %s, got %s", instanceName, body.InstanceName)
+			}
+			change = fmt.Sprintf("%s index=%d volume=%s", change, body.Index, body.StorageVolumeName)
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s/attachment-new", "index": %d, "state": "attaching"}`, instanceName, body.Index)))
+		case r.Method == "GET" && r.URL.Path == "/storage/attachment"+instanceName+"/attachment-new":
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s/attachment-new", "index": 3, "state": "attached"}`, instanceName)))
+		case r.Method == "DELETE":
+			s.deleted[r.URL.Path] = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && s.deleted[r.URL.Path]:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
+
 func serverThatAttachesStorageVolumeAfterThreeSeconds(t *testing.T, name string) *httptest.Server {
 	count := 0
 	return newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
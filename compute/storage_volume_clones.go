@@ -0,0 +1,211 @@
+package compute
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+)
+
+// CloneStorageVolumeInput specifies the storage volume to clone
+type CloneStorageVolumeInput struct {
+	// The name of the storage volume to clone
+	// Required
+	Source string
+	// The name of the new storage volume
+	// Required
+	Name string
+	// The description of the new storage volume
+	// Optional
+	Description string
+	// Tags for the new storage volume
+	// Optional
+	Tags []string
+	// Name of an instance, in the form name/id, to attach the new storage volume to at the first free index
+	// Optional
+	Instance string
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for each of the snapshot, storage volume and attachment to be ready
+	Timeout time.Duration
+}
+
+// CloneStorageVolumeOutput details the resources created by cloning a storage volume
+type CloneStorageVolumeOutput struct {
+	// The collocated snapshot the new storage volume was created from
+	Snapshot *StorageVolumeSnapshotInfo
+	// The new storage volume
+	Volume *StorageVolumeInfo
+	// The attachment of the new storage volume, if it was attached to an instance
+	Attachment *StorageAttachmentInfo
+}
+
+// CloneStorageVolume creates a collocated snapshot of a storage volume, and creates a new storage volume
+// of the same size and storage pool from it. The snapshot is kept, as the new storage volume is based on it.
+func (c *StorageVolumeClient) CloneStorageVolume(input *CloneStorageVolumeInput) (*CloneStorageVolumeOutput, error) {
+	if input.Source == "" || input.Name == "" {
+		return nil, fmt.Errorf("Both the source and the new storage volume name need to be specified")
+	}
+
+	source, err := c.GetStorageVolume(&GetStorageVolumeInput{Name: input.Source})
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("Storage volume %s not found", input.Source)
+	}
+
+	snapshotInput := &CreateStorageVolumeSnapshotInput{
+		Description:  fmt.Sprintf("Clone of %s as %s", input.Source, input.Name),
+		Property:     SnapshotPropertyCollocated,
+		Volume:       input.Source,
+		PollInterval: input.PollInterval,
+		Timeout:      input.Timeout,
+	}
+	snapshot, err := c.Client.StorageVolumeSnapshots().CreateStorageVolumeSnapshot(snapshotInput)
+	if err != nil {
+		return nil, fmt.Errorf("Error snapshotting storage volume %s: %s", input.Source, err)
+	}
+
+	createInput := &CreateStorageVolumeInput{
+		Bootable:       source.Bootable,
+		Description:    input.Description,
+		ImageList:      source.ImageList,
+		ImageListEntry: source.ImageListEntry,
+		Name:           input.Name,
+		Properties:     source.Properties,
+		Size:           source.Size,
+		Snapshot:       c.getQualifiedName(snapshot.Name),
+		SnapshotID:     snapshot.SnapshotID,
+		Tags:           input.Tags,
+		PollInterval:   input.PollInterval,
+		Timeout:        input.Timeout,
+	}
+	volume, err := c.CreateStorageVolume(createInput)
+	if err != nil {
+		deleteInput := &DeleteStorageVolumeSnapshotInput{
+			Name:         snapshot.Name,
+			PollInterval: input.PollInterval,
+			Timeout:      input.Timeout,
+		}
+		if deleteErr := c.Client.StorageVolumeSnapshots().DeleteStorageVolumeSnapshot(deleteInput); deleteErr != nil {
+			c.client.DebugLogString(fmt.Sprintf("Error deleting snapshot %s of failed clone %s: %s", snapshot.Name, input.Name, deleteErr))
+		}
+		return nil, fmt.Errorf("Error creating storage volume %s from snapshot %s: %s", input.Name, snapshot.Name, err)
+	}
+
+	output := &CloneStorageVolumeOutput{
+		Snapshot: snapshot,
+		Volume:   volume,
+	}
+	if input.Instance != "" {
+		if output.Attachment, err = c.attachToFreeIndex(input.Instance, input.Name, input.PollInterval, input.Timeout); err != nil {
+			return output, err
+		}
+	}
+	return output, nil
+}
+
+// RestoreStorageVolumeInput specifies the snapshot to restore
+type RestoreStorageVolumeInput struct {
+	// The name of the storage volume snapshot to restore
+	// Required
+	Snapshot string
+	// The name of the new storage volume
+	// Required
+	Name string
+	// The size of the new storage volume. Defaults to the size of the snapshot,
+	// and can be larger to grow the volume.
+	// Optional
+	Size opc.ByteSize
+	// The storage-pool property: /oracle/public/storage/latency or /oracle/public/storage/default.
+	// Optional
+	Properties []string
+	// The description of the new storage volume
+	// Optional
+	Description string
+	// Tags for the new storage volume
+	// Optional
+	Tags []string
+	// Name of an instance, in the form name/id, to attach the new storage volume to at the first free index
+	// Optional
+	Instance string
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for each of the storage volume and attachment to be ready
+	Timeout time.Duration
+}
+
+// RestoreStorageVolumeOutput details the resources created by restoring a storage volume snapshot
+type RestoreStorageVolumeOutput struct {
+	// The new storage volume
+	Volume *StorageVolumeInfo
+	// The attachment of the new storage volume, if it was attached to an instance
+	Attachment *StorageAttachmentInfo
+}
+
+// RestoreStorageVolume creates a new storage volume from a snapshot
+func (c *StorageVolumeClient) RestoreStorageVolume(input *RestoreStorageVolumeInput) (*RestoreStorageVolumeOutput, error) {
+	if input.Snapshot == "" || input.Name == "" {
+		return nil, fmt.Errorf("Both the snapshot and the new storage volume name need to be specified")
+	}
+
+	snapshot, err := c.Client.StorageVolumeSnapshots().GetStorageVolumeSnapshot(&GetStorageVolumeSnapshotInput{Name: input.Snapshot})
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("Storage volume snapshot %s not found", input.Snapshot)
+	}
+
+	size := snapshot.Size
+	if input.Size != 0 {
+		if input.Size < snapshot.Size {
+			return nil, fmt.Errorf("Storage volume %s can't be smaller than its snapshot %s of %s", input.Name, input.Snapshot, snapshot.Size)
+		}
+		size = input.Size
+	}
+
+	createInput := &CreateStorageVolumeInput{
+		Bootable:     strings.ToLower(snapshot.ParentVolumeBootable) == "true",
+		Description:  input.Description,
+		Name:         input.Name,
+		Properties:   input.Properties,
+		Size:         size,
+		Snapshot:     c.getQualifiedName(snapshot.Name),
+		SnapshotID:   snapshot.SnapshotID,
+		Tags:         input.Tags,
+		PollInterval: input.PollInterval,
+		Timeout:      input.Timeout,
+	}
+	volume, err := c.CreateStorageVolume(createInput)
+	if err != nil {
+		return nil, fmt.Errorf("Error restoring snapshot %s as storage volume %s: %s", input.Snapshot, input.Name, err)
+	}
+
+	output := &RestoreStorageVolumeOutput{
+		Volume: volume,
+	}
+	if input.Instance != "" {
+		if output.Attachment, err = c.attachToFreeIndex(input.Instance, input.Name, input.PollInterval, input.Timeout); err != nil {
+			return output, err
+		}
+	}
+	return output, nil
+}
+
+// Attaches a storage volume to an instance at the next free index
+func (c *StorageVolumeClient) attachToFreeIndex(instance, volume string, pollInterval, timeout time.Duration) (*StorageAttachmentInfo, error) {
+	attachInput := &AttachStorageVolumeInput{
+		Instance:          instance,
+		StorageVolumeName: volume,
+		PollInterval:      pollInterval,
+		Timeout:           timeout,
+	}
+	output, err := c.Client.StorageAttachments().AttachStorageVolume(attachInput)
+	if err != nil {
+		return nil, fmt.Errorf("Error attaching storage volume %s to instance %s: %s", volume, instance, err)
+	}
+	return output.Attachment, nil
+}
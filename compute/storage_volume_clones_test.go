@@ -0,0 +1,198 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Test that a volume is cloned through a collocated snapshot, and attached at the first free index.
+func TestStorageVolumeClient_CloneStorageVolume(t *testing.T) {
+	server := newStorageVolumeCloneServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CloneStorageVolumeInput{
+		Source:       "db",
+		Name:         "db-copy",
+		Instance:     "app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	output, err := client.CloneStorageVolume(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Attachment.Index != 3 {
+		t.Errorf("Expected the clone to be attached at index 3, got %d", output.Attachment.Index)
+	}
+
+	expected := []string{
+		"POST /storage/snapshot/ volume=/Compute-test/test/db property=/oracle/private/storage/snapshot/collocated",
+		"POST /storage/volume/ name=/Compute-test/test/db-copy snapshot=/Compute-test/test/db-clone size=21474836480 properties=/oracle/public/storage/latency",
+		"POST /storage/attachment/ index=3 instance=/Compute-test/test/app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a volume=/Compute-test/test/db-copy",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Clone Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that the snapshot of a clone is deleted again if the volume can't be created.
+func TestStorageVolumeClient_CloneStorageVolume_Failed(t *testing.T) {
+	server := newStorageVolumeCloneServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CloneStorageVolumeInput{
+		Source:       "db",
+		Name:         "db-failed",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := client.CloneStorageVolume(input); err == nil {
+		t.Fatal("Expected an error cloning to a volume that can't be created")
+	}
+
+	expected := []string{
+		"POST /storage/snapshot/ volume=/Compute-test/test/db property=/oracle/private/storage/snapshot/collocated",
+		"POST /storage/volume/ name=/Compute-test/test/db-failed snapshot=/Compute-test/test/db-clone size=21474836480 properties=/oracle/public/storage/latency",
+		"DELETE /storage/snapshot/Compute-test/test/db-clone",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Clone Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that a snapshot can be restored to a larger volume, but not a smaller one.
+func TestStorageVolumeClient_RestoreStorageVolume(t *testing.T) {
+	server := newStorageVolumeCloneServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &RestoreStorageVolumeInput{
+		Snapshot:     "db-nightly",
+		Name:         "db-restored",
+		Size:         50 * opc.GB,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	output, err := client.RestoreStorageVolume(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Attachment != nil {
+		t.Errorf("Expected the restored volume not to be attached, got %+v", output.Attachment)
+	}
+
+	input = &RestoreStorageVolumeInput{
+		Snapshot: "db-nightly",
+		Name:     "db-shrunk",
+		Size:     5 * opc.GB,
+	}
+	if _, err := client.RestoreStorageVolume(input); err == nil {
+		t.Errorf("Expected an error restoring a snapshot to a smaller volume")
+	}
+
+	expected := []string{
+		"POST /storage/volume/ name=/Compute-test/test/db-restored snapshot=/Compute-test/test/db-nightly size=53687091200 properties=",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Restore Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+type storageVolumeCloneServer struct {
+	server          *httptest.Server
+	lock            sync.Mutex
+	changed         []string
+	snapshotDeleted bool
+}
+
+func (s *storageVolumeCloneServer) Close() {
+	s.server.Close()
+}
+
+func (s *storageVolumeCloneServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves a 20GB volume, its snapshots and an instance with volumes attached at index 1 and 2.
+// Volumes named db-failed can't be created.
+func newStorageVolumeCloneServer(t *testing.T) *storageVolumeCloneServer {
+	s := &storageVolumeCloneServer{}
+	instanceName := "/Compute-test/test/app/3f8e2a8e-6a4c-4f5e-9a9f-0b6f5c2e7d1a"
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/storage/volume/Compute-test/test/db":
+			w.Write([]byte(`{"name": "/Compute-test/test/db", "size": "21474836480", "properties": ["/oracle/public/storage/latency"], "status": "online"}`))
+		case r.Method == "POST" && r.URL.Path == "/storage/snapshot/":
+			var body CreateStorageVolumeSnapshotInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s volume=%s property=%s", change, body.Volume, body.Property)
+			w.Write([]byte(`{"name": "/Compute-test/test/db-clone", "volume": "/Compute-test/test/db", "size": "21474836480", "status": "creating"}`))
+		case r.Method == "DELETE" && r.URL.Path == "/storage/snapshot/Compute-test/test/db-clone":
+			s.snapshotDeleted = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/db-clone" && s.snapshotDeleted:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/db-clone":
+			w.Write([]byte(`{"name": "/Compute-test/test/db-clone", "volume": "/Compute-test/test/db", "size": "21474836480", "snapshot_id": "1", "status": "completed"}`))
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/db-nightly":
+			w.Write([]byte(`{"name": "/Compute-test/test/db-nightly", "volume": "/Compute-test/test/db", "size": "21474836480", "snapshot_id": "2", "status": "completed"}`))
+		case r.Method == "POST" && r.URL.Path == "/storage/volume/":
+			var body CreateStorageVolumeInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s name=%s snapshot=%s size=%d properties=%s", change, body.Name, body.Snapshot, body.Size, strings.Join(body.Properties, ","))
+			if body.Name == "/Compute-test/test/db-failed" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message": "Not enough storage"}`))
+				break
+			}
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "size": "%d", "status": "initializing"}`, body.Name, body.Size)))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/storage/volume/Compute-test/test/db-"):
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "size": "21474836480", "status": "online"}`, strings.TrimPrefix(r.URL.Path, "/storage/volume"))))
+		case r.Method == "GET" && r.URL.Path == "/instance"+instanceName:
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "state": "running", "storage_attachments": [
+				{"index": 1, "storage_volume_name": "/Compute-test/test/app-boot"},
+				{"index": 2, "storage_volume_name": "/Compute-test/test/app-data"}]}`, instanceName)))
+		case r.Method == "POST" && r.URL.Path == "/storage/attachment/":
+			var body CreateStorageAttachmentInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s index=%d instance=%s volume=%s", change, body.Index, body.InstanceName, body.StorageVolumeName)
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s/attachment", "index": %d, "state": "attaching"}`, instanceName, body.Index)))
+		case r.Method == "GET" && r.URL.Path == "/storage/attachment"+instanceName+"/attachment":
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s/attachment", "index": 3, "state": "attached"}`, instanceName)))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
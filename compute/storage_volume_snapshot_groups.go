@@ -0,0 +1,385 @@
+package compute
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// StorageVolumeSnapshotGroupTag prefixes the tag that identifies the snapshots of a group
+	StorageVolumeSnapshotGroupTag = "snapshot-group="
+	// StorageVolumeSnapshotGroupIndexTag prefixes the tag that records the attachment index of a snapshot's volume
+	StorageVolumeSnapshotGroupIndexTag = "snapshot-group-index="
+)
+
+// StorageVolumeSnapshotGroup is a set of snapshots of all of the volumes attached to an instance,
+// taken at the same time and tagged with a shared group ID.
+type StorageVolumeSnapshotGroup struct {
+	// The ID shared by the snapshots of the group
+	GroupID string
+	// The snapshots of the group, ordered by the attachment index of their volumes
+	Snapshots []StorageVolumeSnapshotInfo
+}
+
+// CreateStorageVolumeSnapshotGroupInput specifies the instance to snapshot the volumes of
+type CreateStorageVolumeSnapshotGroupInput struct {
+	// Name of the instance, in the form name/id
+	// Required
+	Instance string
+	// The ID shared by the snapshots. Defaults to the instance name and the current time.
+	// Optional
+	GroupID string
+	// Description of the snapshots
+	// Optional
+	Description string
+	// Whether collocated or remote
+	// Optional, will be remote if unspecified
+	Property string
+	// If true, the instance is shut down while the snapshots are triggered, and started again afterwards
+	// Optional
+	Shutdown bool
+	// Additional tags for the snapshots
+	// Optional
+	Tags []string
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for the snapshots to be completed
+	Timeout time.Duration
+}
+
+// CreateStorageVolumeSnapshotGroup snapshots all of the volumes attached to an instance.
+// The snapshots are triggered concurrently so they are as close together in time as possible,
+// which gives crash-consistent backups of data spread over several volumes.
+// If any snapshot can't be triggered or doesn't complete, the snapshots that were triggered are deleted again.
+func (c *StorageVolumeSnapshotClient) CreateStorageVolumeSnapshotGroup(input *CreateStorageVolumeSnapshotGroupInput) (*StorageVolumeSnapshotGroup, error) {
+	name, id, err := c.splitInstanceName(input.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("Instance to snapshot must be specified as name/id, got %q", input.Instance)
+	}
+
+	groupID := input.GroupID
+	if groupID == "" {
+		// Instances of other users keep their qualified name, of which the group only takes the last part
+		groupID = fmt.Sprintf("%s-%s", path.Base(name), time.Now().UTC().Format("20060102T150405Z"))
+	}
+	pollInterval := input.PollInterval
+	if pollInterval == 0 {
+		pollInterval = waitForSnapshotCreatePollInterval
+	}
+	timeout := input.Timeout
+	if timeout == 0 {
+		timeout = waitForSnapshotCreateTimeout
+	}
+
+	instancesClient := c.Client.Instances()
+	instanceInfo, err := instancesClient.GetInstance(&GetInstanceInput{Name: name, ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(instanceInfo.Storage) == 0 {
+		return nil, fmt.Errorf("Instance %s has no storage volumes attached", input.Instance)
+	}
+
+	if input.Shutdown {
+		shutdownInput := &UpdateInstanceInput{
+			Name:         name,
+			ID:           id,
+			DesiredState: InstanceDesiredShutdown,
+			PollInterval: pollInterval,
+			Timeout:      timeout,
+		}
+		if _, err := instancesClient.UpdateInstance(shutdownInput); err != nil {
+			return nil, fmt.Errorf("Error shutting down instance %s for snapshot: %s", input.Instance, err)
+		}
+	}
+
+	names, triggerErr := c.triggerStorageVolumeSnapshots(input, groupID, instanceInfo.Storage)
+
+	if input.Shutdown {
+		restartInput := &UpdateInstanceInput{
+			Name:         name,
+			ID:           id,
+			DesiredState: InstanceDesiredRunning,
+			PollInterval: pollInterval,
+			Timeout:      timeout,
+		}
+		if _, err := instancesClient.UpdateInstance(restartInput); err != nil && triggerErr == nil {
+			triggerErr = fmt.Errorf("Error restarting instance %s after snapshot: %s", input.Instance, err)
+		}
+	}
+
+	if triggerErr != nil {
+		c.deleteFailedStorageVolumeSnapshotGroup(groupID, names)
+		return nil, triggerErr
+	}
+
+	group := &StorageVolumeSnapshotGroup{
+		GroupID: groupID,
+	}
+	for _, name := range names {
+		info, err := c.waitForStorageSnapshotAvailable(name, pollInterval, timeout)
+		if err != nil {
+			c.deleteFailedStorageVolumeSnapshotGroup(groupID, names)
+			return nil, err
+		}
+		group.Snapshots = append(group.Snapshots, *info)
+	}
+	sortStorageVolumeSnapshotGroup(group)
+
+	return group, nil
+}
+
+// Deletes the snapshots of a group that failed, logging rather than returning errors so the failure is reported
+func (c *StorageVolumeSnapshotClient) deleteFailedStorageVolumeSnapshotGroup(groupID string, names []string) {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if err := c.DeleteStorageVolumeSnapshot(&DeleteStorageVolumeSnapshotInput{Name: name}); err != nil {
+			c.client.DebugLogString(fmt.Sprintf("Error deleting snapshot %s of failed group %s: %s", name, groupID, err))
+		}
+	}
+}
+
+// Creates the snapshots of all of the attachments concurrently, without waiting for them to complete.
+// Returns the names of the snapshots created, aligned with the attachments, and the first error encountered.
+func (c *StorageVolumeSnapshotClient) triggerStorageVolumeSnapshots(input *CreateStorageVolumeSnapshotGroupInput, groupID string, attachments []StorageAttachment) ([]string, error) {
+	names := make([]string, len(attachments))
+	errs := make([]error, len(attachments))
+
+	var wg sync.WaitGroup
+	for i, attachment := range attachments {
+		wg.Add(1)
+		go func(i int, attachment StorageAttachment) {
+			defer wg.Done()
+
+			tags := append([]string{}, input.Tags...)
+			tags = append(tags,
+				StorageVolumeSnapshotGroupTag+groupID,
+				fmt.Sprintf("%s%d", StorageVolumeSnapshotGroupIndexTag, attachment.Index))
+			createInput := &CreateStorageVolumeSnapshotInput{
+				Description: input.Description,
+				Property:    input.Property,
+				Tags:        tags,
+				Volume:      c.getQualifiedName(attachment.StorageVolumeName),
+			}
+
+			var info StorageVolumeSnapshotInfo
+			if err := c.createResource(createInput, &info); err != nil {
+				errs[i] = fmt.Errorf("Error creating snapshot of storage volume %s: %s", attachment.StorageVolumeName, err)
+				return
+			}
+			names[i] = info.Name
+		}(i, attachment)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return names, err
+		}
+	}
+	return names, nil
+}
+
+// GetStorageVolumeSnapshotGroupInput specifies the snapshot group to get
+type GetStorageVolumeSnapshotGroupInput struct {
+	// The ID shared by the snapshots of the group
+	// Required
+	GroupID string
+}
+
+// GetStorageVolumeSnapshotGroup retrieves all of the snapshots tagged with the given group ID
+func (c *StorageVolumeSnapshotClient) GetStorageVolumeSnapshotGroup(input *GetStorageVolumeSnapshotGroupInput) (*StorageVolumeSnapshotGroup, error) {
+	snapshots, err := c.ListStorageVolumeSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	group := &StorageVolumeSnapshotGroup{
+		GroupID: input.GroupID,
+	}
+	groupTag := StorageVolumeSnapshotGroupTag + input.GroupID
+	for _, snapshot := range snapshots {
+		if hasTag(snapshot.Tags, groupTag) {
+			group.Snapshots = append(group.Snapshots, snapshot)
+		}
+	}
+	if len(group.Snapshots) == 0 {
+		return nil, fmt.Errorf("No storage volume snapshots found in group %s", input.GroupID)
+	}
+	sortStorageVolumeSnapshotGroup(group)
+
+	return group, nil
+}
+
+// DeleteStorageVolumeSnapshotGroupInput specifies the snapshot group to delete
+type DeleteStorageVolumeSnapshotGroupInput struct {
+	// The ID shared by the snapshots of the group
+	// Required
+	GroupID string
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for each snapshot to be deleted
+	Timeout time.Duration
+}
+
+// DeleteStorageVolumeSnapshotGroup deletes all of the snapshots tagged with the given group ID
+func (c *StorageVolumeSnapshotClient) DeleteStorageVolumeSnapshotGroup(input *DeleteStorageVolumeSnapshotGroupInput) error {
+	group, err := c.GetStorageVolumeSnapshotGroup(&GetStorageVolumeSnapshotGroupInput{GroupID: input.GroupID})
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range group.Snapshots {
+		deleteInput := &DeleteStorageVolumeSnapshotInput{
+			Name:         snapshot.Name,
+			PollInterval: input.PollInterval,
+			Timeout:      input.Timeout,
+		}
+		if err := c.DeleteStorageVolumeSnapshot(deleteInput); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreStorageVolumeSnapshotGroupInput specifies the snapshot group to restore
+type RestoreStorageVolumeSnapshotGroupInput struct {
+	// The ID shared by the snapshots of the group
+	// Required
+	GroupID string
+	// The new volumes are named <NamePrefix>-<index>, after the attachment index of the snapshotted volume
+	// Required
+	NamePrefix string
+	// The storage-pool property of the new volumes
+	// Optional
+	Properties []string
+	// Tags for the new volumes
+	// Optional
+	Tags []string
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for the volumes to be available
+	Timeout time.Duration
+}
+
+// RestoredStorageVolume is a volume created from a snapshot of a group
+type RestoredStorageVolume struct {
+	// The attachment index of the volume the snapshot was taken of
+	Index int
+	// The name of the snapshot the volume was restored from
+	Snapshot string
+	// The new volume
+	Volume *StorageVolumeInfo
+}
+
+// RestoreStorageVolumeSnapshotGroup creates a new storage volume from each snapshot of a group.
+// Groups with snapshots that don't have a distinct attachment index are rejected, as the names of their volumes would collide.
+// If any volume can't be created, the volumes that were created are deleted again.
+func (c *StorageVolumeSnapshotClient) RestoreStorageVolumeSnapshotGroup(input *RestoreStorageVolumeSnapshotGroupInput) ([]RestoredStorageVolume, error) {
+	if input.NamePrefix == "" {
+		return nil, fmt.Errorf("A name prefix for the restored storage volumes needs to be specified")
+	}
+
+	group, err := c.GetStorageVolumeSnapshotGroup(&GetStorageVolumeSnapshotGroupInput{GroupID: input.GroupID})
+	if err != nil {
+		return nil, err
+	}
+
+	restored := make([]RestoredStorageVolume, len(group.Snapshots))
+	errs := make([]error, len(group.Snapshots))
+
+	indexes := map[int]string{}
+	for i, snapshot := range group.Snapshots {
+		index, ok := storageVolumeSnapshotGroupIndex(&snapshot)
+		if !ok {
+			return nil, fmt.Errorf("Storage volume snapshot %s of group %s has no attachment index to name its volume after", snapshot.Name, input.GroupID)
+		}
+		if other, ok := indexes[index]; ok {
+			return nil, fmt.Errorf("Storage volume snapshots %s and %s of group %s have the same attachment index %d", other, snapshot.Name, input.GroupID, index)
+		}
+		indexes[index] = snapshot.Name
+		restored[i] = RestoredStorageVolume{
+			Index:    index,
+			Snapshot: snapshot.Name,
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, snapshot := range group.Snapshots {
+		wg.Add(1)
+		go func(i int, snapshot StorageVolumeSnapshotInfo) {
+			defer wg.Done()
+
+			createInput := &CreateStorageVolumeInput{
+				Bootable:     snapshot.ParentVolumeBootable == "true",
+				Name:         fmt.Sprintf("%s-%d", input.NamePrefix, restored[i].Index),
+				Properties:   input.Properties,
+				Size:         snapshot.Size,
+				Snapshot:     c.getQualifiedName(snapshot.Name),
+				SnapshotID:   snapshot.SnapshotID,
+				Tags:         input.Tags,
+				PollInterval: input.PollInterval,
+				Timeout:      input.Timeout,
+			}
+			volume, err := c.Client.StorageVolumes().CreateStorageVolume(createInput)
+			if err != nil {
+				errs[i] = fmt.Errorf("Error restoring snapshot %s: %s", snapshot.Name, err)
+				return
+			}
+			restored[i].Volume = volume
+		}(i, snapshot)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		for _, volume := range restored {
+			if volume.Volume == nil {
+				continue
+			}
+			deleteInput := &DeleteStorageVolumeInput{
+				Name:         volume.Volume.Name,
+				PollInterval: input.PollInterval,
+				Timeout:      input.Timeout,
+			}
+			if deleteErr := c.Client.StorageVolumes().DeleteStorageVolume(deleteInput); deleteErr != nil {
+				c.client.DebugLogString(fmt.Sprintf("Error deleting restored storage volume %s: %s", volume.Volume.Name, deleteErr))
+			}
+		}
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// Returns the attachment index recorded in the tags of a snapshot of a group
+func storageVolumeSnapshotGroupIndex(snapshot *StorageVolumeSnapshotInfo) (int, bool) {
+	for _, tag := range snapshot.Tags {
+		if !strings.HasPrefix(tag, StorageVolumeSnapshotGroupIndexTag) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(tag, StorageVolumeSnapshotGroupIndexTag))
+		if err != nil {
+			return 0, false
+		}
+		return index, true
+	}
+	return 0, false
+}
+
+func sortStorageVolumeSnapshotGroup(group *StorageVolumeSnapshotGroup) {
+	sort.SliceStable(group.Snapshots, func(i, j int) bool {
+		a, _ := storageVolumeSnapshotGroupIndex(&group.Snapshots[i])
+		b, _ := storageVolumeSnapshotGroupIndex(&group.Snapshots[j])
+		return a < b
+	})
+}
@@ -0,0 +1,332 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Test that all of the volumes attached to an instance are snapshotted with a shared group ID,
+// while the instance is shut down.
+func TestStorageVolumeSnapshotClient_CreateStorageVolumeSnapshotGroup(t *testing.T) {
+	server := newSnapshotGroupServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeSnapshotClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateStorageVolumeSnapshotGroupInput{
+		Instance:     "db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e",
+		GroupID:      "nightly",
+		Property:     SnapshotPropertyCollocated,
+		Shutdown:     true,
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	group, err := client.CreateStorageVolumeSnapshotGroup(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, snapshot := range group.Snapshots {
+		names = append(names, snapshot.Name)
+	}
+	if diff := pretty.Compare(names, []string{"db-boot-snapshot", "db-data-snapshot"}); diff != "" {
+		t.Errorf("Snapshot Group Diff: (-got +want)\n%s", diff)
+	}
+
+	changes := server.changes()
+	if len(changes) != 4 {
+		t.Fatalf("Expected 4 changes, got %v", changes)
+	}
+	// The snapshots are triggered concurrently, so their order isn't deterministic
+	sort.Strings(changes[1:3])
+	expected := []string{
+		"PUT /instance/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e desired_state=shutdown",
+		"POST /storage/snapshot/ volume=/Compute-test/test/db-boot tags=snapshot-group=nightly,snapshot-group-index=1",
+		"POST /storage/snapshot/ volume=/Compute-test/test/db-data tags=snapshot-group=nightly,snapshot-group-index=2",
+		"PUT /instance/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e desired_state=running",
+	}
+	if diff := pretty.Compare(changes, expected); diff != "" {
+		t.Errorf("Snapshot Group Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Test that the snapshots of a group are deleted again if one of them fails, and that the input isn't changed.
+func TestStorageVolumeSnapshotClient_CreateStorageVolumeSnapshotGroup_Failed(t *testing.T) {
+	server := newSnapshotGroupServer(t)
+	server.failed = "/Compute-test/test/db-data-snapshot"
+	defer server.Close()
+
+	client, err := getStubStorageVolumeSnapshotClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &CreateStorageVolumeSnapshotGroupInput{
+		Instance:     "db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e",
+		Shutdown:     true,
+		PollInterval: 1 * time.Second,
+	}
+	original := *input
+	if _, err := client.CreateStorageVolumeSnapshotGroup(input); err == nil {
+		t.Fatal("Expected an error creating a group with a failed snapshot")
+	}
+	if diff := pretty.Compare(input, &original); diff != "" {
+		t.Errorf("Input Diff: (-got +want)\n%s", diff)
+	}
+
+	changes := server.changes()
+	if len(changes) != 6 {
+		t.Fatalf("Expected 6 changes, got %v", changes)
+	}
+	// The snapshots are triggered concurrently, and deleted in the order of the attachments
+	sort.Strings(changes[1:3])
+	sort.Strings(changes[4:6])
+	expected := []string{
+		"PUT /instance/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e desired_state=shutdown",
+		"POST /storage/snapshot/ volume=/Compute-test/test/db-boot tags=snapshot-group=" + groupIDOf(changes[1]) + ",snapshot-group-index=1",
+		"POST /storage/snapshot/ volume=/Compute-test/test/db-data tags=snapshot-group=" + groupIDOf(changes[1]) + ",snapshot-group-index=2",
+		"PUT /instance/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e desired_state=running",
+		"DELETE /storage/snapshot/Compute-test/test/db-boot-snapshot",
+		"DELETE /storage/snapshot/Compute-test/test/db-data-snapshot",
+	}
+	if diff := pretty.Compare(changes, expected); diff != "" {
+		t.Errorf("Snapshot Group Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+// Returns the generated group ID a snapshot was tagged with
+func groupIDOf(change string) string {
+	tags := strings.SplitN(change, "tags="+StorageVolumeSnapshotGroupTag, 2)
+	return strings.Split(tags[len(tags)-1], ",")[0]
+}
+
+// Test that every snapshot of a group is restored as a new volume named after its attachment index.
+func TestStorageVolumeSnapshotClient_RestoreStorageVolumeSnapshotGroup(t *testing.T) {
+	server := newSnapshotGroupServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeSnapshotClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	input := &RestoreStorageVolumeSnapshotGroupInput{
+		GroupID:      "weekly",
+		NamePrefix:   "db-restored",
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	restored, err := client.RestoreStorageVolumeSnapshotGroup(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(restored) != 2 {
+		t.Fatalf("Expected 2 restored volumes, got %d", len(restored))
+	}
+	for i, volume := range restored {
+		expectedName := fmt.Sprintf("db-restored-%d", i+1)
+		if volume.Index != i+1 || volume.Volume.Name != expectedName {
+			t.Errorf("Expected volume %s at index %d, got %s at index %d", expectedName, i+1, volume.Volume.Name, volume.Index)
+		}
+	}
+
+	changes := server.changes()
+	sort.Strings(changes)
+	expected := []string{
+		"POST /storage/volume/ name=/Compute-test/test/db-restored-1 snapshot=/Compute-test/test/weekly-boot size=10737418240",
+		"POST /storage/volume/ name=/Compute-test/test/db-restored-2 snapshot=/Compute-test/test/weekly-data size=53687091200",
+	}
+	if diff := pretty.Compare(changes, expected); diff != "" {
+		t.Errorf("Restore Requests Diff: (-got +want)\n%s", diff)
+	}
+
+	// The volume of manual-data would have no index to be named after
+	input.GroupID = "manual"
+	if _, err := client.RestoreStorageVolumeSnapshotGroup(input); err == nil {
+		t.Errorf("Expected an error restoring a group with a snapshot without an attachment index")
+	}
+	if len(server.changes()) != len(expected) {
+		t.Errorf("Expected no volumes to be restored from group manual, got %v", server.changes()[len(expected):])
+	}
+}
+
+type snapshotGroupServer struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	state   InstanceState
+	changed []string
+	// The name of a snapshot that fails to be created
+	failed  string
+	deleted map[string]bool
+}
+
+func (s *snapshotGroupServer) Close() {
+	s.server.Close()
+}
+
+func (s *snapshotGroupServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves an instance with a boot and a data volume, the snapshots of the "weekly" group and restored volumes
+func newSnapshotGroupServer(t *testing.T) *snapshotGroupServer {
+	s := &snapshotGroupServer{
+		state:   InstanceRunning,
+		deleted: map[string]bool{},
+	}
+	instancePath := "/instance/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e"
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "GET" && r.URL.Path == instancePath:
+			w.Write([]byte(fmt.Sprintf(exampleSnapshotGroupInstanceResponse, s.state, s.state)))
+		case r.Method == "PUT" && r.URL.Path == instancePath:
+			var body UpdateInstanceInput
+			unmarshalRequestBody(t, r, &body)
+			s.state = InstanceState(body.DesiredState)
+			change = fmt.Sprintf("%s desired_state=%s", change, body.DesiredState)
+			w.Write([]byte(fmt.Sprintf(exampleSnapshotGroupInstanceResponse, s.state, s.state)))
+		case r.Method == "POST" && r.URL.Path == "/storage/snapshot/":
+			var body CreateStorageVolumeSnapshotInput
+			unmarshalRequestBody(t, r, &body)
+			if s.state != InstanceShutdown {
+				t.Errorf("Expected the instance to be shut down while snapshotting, got %s", s.state)
+			}
+			change = fmt.Sprintf("%s volume=%s tags=%s", change, body.Volume, strings.Join(body.Tags, ","))
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s-snapshot", "volume": "%s", "status": "creating", "size": "10737418240"}`, body.Volume, body.Volume)))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/storage/snapshot/Compute-test/test/db-"):
+			s.deleted[strings.TrimPrefix(r.URL.Path, "/storage/snapshot")] = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/storage/snapshot/Compute-test/test/db-"):
+			name := strings.TrimPrefix(r.URL.Path, "/storage/snapshot")
+			if s.deleted[name] {
+				w.WriteHeader(http.StatusNotFound)
+				break
+			}
+			index := 2
+			if strings.Contains(name, "boot") {
+				index = 1
+			}
+			status := "completed"
+			if name == s.failed {
+				status = "error"
+			}
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "volume": "%s", "status": "%s", "size": "10737418240", "tags": ["snapshot-group=nightly", "snapshot-group-index=%d"]}`,
+				name, strings.TrimSuffix(name, "-snapshot"), status, index)))
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/":
+			w.Write([]byte(exampleSnapshotGroupListResponse))
+		case r.Method == "POST" && r.URL.Path == "/storage/volume/":
+			var body CreateStorageVolumeInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s name=%s snapshot=%s size=%d", change, body.Name, body.Snapshot, body.Size)
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "size": "%d", "status": "initializing"}`, body.Name, body.Size)))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/storage/volume/Compute-test/test/db-restored-"):
+			w.Write([]byte(fmt.Sprintf(`{"name": "%s", "size": "10737418240", "status": "online"}`, strings.TrimPrefix(r.URL.Path, "/storage/volume"))))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
+
+func getStubStorageVolumeSnapshotClient(server *httptest.Server) (*StorageVolumeSnapshotClient, error) {
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.StorageVolumeSnapshots(), nil
+}
+
+var exampleSnapshotGroupInstanceResponse = `
+{
+  "name": "/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e",
+  "desired_state": "%s",
+  "state": "%s",
+  "storage_attachments": [
+    {
+      "index": 2,
+      "name": "/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e/2b1e4f07-9d1a-4d5d-8f22-7fd9d3c5a1b0",
+      "storage_volume_name": "/Compute-test/test/db-data"
+    },
+    {
+      "index": 1,
+      "name": "/Compute-test/test/db/8d0c8a0a-1e55-4b0c-9d3e-4c1fb1bd7a3e/5c0b6a3e-7d28-4e0c-b8a1-8b0fd3f0c6d2",
+      "storage_volume_name": "/Compute-test/test/db-boot"
+    }
+  ]
+}
+`
+
+var exampleSnapshotGroupListResponse = `
+{
+  "result": [
+    {
+      "name": "/Compute-test/test/weekly-data",
+      "volume": "/Compute-test/test/db-data",
+      "snapshot_id": "2",
+      "status": "completed",
+      "size": "53687091200",
+      "tags": ["snapshot-group=weekly", "snapshot-group-index=2"]
+    },
+    {
+      "name": "/Compute-test/test/unrelated",
+      "volume": "/Compute-test/test/web",
+      "snapshot_id": "3",
+      "status": "completed",
+      "size": "10737418240",
+      "tags": ["snapshot-group=daily", "snapshot-group-index=1"]
+    },
+    {
+      "name": "/Compute-test/test/manual-data",
+      "volume": "/Compute-test/test/db-data",
+      "snapshot_id": "4",
+      "status": "completed",
+      "size": "53687091200",
+      "tags": ["snapshot-group=manual"]
+    },
+    {
+      "name": "/Compute-test/test/manual-boot",
+      "volume": "/Compute-test/test/db-boot",
+      "snapshot_id": "5",
+      "status": "completed",
+      "size": "10737418240",
+      "tags": ["snapshot-group=manual", "snapshot-group-index=1"]
+    },
+    {
+      "name": "/Compute-test/test/weekly-boot",
+      "volume": "/Compute-test/test/db-boot",
+      "snapshot_id": "1",
+      "status": "completed",
+      "size": "10737418240",
+      "tags": ["snapshot-group=weekly", "snapshot-group-index=1"]
+    }
+  ]
+}
+`
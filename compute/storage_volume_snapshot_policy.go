@@ -0,0 +1,346 @@
+package compute
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// StorageVolumeSnapshotPolicyTag prefixes the tag that identifies the snapshots created by a policy
+	StorageVolumeSnapshotPolicyTag = "snapshot-policy="
+	// StorageVolumeSnapshotPolicyTimeTag prefixes the tag that records when a policy created a snapshot
+	StorageVolumeSnapshotPolicyTimeTag = "snapshot-policy-time="
+)
+
+// StorageVolumeSnapshotPolicy describes which storage volumes to snapshot, and how many of their snapshots to keep.
+// Snapshots are retained grandfather-father-son style: the latest snapshot of each of the last Hourly hours,
+// Daily days and Weekly weeks is kept, and all other snapshots created by the policy are deleted.
+type StorageVolumeSnapshotPolicy struct {
+	// The name of the policy. Only snapshots tagged with this name are ever deleted.
+	// Required
+	Name string
+	// Select the volumes that have all of these tags
+	// Optional, but either VolumeTags or VolumeNamePattern must be given
+	VolumeTags []string
+	// Select the volumes with an unqualified name matching this pattern, in path.Match syntax, such as db-*
+	// Optional, but either VolumeTags or VolumeNamePattern must be given
+	VolumeNamePattern string
+	// The number of hourly snapshots to keep
+	// Optional
+	Hourly int
+	// The number of daily snapshots to keep
+	// Optional
+	Daily int
+	// The number of weekly snapshots to keep
+	// Optional
+	Weekly int
+	// Whether the snapshots are collocated or remote
+	// Optional, will be remote if unspecified
+	Property string
+	// Returns the current time. Defaults to time.Now, and can be replaced in tests.
+	// Optional
+	Clock func() time.Time
+}
+
+// StorageVolumeSnapshotPlan details the snapshots a policy creates and deletes
+type StorageVolumeSnapshotPlan struct {
+	// The names of the volumes to snapshot
+	Create []string
+	// The names of the snapshots to delete
+	Delete []string
+	// The names of the snapshots of the policy that are kept, as the time they were created is unknown
+	Skipped []string
+}
+
+// ApplyStorageVolumeSnapshotPolicyInput specifies the policy to apply
+type ApplyStorageVolumeSnapshotPolicyInput struct {
+	// The policy to apply
+	// Required
+	Policy *StorageVolumeSnapshotPolicy
+	// If true, the plan is returned without creating or deleting any snapshots
+	// Optional
+	DryRun bool
+	// Time to wait between polls to check status
+	PollInterval time.Duration
+	// Time to wait for each snapshot to be created or deleted
+	Timeout time.Duration
+}
+
+// ApplyStorageVolumeSnapshotPolicy snapshots the volumes selected by a policy, if a snapshot is due,
+// and deletes the snapshots that are no longer retained. It's meant to be run at least as often as
+// the shortest period the policy keeps snapshots for, such as every hour for an hourly policy.
+// The snapshots of volumes that are no longer selected, or were deleted, age out the same way.
+// Returns the plan that was applied.
+func (c *StorageVolumeSnapshotClient) ApplyStorageVolumeSnapshotPolicy(input *ApplyStorageVolumeSnapshotPolicyInput) (*StorageVolumeSnapshotPlan, error) {
+	policy := input.Policy
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if policy.Clock != nil {
+		now = policy.Clock
+	}
+	at := now().UTC()
+
+	volumes, err := c.Client.StorageVolumes().ListStorageVolumes()
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := c.ListStorageVolumeSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := policy.plan(volumes, snapshots, at)
+	if input.DryRun {
+		return plan, nil
+	}
+
+	for _, volume := range plan.Create {
+		createInput := &CreateStorageVolumeSnapshotInput{
+			Description: fmt.Sprintf("Created by snapshot policy %s", policy.Name),
+			Property:    policy.Property,
+			Tags: []string{
+				StorageVolumeSnapshotPolicyTag + policy.Name,
+				StorageVolumeSnapshotPolicyTimeTag + at.Format(time.RFC3339),
+			},
+			Volume:       volume,
+			PollInterval: input.PollInterval,
+			Timeout:      input.Timeout,
+		}
+		if _, err := c.CreateStorageVolumeSnapshot(createInput); err != nil {
+			return plan, fmt.Errorf("Error creating snapshot of storage volume %s: %s", volume, err)
+		}
+	}
+
+	for _, snapshot := range plan.Delete {
+		deleteInput := &DeleteStorageVolumeSnapshotInput{
+			Name:         snapshot,
+			PollInterval: input.PollInterval,
+			Timeout:      input.Timeout,
+		}
+		if err := c.DeleteStorageVolumeSnapshot(deleteInput); err != nil {
+			return plan, fmt.Errorf("Error deleting snapshot %s: %s", snapshot, err)
+		}
+	}
+
+	return plan, nil
+}
+
+func (p *StorageVolumeSnapshotPolicy) validate() error {
+	if p == nil || p.Name == "" {
+		return fmt.Errorf("A snapshot policy with a name needs to be specified")
+	}
+	if len(p.VolumeTags) == 0 && p.VolumeNamePattern == "" {
+		return fmt.Errorf("Snapshot policy %s must select volumes by tag or name pattern", p.Name)
+	}
+	if p.VolumeNamePattern != "" {
+		if _, err := path.Match(p.VolumeNamePattern, ""); err != nil {
+			return fmt.Errorf("Snapshot policy %s has an invalid name pattern %q: %s", p.Name, p.VolumeNamePattern, err)
+		}
+	}
+	if p.Hourly < 0 || p.Daily < 0 || p.Weekly < 0 {
+		return fmt.Errorf("Snapshot policy %s can't keep a negative number of snapshots", p.Name)
+	}
+	if p.Hourly+p.Daily+p.Weekly == 0 {
+		return fmt.Errorf("Snapshot policy %s must keep at least one hourly, daily or weekly snapshot", p.Name)
+	}
+	return nil
+}
+
+func (p *StorageVolumeSnapshotPolicy) selects(volume *StorageVolumeInfo) bool {
+	if p.VolumeNamePattern != "" {
+		if matched, _ := path.Match(p.VolumeNamePattern, volume.Name); !matched {
+			return false
+		}
+	}
+	for _, tag := range p.VolumeTags {
+		if !hasTag(volume.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// A snapshot created by a policy, or one the policy is about to create if name is empty
+type policySnapshot struct {
+	name    string
+	created time.Time
+}
+
+func (p *StorageVolumeSnapshotPolicy) plan(volumes []StorageVolumeInfo, snapshots []StorageVolumeSnapshotInfo, now time.Time) *StorageVolumeSnapshotPlan {
+	byVolume := map[string][]policySnapshot{}
+	skipped := []string{}
+	for _, snapshot := range snapshots {
+		if !hasTag(snapshot.Tags, StorageVolumeSnapshotPolicyTag+p.Name) {
+			continue
+		}
+		created, ok := storageVolumeSnapshotPolicyTime(&snapshot)
+		if !ok {
+			// Fall back to when the API took the snapshot, if the tag was lost or mangled
+			created = snapshot.SnapshotTimestamp.Time.UTC()
+		}
+		if created.IsZero() {
+			skipped = append(skipped, snapshot.Name)
+			continue
+		}
+		byVolume[snapshot.Volume] = append(byVolume[snapshot.Volume], policySnapshot{
+			name:    snapshot.Name,
+			created: created,
+		})
+	}
+	sort.Strings(skipped)
+
+	plan := &StorageVolumeSnapshotPlan{
+		Create:  []string{},
+		Delete:  []string{},
+		Skipped: skipped,
+	}
+	selected := map[string]bool{}
+	names := []string{}
+	for i := range volumes {
+		if p.selects(&volumes[i]) {
+			selected[volumes[i].Name] = true
+			names = append(names, volumes[i].Name)
+		}
+	}
+	// The snapshots of the volumes that are no longer selected still age out
+	for volume := range byVolume {
+		if !selected[volume] {
+			names = append(names, volume)
+		}
+	}
+	sort.Strings(names)
+
+	for _, volume := range names {
+		existing := byVolume[volume]
+		if selected[volume] && p.due(existing, now) {
+			plan.Create = append(plan.Create, volume)
+			existing = append(existing, policySnapshot{created: now})
+		}
+
+		retained := p.retain(existing, now)
+		for i, snapshot := range existing {
+			if snapshot.name != "" && !retained[i] {
+				plan.Delete = append(plan.Delete, snapshot.name)
+			}
+		}
+	}
+	sort.Strings(plan.Delete)
+
+	return plan
+}
+
+// A snapshot is due if there's none yet in the current period of the shortest retention period
+func (p *StorageVolumeSnapshotPolicy) due(snapshots []policySnapshot, now time.Time) bool {
+	period := p.periods()[0]
+	start := period.start(now)
+	for _, snapshot := range snapshots {
+		if !snapshot.created.Before(start) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keeps the latest snapshot in each of the retained periods, and any snapshot from the future.
+// Returns the indexes of the snapshots to keep.
+func (p *StorageVolumeSnapshotPolicy) retain(snapshots []policySnapshot, now time.Time) map[int]bool {
+	retained := map[int]bool{}
+	for i, snapshot := range snapshots {
+		if snapshot.created.After(now) {
+			retained[i] = true
+		}
+	}
+
+	for _, period := range p.periods() {
+		for i := 0; i < period.keep; i++ {
+			start := period.shift(period.start(now), -i)
+			end := period.shift(start, 1)
+
+			latest := -1
+			for j, snapshot := range snapshots {
+				if snapshot.created.Before(start) || !snapshot.created.Before(end) {
+					continue
+				}
+				if latest == -1 || snapshot.created.After(snapshots[latest].created) {
+					latest = j
+				}
+			}
+			if latest != -1 {
+				retained[latest] = true
+			}
+		}
+	}
+	return retained
+}
+
+// A retention period, with the number of snapshots to keep for it
+type snapshotPeriod struct {
+	keep  int
+	start func(t time.Time) time.Time
+	shift func(t time.Time, n int) time.Time
+}
+
+// Returns the retention periods of the policy, shortest first
+func (p *StorageVolumeSnapshotPolicy) periods() []snapshotPeriod {
+	periods := []snapshotPeriod{}
+	if p.Hourly > 0 {
+		periods = append(periods, snapshotPeriod{
+			keep:  p.Hourly,
+			start: func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) },
+			shift: func(t time.Time, n int) time.Time { return t.Add(time.Duration(n) * time.Hour) },
+		})
+	}
+	if p.Daily > 0 {
+		periods = append(periods, snapshotPeriod{
+			keep:  p.Daily,
+			start: startOfDay,
+			shift: func(t time.Time, n int) time.Time { return t.AddDate(0, 0, n) },
+		})
+	}
+	if p.Weekly > 0 {
+		periods = append(periods, snapshotPeriod{
+			keep: p.Weekly,
+			// Weeks start on Monday
+			start: func(t time.Time) time.Time {
+				day := startOfDay(t)
+				return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+			},
+			shift: func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) },
+		})
+	}
+	return periods
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Returns the time a snapshot was created by a policy, as recorded in its tags
+func storageVolumeSnapshotPolicyTime(snapshot *StorageVolumeSnapshotInfo) (time.Time, bool) {
+	for _, tag := range snapshot.Tags {
+		if !strings.HasPrefix(tag, StorageVolumeSnapshotPolicyTimeTag) {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, strings.TrimPrefix(tag, StorageVolumeSnapshotPolicyTimeTag))
+		if err != nil {
+			return time.Time{}, false
+		}
+		return created.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
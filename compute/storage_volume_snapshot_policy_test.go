@@ -0,0 +1,229 @@
+package compute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// Test the snapshots planned for a grandfather-father-son policy as time passes.
+func TestStorageVolumeSnapshotClient_ApplyStorageVolumeSnapshotPolicyDryRun(t *testing.T) {
+	server := newSnapshotPolicyServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeSnapshotClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	// A Wednesday
+	clock := &fakeClock{now: time.Date(2018, 3, 14, 10, 30, 0, 0, time.UTC)}
+	input := &ApplyStorageVolumeSnapshotPolicyInput{
+		Policy: &StorageVolumeSnapshotPolicy{
+			Name:       "gfs",
+			VolumeTags: []string{"backup"},
+			Hourly:     2,
+			Daily:      2,
+			Weekly:     1,
+			Clock:      clock.Now,
+		},
+		DryRun: true,
+	}
+
+	plan, err := client.ApplyStorageVolumeSnapshotPolicy(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &StorageVolumeSnapshotPlan{
+		// The db volume already has a snapshot this hour, and the scratch volume is no longer selected
+		Create: []string{"web"},
+		// Keeps the snapshots of this and the previous hour, and the latest of yesterday.
+		// db-0313-0700 lost its time tag, and is retained by the time it was taken instead.
+		Delete: []string{"db-0314-0810", "db-0313-1200", "db-0313-0700", "db-0305-0900", "scratch-0314-0830"},
+		// The time of db-mangled is unknown
+		Skipped: []string{"db-mangled"},
+	}
+	sort.Strings(expected.Delete)
+	if diff := pretty.Compare(plan, expected); diff != "" {
+		t.Errorf("Snapshot Plan Diff: (-got +want)\n%s", diff)
+	}
+
+	clock.Advance(time.Hour)
+	plan, err = client.ApplyStorageVolumeSnapshotPolicy(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = &StorageVolumeSnapshotPlan{
+		Create: []string{"db", "web"},
+		// The 09:10 snapshot drops out of the hourly snapshots
+		Delete:  []string{"db-0314-0810", "db-0314-0910", "db-0313-1200", "db-0313-0700", "db-0305-0900", "scratch-0314-0830"},
+		Skipped: []string{"db-mangled"},
+	}
+	sort.Strings(expected.Delete)
+	if diff := pretty.Compare(plan, expected); diff != "" {
+		t.Errorf("Snapshot Plan Diff: (-got +want)\n%s", diff)
+	}
+
+	if len(server.changes()) != 0 {
+		t.Errorf("Expected no changes in a dry run, got %v", server.changes())
+	}
+}
+
+// Test that applying a policy creates and deletes the planned snapshots.
+func TestStorageVolumeSnapshotClient_ApplyStorageVolumeSnapshotPolicy(t *testing.T) {
+	server := newSnapshotPolicyServer(t)
+	defer server.Close()
+
+	client, err := getStubStorageVolumeSnapshotClient(server.server)
+	if err != nil {
+		t.Fatalf("err getting stub client: %s", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2018, 3, 14, 10, 30, 0, 0, time.UTC)}
+	input := &ApplyStorageVolumeSnapshotPolicyInput{
+		Policy: &StorageVolumeSnapshotPolicy{
+			Name:              "gfs",
+			VolumeNamePattern: "*",
+			VolumeTags:        []string{"backup"},
+			Daily:             2,
+			Clock:             clock.Now,
+		},
+		PollInterval: 1 * time.Second,
+		Timeout:      10 * time.Second,
+	}
+	if _, err := client.ApplyStorageVolumeSnapshotPolicy(input); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"POST /storage/snapshot/ volume=/Compute-test/test/web tags=snapshot-policy=gfs,snapshot-policy-time=2018-03-14T10:30:00Z",
+		"DELETE /storage/snapshot/Compute-test/test/db-0305-0900",
+		"DELETE /storage/snapshot/Compute-test/test/db-0313-0700",
+		"DELETE /storage/snapshot/Compute-test/test/db-0313-1200",
+		"DELETE /storage/snapshot/Compute-test/test/db-0314-0810",
+		"DELETE /storage/snapshot/Compute-test/test/db-0314-0910",
+		"DELETE /storage/snapshot/Compute-test/test/scratch-0314-0830",
+	}
+	if diff := pretty.Compare(server.changes(), expected); diff != "" {
+		t.Errorf("Snapshot Policy Requests Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestStorageVolumeSnapshotPolicy_Validate(t *testing.T) {
+	invalid := []*StorageVolumeSnapshotPolicy{
+		nil,
+		{Name: "", VolumeTags: []string{"backup"}, Daily: 1},
+		{Name: "all", Daily: 1},
+		{Name: "pattern", VolumeNamePattern: "[", Daily: 1},
+		{Name: "none", VolumeTags: []string{"backup"}},
+		{Name: "negative", VolumeTags: []string{"backup"}, Daily: 2, Weekly: -1},
+	}
+	for _, policy := range invalid {
+		if err := policy.validate(); err == nil {
+			t.Errorf("Expected policy %+v to be invalid", policy)
+		}
+	}
+}
+
+type snapshotPolicyServer struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	deleted map[string]bool
+	changed []string
+}
+
+func (s *snapshotPolicyServer) Close() {
+	s.server.Close()
+}
+
+func (s *snapshotPolicyServer) changes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Serves the volumes and snapshots of the policy tests
+func newSnapshotPolicyServer(t *testing.T) *snapshotPolicyServer {
+	s := &snapshotPolicyServer{
+		deleted: map[string]bool{},
+	}
+	s.server = newAuthenticatingServer(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		change := strings.Join([]string{r.Method, r.URL.Path}, " ")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/storage/volume/Compute-test/test/":
+			w.Write([]byte(exampleSnapshotPolicyVolumesResponse))
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/":
+			w.Write([]byte(exampleSnapshotPolicySnapshotsResponse))
+		case r.Method == "POST" && r.URL.Path == "/storage/snapshot/":
+			var body CreateStorageVolumeSnapshotInput
+			unmarshalRequestBody(t, r, &body)
+			change = fmt.Sprintf("%s volume=%s tags=%s", change, body.Volume, strings.Join(body.Tags, ","))
+			w.Write([]byte(`{"name": "/Compute-test/test/web-new", "volume": "/Compute-test/test/web", "status": "creating", "size": "0"}`))
+		case r.Method == "GET" && r.URL.Path == "/storage/snapshot/Compute-test/test/web-new":
+			w.Write([]byte(`{"name": "/Compute-test/test/web-new", "volume": "/Compute-test/test/web", "status": "completed", "size": "0"}`))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/storage/snapshot/Compute-test/test/"):
+			s.deleted[r.URL.Path] = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && s.deleted[r.URL.Path]:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Method != "GET" {
+			s.changed = append(s.changed, change)
+		}
+	})
+	return s
+}
+
+var exampleSnapshotPolicyVolumesResponse = `
+{
+  "result": [
+    {"name": "/Compute-test/test/db", "size": "10737418240", "tags": ["backup"]},
+    {"name": "/Compute-test/test/web", "size": "10737418240", "tags": ["backup", "frontend"]},
+    {"name": "/Compute-test/test/scratch", "size": "10737418240", "tags": ["frontend"]}
+  ]
+}
+`
+
+var exampleSnapshotPolicySnapshotsResponse = `
+{
+  "result": [
+    {"name": "/Compute-test/test/db-0314-1005", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-14T10:05:00Z"]},
+    {"name": "/Compute-test/test/db-0314-0910", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-14T09:10:00Z"]},
+    {"name": "/Compute-test/test/db-0314-0810", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-14T08:10:00Z"]},
+    {"name": "/Compute-test/test/db-0313-2300", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-13T23:00:00Z"]},
+    {"name": "/Compute-test/test/db-0313-1200", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-13T12:00:00Z"]},
+    {"name": "/Compute-test/test/db-0305-0900", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-05T09:00:00Z"]},
+    {"name": "/Compute-test/test/db-manual", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=other", "snapshot-policy-time=2018-03-01T09:00:00Z"]},
+    {"name": "/Compute-test/test/web-untagged", "volume": "/Compute-test/test/web", "size": "0"},
+    {"name": "/Compute-test/test/db-0313-0700", "volume": "/Compute-test/test/db", "size": "0", "snapshot_timestamp": "2018-03-13T07:00:00Z", "tags": ["snapshot-policy=gfs"]},
+    {"name": "/Compute-test/test/db-mangled", "volume": "/Compute-test/test/db", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=yesterday"]},
+    {"name": "/Compute-test/test/scratch-0314-0900", "volume": "/Compute-test/test/scratch", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-14T09:00:00Z"]},
+    {"name": "/Compute-test/test/scratch-0314-0830", "volume": "/Compute-test/test/scratch", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-14T08:30:00Z"]},
+    {"name": "/Compute-test/test/scratch-0313-1100", "volume": "/Compute-test/test/scratch", "size": "0", "tags": ["snapshot-policy=gfs", "snapshot-policy-time=2018-03-13T11:00:00Z"]}
+  ]
+}
+`
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const (
@@ -62,17 +63,17 @@ type StorageVolumeSnapshotInfo struct {
 	// String determining whether the snapshot is remote or collocated
 	Property string `json:"property"`
 
-	// The size of the snapshot in GB
-	Size string `json:"size"`
+	// The size of the snapshot
+	Size opc.ByteSize `json:"size"`
 
 	// The ID of the snapshot. Generated by the server
 	SnapshotID string `json:"snapshot_id"`
 
 	// The timestamp of the storage snapshot
-	SnapshotTimestamp string `json:"snapshot_timestamp"`
+	SnapshotTimestamp opc.Time `json:"snapshot_timestamp"`
 
 	// Timestamp for when the operation started
-	StartTimestamp string `json:"start_timestamp"`
+	StartTimestamp opc.Time `json:"start_timestamp"`
 
 	// Status of the snapshot
 	Status string `json:"status"`
@@ -81,7 +82,7 @@ type StorageVolumeSnapshotInfo struct {
 	StatusDetail string `json:"status_detail"`
 
 	// Indicates the time that the current view of the storage volume snapshot was generated.
-	StatusTimestamp string `json:"status_timestamp"`
+	StatusTimestamp opc.Time `json:"status_timestamp"`
 
 	// Array of tags for the snapshot
 	Tags []string `json:"tags,omitempty"`
@@ -169,6 +170,26 @@ func (c *StorageVolumeSnapshotClient) GetStorageVolumeSnapshot(input *GetStorage
 	return c.success(&storageSnapshot)
 }
 
+// StorageVolumeSnapshotList represents the list of storage volume snapshots returned by the service
+type StorageVolumeSnapshotList struct {
+	Result []StorageVolumeSnapshotInfo `json:"result"`
+}
+
+// ListStorageVolumeSnapshots makes an API request to list the storage volume snapshots of the client's owner
+func (c *StorageVolumeSnapshotClient) ListStorageVolumeSnapshots() ([]StorageVolumeSnapshotInfo, error) {
+	var snapshotList StorageVolumeSnapshotList
+	if err := c.getResource(c.getListContainer(), &snapshotList); err != nil {
+		return nil, err
+	}
+
+	for i := range snapshotList.Result {
+		if _, err := c.success(&snapshotList.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return snapshotList.Result, nil
+}
+
 // DeleteStorageVolumeSnapshotInput represents the body of an API request to delete a storage volume snapshot
 type DeleteStorageVolumeSnapshotInput struct {
 	// Name of the snapshot to delete
@@ -203,12 +224,6 @@ func (c *StorageVolumeSnapshotClient) success(result *StorageVolumeSnapshotInfo)
 	c.unqualify(&result.Name)
 	c.unqualify(&result.Volume)
 
-	sizeInGigaBytes, err := sizeInGigaBytes(result.Size)
-	if err != nil {
-		return nil, err
-	}
-	result.Size = sizeInGigaBytes
-
 	return result, nil
 }
 
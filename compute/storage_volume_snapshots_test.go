@@ -25,7 +25,7 @@ func TestAccStorageVolumeSnapshot_Lifecycle(t *testing.T) {
 	sVolumeInput := &CreateStorageVolumeInput{
 		Name:        volumeName,
 		Description: "test-acc",
-		Size:        "20",
+		Size:        20 * opc.GB,
 		Properties:  []string{string(StorageVolumeKindDefault)},
 	}
 
@@ -2,11 +2,11 @@ package compute
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForVolumeReadyPollInterval = 10 * time.Second
@@ -70,8 +70,8 @@ type StorageVolumeInfo struct {
 	// The storage-pool property: /oracle/public/storage/latency or /oracle/public/storage/default.
 	Properties []string `json:"properties,omitempty"`
 
-	// The size of this storage volume in GB.
-	Size string `json:"size"`
+	// The size of this storage volume, such as 10 * opc.GB.
+	Size opc.ByteSize `json:"size"`
 
 	// Name of the parent snapshot from which the storage volume is restored or cloned.
 	Snapshot string `json:"snapshot,omitempty"`
@@ -90,7 +90,7 @@ type StorageVolumeInfo struct {
 	StatusDetail string `json:"status_detail,omitempty"`
 
 	// It indicates the time that the current view of the storage volume was generated.
-	StatusTimestamp string `json:"status_timestamp,omitempty"`
+	StatusTimestamp opc.Time `json:"status_timestamp,omitempty"`
 
 	// The storage pool from which this volume is allocated.
 	StoragePool string `json:"storage_pool,omitempty"`
@@ -140,8 +140,8 @@ type CreateStorageVolumeInput struct {
 	// The storage-pool property: /oracle/public/storage/latency or /oracle/public/storage/default.
 	Properties []string `json:"properties,omitempty"`
 
-	// The size of this storage volume in GB.
-	Size string `json:"size"`
+	// The size of this storage volume, such as 10 * opc.GB.
+	Size opc.ByteSize `json:"size"`
 
 	// Name of the parent snapshot from which the storage volume is restored or cloned.
 	Snapshot string `json:"snapshot,omitempty"`
@@ -167,14 +167,8 @@ func (c *StorageVolumeClient) CreateStorageVolume(input *CreateStorageVolumeInpu
 	input.Name = c.getQualifiedName(input.Name)
 	input.ImageList = c.getQualifiedName(input.ImageList)
 
-	sizeInBytes, err := sizeInBytes(input.Size)
-	if err != nil {
-		return nil, err
-	}
-	input.Size = sizeInBytes
-
 	var storageInfo StorageVolumeInfo
-	if err = c.createResource(&input, &storageInfo); err != nil {
+	if err := c.createResource(&input, &storageInfo); err != nil {
 		return nil, err
 	}
 
@@ -241,12 +235,6 @@ func (c *StorageVolumeClient) success(result *StorageVolumeInfo) (*StorageVolume
 	c.unqualify(&result.Name)
 	c.unqualify(&result.Snapshot)
 
-	sizeInMegaBytes, err := sizeInGigaBytes(result.Size)
-	if err != nil {
-		return nil, err
-	}
-	result.Size = sizeInMegaBytes
-
 	return result, nil
 }
 
@@ -264,6 +252,26 @@ func (c *StorageVolumeClient) GetStorageVolume(input *GetStorageVolumeInput) (*S
 	return c.success(&storageVolume)
 }
 
+// StorageVolumeList represents the list of storage volumes returned by the service
+type StorageVolumeList struct {
+	Result []StorageVolumeInfo `json:"result"`
+}
+
+// ListStorageVolumes gets Storage Volume information for the storage volumes of the client's owner.
+func (c *StorageVolumeClient) ListStorageVolumes() ([]StorageVolumeInfo, error) {
+	var volumeList StorageVolumeList
+	if err := c.getResource(c.getListContainer(), &volumeList); err != nil {
+		return nil, err
+	}
+
+	for i := range volumeList.Result {
+		if _, err := c.success(&volumeList.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return volumeList.Result, nil
+}
+
 // UpdateStorageVolumeInput represents the body of an API request to update a Storage Volume.
 type UpdateStorageVolumeInput struct {
 	// The description of the storage volume.
@@ -281,8 +289,8 @@ type UpdateStorageVolumeInput struct {
 	// The storage-pool property: /oracle/public/storage/latency or /oracle/public/storage/default.
 	Properties []string `json:"properties,omitempty"`
 
-	// The size of this storage volume in GB.
-	Size string `json:"size"`
+	// The size of this storage volume, such as 10 * opc.GB.
+	Size opc.ByteSize `json:"size"`
 
 	// Name of the parent snapshot from which the storage volume is restored or cloned.
 	Snapshot string `json:"snapshot,omitempty"`
@@ -308,14 +316,8 @@ func (c *StorageVolumeClient) UpdateStorageVolume(input *UpdateStorageVolumeInpu
 	input.Name = c.getQualifiedName(input.Name)
 	input.ImageList = c.getQualifiedName(input.ImageList)
 
-	sizeInBytes, err := sizeInBytes(input.Size)
-	if err != nil {
-		return nil, err
-	}
-	input.Size = sizeInBytes
-
 	path := c.getStorageVolumePath(input.Name)
-	_, err = c.executeRequest("PUT", path, input)
+	_, err := c.executeRequest("PUT", path, input)
 	if err != nil {
 		return nil, err
 	}
@@ -391,25 +393,3 @@ func (c *StorageVolumeClient) waitForStorageVolumeToBeDeleted(name string, pollI
 			return result == nil, nil
 		})
 }
-
-func sizeInGigaBytes(input string) (string, error) {
-	sizeInBytes, err := strconv.Atoi(input)
-	if err != nil {
-		return "", err
-	}
-	sizeInKB := sizeInBytes / 1024
-	sizeInMB := sizeInKB / 1024
-	sizeInGb := sizeInMB / 1024
-	return strconv.Itoa(sizeInGb), nil
-}
-
-func sizeInBytes(input string) (string, error) {
-	sizeInGB, err := strconv.Atoi(input)
-	if err != nil {
-		return "", err
-	}
-	sizeInMB := sizeInGB * 1024
-	sizeInKB := sizeInMB * 1024
-	sizeInBytes := sizeInKB * 1024
-	return strconv.Itoa(sizeInBytes), nil
-}
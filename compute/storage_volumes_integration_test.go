@@ -19,13 +19,13 @@ func TestAccStorageVolumeLifecycle(t *testing.T) {
 	createRequest := CreateStorageVolumeInput{
 		Name:        name,
 		Description: "original description",
-		Size:        "20",
+		Size:        20 * opc.GB,
 		Properties:  []string{string(StorageVolumeKindDefault)},
 	}
 
 	updateRequest := UpdateStorageVolumeInput{
 		Name:        name,
-		Size:        "30",
+		Size:        30 * opc.GB,
 		Description: "updated description",
 		Properties:  []string{string(StorageVolumeKindDefault)},
 	}
@@ -44,14 +44,14 @@ func TestAccStorageVolumeBootableLifecycle(t *testing.T) {
 	createRequest := CreateStorageVolumeInput{
 		Name:        name,
 		Description: "original description",
-		Size:        "20",
+		Size:        20 * opc.GB,
 		ImageList:   imageListName,
 		Properties:  []string{string(StorageVolumeKindDefault)},
 	}
 
 	updateRequest := UpdateStorageVolumeInput{
 		Name:        name,
-		Size:        "30",
+		Size:        30 * opc.GB,
 		Description: "updated description",
 		ImageList:   imageListName,
 		Properties:  []string{string(StorageVolumeKindDefault)},
@@ -103,7 +103,7 @@ func testStorageVolume(t *testing.T, createInput CreateStorageVolumeInput, updat
 		"Retrieved Storage Volume Size did not match Expected.")
 
 	actualSize := createdResponse.Size
-	expectedSize := "20"
+	expectedSize := 20 * opc.GB
 	if actualSize != expectedSize {
 		t.Fatalf("Expected storage volume size %s, but was %s", expectedSize, actualSize)
 	}
@@ -122,7 +122,7 @@ func testStorageVolume(t *testing.T, createInput CreateStorageVolumeInput, updat
 		"Retrieved Storage Volume did not match Expected.")
 
 	actualSize = updatedResponse.Size
-	expectedSize = "30"
+	expectedSize = 30 * opc.GB
 
 	if actualSize != expectedSize {
 		t.Fatalf("Expected storage volume size %s, but was %s", expectedSize, actualSize)
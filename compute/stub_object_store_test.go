@@ -0,0 +1,125 @@
+package compute
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A stub server that stores objects in memory and serves them like the API does, so tests can make changes
+// and read them back. It records the requests that change objects, such as "POST /ip/reservation/".
+type stubObjectStore struct {
+	t *testing.T
+	// The objects by their resource root, such as /ip/reservation, and their qualified name
+	objects  map[string]map[string]map[string]interface{}
+	requests []string
+	// Called with each object that's created or updated, before it's stored. It can change the object,
+	// or return a status code to fail the request.
+	store func(r *http.Request, object map[string]interface{}) int
+	lock  sync.Mutex
+}
+
+func newStubObjectStore(t *testing.T) *stubObjectStore {
+	return &stubObjectStore{
+		t:        t,
+		objects:  map[string]map[string]map[string]interface{}{},
+		requests: []string{},
+	}
+}
+
+// Stores the objects of a list response, such as {"result": [...]}, under the given resource root
+func (s *stubObjectStore) load(root, list string) {
+	var result struct{ Result []map[string]interface{} }
+	if err := json.Unmarshal([]byte(list), &result); err != nil {
+		s.t.Fatal(err)
+	}
+	s.objects[root] = map[string]map[string]interface{}{}
+	for _, object := range result.Result {
+		s.objects[root][object["name"].(string)] = object
+	}
+}
+
+// Returns a client of a server of the stored objects, and a function to close the server
+func (s *stubObjectStore) newClient() (*Client, func()) {
+	server := newAuthenticatingServer(s.serve)
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		server.Close()
+		s.t.Fatal(err)
+	}
+	client, err := getStubClient(endpoint)
+	if err != nil {
+		server.Close()
+		s.t.Fatal(err)
+	}
+	return client, server.Close
+}
+
+func (s *stubObjectStore) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if r.Method != "GET" {
+		s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+	}
+	for root, stored := range s.objects {
+		if !strings.HasPrefix(r.URL.Path, root+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(r.URL.Path, root)
+		var object map[string]interface{}
+		switch r.Method {
+		case "GET":
+			if name == "/Compute-test/test/" {
+				list := []map[string]interface{}{}
+				for _, object := range stored {
+					list = append(list, object)
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"result": list})
+				return
+			}
+			if object = stored[name]; object == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		case "POST", "PUT":
+			if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+				s.t.Errorf("Error decoding %s %s: %s", r.Method, r.URL.Path, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			// Like the API, keep the attributes that aren't updated, such as the IP address of IP reservations
+			for key, value := range stored[name] {
+				if _, ok := object[key]; !ok {
+					object[key] = value
+				}
+			}
+			if s.store != nil {
+				if status := s.store(r, object); status != 0 {
+					w.WriteHeader(status)
+					return
+				}
+			}
+			objectName, ok := object["name"].(string)
+			if !ok {
+				s.t.Errorf("Expected %s %s to have a name", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stored[objectName] = object
+		case "DELETE":
+			delete(stored, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(object)
+		return
+	}
+	s.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+	w.WriteHeader(http.StatusNotFound)
+}
@@ -0,0 +1,299 @@
+package compute
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	// UserDataAttribute is the instance attribute that is made available to the instance in an EC2-compatible manner
+	UserDataAttribute = "userdata"
+	// MaxUserDataSize is the maximum size, in bytes, of the userdata attribute
+	MaxUserDataSize = 16 * 1024
+	// MaxAttributesSize is the maximum size, in bytes, of all of the JSON encoded attributes of an instance
+	MaxAttributesSize = 64 * 1024
+)
+
+// userDataBoundary separates the parts of a multi-part userdata.
+// A fixed boundary keeps the generated userdata stable between runs.
+const userDataBoundary = "===============go-oracle-terraform-userdata=="
+
+// UserDataContentType specifies the constants that a userdata part content type can be in
+type UserDataContentType string
+
+const (
+	// UserDataCloudConfig - text/cloud-config
+	UserDataCloudConfig UserDataContentType = "text/cloud-config"
+	// UserDataShellScript - text/x-shellscript
+	UserDataShellScript UserDataContentType = "text/x-shellscript"
+	// UserDataCloudBoothook - text/cloud-boothook
+	UserDataCloudBoothook UserDataContentType = "text/cloud-boothook"
+	// UserDataIncludeURL - text/x-include-url
+	UserDataIncludeURL UserDataContentType = "text/x-include-url"
+	// UserDataPlainText - text/plain
+	UserDataPlainText UserDataContentType = "text/plain"
+)
+
+const cloudConfigHeader = "#cloud-config"
+
+// UserDataPart is a single cloud-init part of an instance's userdata
+type UserDataPart struct {
+	// The type of the part, which determines how cloud-init handles it
+	ContentType UserDataContentType
+	// The file name of the part in a multi-part userdata
+	Filename string
+	// The content of the part
+	Content string
+}
+
+// CloudConfig is a structured cloud-config document, such as {"packages": ["git"]}.
+// Its values must be encodable by encoding/json.
+type CloudConfig map[string]interface{}
+
+// UserData builds the userdata attribute of an instance from cloud-config documents,
+// shell scripts and other cloud-init parts. A single part is passed to the instance as is,
+// several parts are combined into a multi-part MIME document.
+type UserData struct {
+	Parts []UserDataPart
+	// The first error adding a part, returned by Build
+	err error
+}
+
+// NewUserData returns an empty UserData to add parts to
+func NewUserData() *UserData {
+	return &UserData{}
+}
+
+// AddCloudConfig adds a cloud-config YAML document. The #cloud-config header is added if it is missing.
+func (u *UserData) AddCloudConfig(config string) *UserData {
+	if !strings.HasPrefix(config, cloudConfigHeader) {
+		config = fmt.Sprintf("%s\n%s", cloudConfigHeader, config)
+	}
+	return u.AddPart(UserDataCloudConfig, "", config)
+}
+
+// AddCloudConfigValues adds a structured cloud-config document. It's encoded as JSON, which cloud-init
+// reads as YAML, with the #cloud-config header. Errors encoding the document are returned by Build.
+func (u *UserData) AddCloudConfigValues(config CloudConfig) *UserData {
+	if len(config) == 0 {
+		u.setErr(errors.New("Cloud-config must not be empty"))
+		return u
+	}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		u.setErr(fmt.Errorf("Error encoding cloud-config: %s", err))
+		return u
+	}
+	return u.AddPart(UserDataCloudConfig, "", fmt.Sprintf("%s\n%s\n", cloudConfigHeader, encoded))
+}
+
+// AddShellScript adds a script to run on first boot. The script must start with an interpreter line, such as #!/bin/bash.
+func (u *UserData) AddShellScript(script string) *UserData {
+	return u.AddPart(UserDataShellScript, "", script)
+}
+
+// AddPart adds a part of any content type. A file name is generated if none is given.
+func (u *UserData) AddPart(contentType UserDataContentType, filename, content string) *UserData {
+	u.Parts = append(u.Parts, UserDataPart{
+		ContentType: contentType,
+		Filename:    filename,
+		Content:     content,
+	})
+	return u
+}
+
+func (u *UserData) setErr(err error) {
+	if u.err == nil {
+		u.err = err
+	}
+}
+
+// Build returns the userdata, validating each part and the size limit. The limit applies to
+// the serialized userdata, including the headers and boundaries of a multi-part MIME document.
+func (u *UserData) Build() (string, error) {
+	if u.err != nil {
+		return "", u.err
+	}
+	if len(u.Parts) == 0 {
+		return "", errors.New("Userdata must contain at least one part")
+	}
+	for i, part := range u.Parts {
+		if err := part.validate(); err != nil {
+			return "", fmt.Errorf("Invalid userdata part %d: %s", i, err)
+		}
+	}
+
+	var userData string
+	if len(u.Parts) == 1 {
+		userData = u.Parts[0].Content
+	} else {
+		var err error
+		if userData, err = u.buildMultipart(); err != nil {
+			return "", err
+		}
+	}
+
+	if len(userData) > MaxUserDataSize {
+		return "", fmt.Errorf("Userdata is %d bytes, which exceeds the limit of %d bytes", len(userData), MaxUserDataSize)
+	}
+	return userData, nil
+}
+
+// Attributes sets the userdata attribute in the given instance attributes, which may be nil,
+// and returns the resulting attributes. The size limit of all attributes is enforced as well.
+func (u *UserData) Attributes(attributes map[string]interface{}) (map[string]interface{}, error) {
+	userData, err := u.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for k, v := range attributes {
+		result[k] = v
+	}
+	result[UserDataAttribute] = userData
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) > MaxAttributesSize {
+		return nil, fmt.Errorf("Instance attributes are %d bytes, which exceeds the limit of %d bytes", len(encoded), MaxAttributesSize)
+	}
+	return result, nil
+}
+
+func (u *UserData) buildMultipart() (string, error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	if err := writer.SetBoundary(userDataBoundary); err != nil {
+		return "", err
+	}
+
+	for i, part := range u.Parts {
+		if strings.Contains(part.Content, userDataBoundary) {
+			return "", fmt.Errorf("Userdata part %d contains the MIME boundary %q", i, userDataBoundary)
+		}
+		filename := part.Filename
+		if filename == "" {
+			filename = fmt.Sprintf("part-%03d", i+1)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("%s; charset=\"utf-8\"", part.ContentType))
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Transfer-Encoding", "7bit")
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write([]byte(part.Content)); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n%s", userDataBoundary, body.String()), nil
+}
+
+func (p *UserDataPart) validate() error {
+	switch p.ContentType {
+	case UserDataCloudConfig:
+		if !strings.HasPrefix(p.Content, cloudConfigHeader) {
+			return fmt.Errorf("cloud-config must start with %q", cloudConfigHeader)
+		}
+	case UserDataShellScript:
+		if !strings.HasPrefix(p.Content, "#!") {
+			return errors.New("shell script must start with an interpreter line, such as #!/bin/bash")
+		}
+	case "":
+		return errors.New("content type must be specified")
+	}
+	return nil
+}
+
+// ParseUserData reads the userdata back from the attributes of an instance, such as InstanceInfo.Attributes.
+// A multi-part MIME document is split into its parts, otherwise the content type of the single
+// part is detected from its first line.
+func ParseUserData(attributes map[string]interface{}) (*UserData, error) {
+	value, ok := attributes[UserDataAttribute]
+	if !ok || value == nil {
+		return nil, errors.New("No userdata attribute found")
+	}
+	userData, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("Expected the userdata attribute to be a string, got %T", value)
+	}
+
+	if !strings.HasPrefix(userData, "Content-Type: multipart/") && !strings.HasPrefix(userData, "MIME-Version:") {
+		return &UserData{
+			Parts: []UserDataPart{{
+				ContentType: detectUserDataContentType(userData),
+				Content:     userData,
+			}},
+		}, nil
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(userData)))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading userdata MIME header: %s", err)
+	}
+	_, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading userdata content type: %s", err)
+	}
+
+	parsed := &UserData{}
+	parts := multipart.NewReader(reader.R, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("Error reading userdata part: %s", err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading userdata part: %s", err)
+		}
+		contentType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil {
+			contentType = string(detectUserDataContentType(string(content)))
+		}
+		parsed.Parts = append(parsed.Parts, UserDataPart{
+			ContentType: UserDataContentType(contentType),
+			Filename:    part.FileName(),
+			Content:     string(content),
+		})
+	}
+	return parsed, nil
+}
+
+func detectUserDataContentType(content string) UserDataContentType {
+	switch {
+	case strings.HasPrefix(content, cloudConfigHeader):
+		return UserDataCloudConfig
+	case strings.HasPrefix(content, "#!"):
+		return UserDataShellScript
+	case strings.HasPrefix(content, "#cloud-boothook"):
+		return UserDataCloudBoothook
+	case strings.HasPrefix(content, "#include"):
+		return UserDataIncludeURL
+	default:
+		return UserDataPlainText
+	}
+}
@@ -0,0 +1,145 @@
+package compute
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestUserData_SinglePart(t *testing.T) {
+	attributes, err := NewUserData().
+		AddCloudConfig("packages:\n  - git\n").
+		Attributes(map[string]interface{}{"attr1": "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"attr1":    "foo",
+		"userdata": "#cloud-config\npackages:\n  - git\n",
+	}
+	if diff := pretty.Compare(attributes, expected); diff != "" {
+		t.Fatalf("Attributes Diff: (-got +want)\n%s", diff)
+	}
+
+	parsed, err := ParseUserData(attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Parts) != 1 || parsed.Parts[0].ContentType != UserDataCloudConfig {
+		t.Fatalf("Expected a single cloud-config part, got %+v", parsed.Parts)
+	}
+}
+
+func TestUserData_MultiPart(t *testing.T) {
+	userData := NewUserData().
+		AddCloudConfig("#cloud-config\nruncmd:\n  - echo hello\n").
+		AddShellScript("#!/bin/bash\necho world\n").
+		AddPart(UserDataPlainText, "notes.txt", "plain text")
+
+	attributes, err := userData.Attributes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built := attributes[UserDataAttribute].(string)
+	if !strings.HasPrefix(built, "Content-Type: multipart/mixed;") {
+		t.Fatalf("Expected a multi-part MIME document, got %q", built)
+	}
+
+	// Building twice must produce the same userdata
+	again, err := userData.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != built {
+		t.Errorf("Expected userdata to be stable between builds")
+	}
+
+	parsed, err := ParseUserData(attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UserDataPart{
+		{ContentType: UserDataCloudConfig, Filename: "part-001", Content: "#cloud-config\nruncmd:\n  - echo hello\n"},
+		{ContentType: UserDataShellScript, Filename: "part-002", Content: "#!/bin/bash\necho world\n"},
+		{ContentType: UserDataPlainText, Filename: "notes.txt", Content: "plain text"},
+	}
+	if diff := pretty.Compare(parsed.Parts, expected); diff != "" {
+		t.Fatalf("Parsed Parts Diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestUserData_CloudConfigValues(t *testing.T) {
+	config := CloudConfig{
+		"packages": []string{"git"},
+		"runcmd":   [][]string{{"systemctl", "start", "app"}},
+	}
+	userData, err := NewUserData().AddCloudConfigValues(config).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "#cloud-config\n{\"packages\":[\"git\"],\"runcmd\":[[\"systemctl\",\"start\",\"app\"]]}\n"
+	if userData != expected {
+		t.Errorf("Expected userdata %q, got %q", expected, userData)
+	}
+
+	parsed, err := ParseUserData(map[string]interface{}{UserDataAttribute: userData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Parts) != 1 || parsed.Parts[0].ContentType != UserDataCloudConfig {
+		t.Fatalf("Expected a single cloud-config part, got %+v", parsed.Parts)
+	}
+
+	if _, err := NewUserData().AddCloudConfigValues(CloudConfig{}).Build(); err == nil {
+		t.Errorf("Expected an error building an empty cloud-config")
+	}
+	invalid := NewUserData().
+		AddCloudConfigValues(CloudConfig{"write_files": func() {}}).
+		AddShellScript("#!/bin/sh\n")
+	if _, err := invalid.Build(); err == nil {
+		t.Errorf("Expected an error building a cloud-config that can't be encoded")
+	}
+
+	// The size limit applies to the serialized cloud-config
+	large := CloudConfig{"runcmd": []string{strings.Repeat("a", MaxUserDataSize-len("#cloud-config\n{\"runcmd\":[\"\"]}\n"))}}
+	if _, err := NewUserData().AddCloudConfigValues(large).Build(); err != nil {
+		t.Errorf("Expected a cloud-config of exactly %d bytes to be valid: %s", MaxUserDataSize, err)
+	}
+	large["runcmd"] = []string{strings.Repeat("a", MaxUserDataSize)}
+	if _, err := NewUserData().AddCloudConfigValues(large).Build(); err == nil {
+		t.Errorf("Expected an error building a cloud-config larger than %d bytes", MaxUserDataSize)
+	}
+}
+
+func TestUserData_Validation(t *testing.T) {
+	if _, err := NewUserData().Build(); err == nil {
+		t.Errorf("Expected an error building empty userdata")
+	}
+
+	if _, err := NewUserData().AddShellScript("echo missing interpreter").Build(); err == nil {
+		t.Errorf("Expected an error building a shell script without an interpreter line")
+	}
+
+	large := NewUserData().AddShellScript("#!/bin/sh\n" + strings.Repeat("#", MaxUserDataSize))
+	if _, err := large.Build(); err == nil {
+		t.Errorf("Expected an error building userdata larger than %d bytes", MaxUserDataSize)
+	}
+
+	attributes := map[string]interface{}{
+		"large": strings.Repeat("a", MaxAttributesSize),
+	}
+	if _, err := NewUserData().AddShellScript("#!/bin/sh\n").Attributes(attributes); err == nil {
+		t.Errorf("Expected an error when the attributes exceed %d bytes", MaxAttributesSize)
+	}
+}
+
+func TestParseUserData_Missing(t *testing.T) {
+	if _, err := ParseUserData(map[string]interface{}{}); err == nil {
+		t.Errorf("Expected an error parsing attributes without userdata")
+	}
+	if _, err := ParseUserData(map[string]interface{}{"userdata": 12}); err == nil {
+		t.Errorf("Expected an error parsing userdata that isn't a string")
+	}
+}
@@ -140,6 +140,26 @@ func (c *VirtNICSetsClient) DeleteVirtualNICSet(input *DeleteVirtualNICSetInput)
 	return c.deleteResource(input.Name)
 }
 
+// VirtualNICSetList represents the list of virtual NIC sets returned by the service
+type VirtualNICSetList struct {
+	Result []VirtualNICSet `json:"result"`
+}
+
+// ListVirtualNICSets retrieves the virtual nic sets of the client's owner
+func (c *VirtNICSetsClient) ListVirtualNICSets() ([]VirtualNICSet, error) {
+	var list VirtualNICSetList
+	if err := c.getResource(c.getListContainer(), &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Result {
+		if _, err := c.success(&list.Result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list.Result, nil
+}
+
 func (c *VirtNICSetsClient) getQualifiedAcls(acls []string) []string {
 	qualifiedAcls := []string{}
 	for _, acl := range acls {
@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 const waitForServiceInstanceReadyPollInterval = 60 * time.Second
@@ -565,11 +566,11 @@ type ParameterInput struct {
 	// Valid values are: db - Oracle Database
 	// Required.
 	Type ServiceInstanceType `json:"type"`
-	// Storage size for data (in GB). Minimum value is 15. Maximum value depends on the backup
-	// destination: if BOTH is specified, the maximum value is 1200; if OSS or NONE is specified,
-	// the maximum value is 2048.
+	// Storage size for data, sent to the API in GB. Minimum value is 15G. Maximum value depends on the backup
+	// destination: if BOTH is specified, the maximum value is 1200G; if OSS or NONE is specified,
+	// the maximum value is 2048G.
 	// Required.
-	UsableStorage string `json:"usableStorage"`
+	UsableStorage opc.ByteSizeGB `json:"usableStorage"`
 	// Specify if the given cloudStorageContainer is to be created if it does not already exist.
 	// Default value is false.
 	// Optional.
@@ -887,10 +888,10 @@ type UpdateServiceInstanceInput struct {
 	// Name of the service instance to update.
 	// Required
 	Name string `json:"-"`
-	// Specify size of additional storage in Giga Bytes. This parameter is optional. User can change shape
+	// Specify size of additional storage, sent to the API in GB. This parameter is optional. User can change shape
 	// only without adding storage. If additionalStorage is specified, minimum value is 1GB and maximum value is 1TB.
 	// Optional
-	AdditionalStorage string `json:"additionalStorage,omitempty"`
+	AdditionalStorage opc.ByteSizeGB `json:"additionalStorage,omitempty"`
 	// (Applies only to service instances that use Oracle RAC and Oracle Data Guard together.)
 	// Specifies whether the scaling operation applies to the primary database or standby database of the
 	// Data Guard configuration. Specify the value DB_1 for the primary database or the value DB_2 for the
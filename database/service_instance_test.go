@@ -21,7 +21,7 @@ const (
 	_ServiceInstanceBackupDestination           = "NONE"
 	_ServiceInstanceDBSID                       = "ORCL"
 	_ServiceInstanceType                        = "db"
-	_ServiceInstanceUsableStorage               = "15"
+	_ServiceInstanceUsableStorage               = opc.ByteSizeGB(15 * opc.GB)
 	_ServiceInstanceCloudStorageContainer       = "Storage-a459477/test-database-instance"
 	_ServiceInstanceCloudStorageCreateIfMissing = true
 	_ServiceInstanceBackupDestinationBoth       = "BOTH"
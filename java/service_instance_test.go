@@ -31,7 +31,7 @@ const (
 	_ServiceInstanceBackupDestinationBoth   = "BOTH"
 	_ServiceInstanceDBSID                   = "ORCL"
 	_ServiceInstanceDBType                  = "db"
-	_ServiceInstanceUsableStorage           = "25"
+	_ServiceInstanceUsableStorage           = opc.ByteSizeGB(25 * opc.GB)
 	_ServiceInstanceDBCloudStorageContainer = "Storage-a459477/test-db-java-instancea"
 	_ServiceInstanceEdition                 = "EE"
 	_ServiceInstanceDatabaseShape           = "oc3"
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-oracle-terraform/client"
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 // WaitForServiceInstanceReadyPollInterval is the default polling interval value for Creating a service instance and waiting for the instance to be ready
@@ -265,8 +266,8 @@ type ComponentParameters struct {
 type MySQLParameters struct {
 	// The name of the MySQL Database. This defaults to mydatabase if the value is omitted or blank.
 	DBName string `json:"dbName,omitempty"`
-	// The Storage Volume size (in GB) for the MySQL Data. The value must be between 25 and 1024. The default value is 25.
-	DBStorage string `json:"dbStorage, omitempty"`
+	// The Storage Volume size for the MySQL Data, sent to the API in GB. The value must be between 25G and 1024G. The default value is 25G.
+	DBStorage opc.ByteSizeGB `json:"dbStorage,omitempty"`
 	// Indicate whether the MySQL Enterprise Monitor should be configured. Values : [ "Yes, "No"]. The default is "No"
 	EnterpriseMonitor string `json:"enterpriseMonitor,omitempty"`
 	// Password for the EM Agent. The password must be at least 8 characters long, and have at least one lower case letter, one upper case letter, one number and one special character
@@ -15,7 +15,7 @@ const (
 	_ServiceInstanceName              = "testserviceinstance1"
 
 	_Service_MySQLDBName              = "demo_db"
-	_Service_MySQLStorage             = "25"
+	_Service_MySQLStorage             = opc.ByteSizeGB(25 * opc.GB)
 	_Service_MySQLPort                = "3306"
 	_Service_MySQLUser                = "root"
 	_Service_MySQLPassword            = "MySqlPassword_1"
@@ -0,0 +1,157 @@
+package opc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes, such as the size of a storage volume or the memory of an instance.
+// It's parsed from Oracle's size notations, a number of bytes or a number followed by a unit such
+// as 10G or 2048M, and marshals to JSON as a string of bytes, as the Compute API expects.
+type ByteSize int64
+
+// Units of ByteSize. Oracle's APIs use binary units, so a G is 1024M.
+const (
+	Byte ByteSize = 1
+	KB            = 1024 * Byte
+	MB            = 1024 * KB
+	GB            = 1024 * MB
+	TB            = 1024 * GB
+)
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"T", TB},
+	{"G", GB},
+	{"M", MB},
+	{"K", KB},
+}
+
+// ParseByteSize parses a size such as 10737418240, 10G, 10GB or 2048m. A number without a unit is a number of bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	return parseByteSize(s, Byte)
+}
+
+// Parses a size, using unit for a number without a unit
+func parseByteSize(s string, unit ByteSize) (ByteSize, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	binary := strings.HasSuffix(value, "IB")
+	if binary {
+		value = strings.TrimSuffix(value, "IB")
+	} else if strings.HasSuffix(value, "B") {
+		value = strings.TrimSuffix(value, "B")
+		unit = Byte
+	}
+	found := false
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSuffix(value, u.suffix)
+			unit = u.size
+			found = true
+			break
+		}
+	}
+	if binary && !found {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("Size %q is too large", s)
+	}
+	return ByteSize(n) * unit, nil
+}
+
+// Bytes returns the size in bytes
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
+
+// GB returns the size in whole GB, rounded down
+func (b ByteSize) GB() int64 {
+	return int64(b / GB)
+}
+
+// String formats the size in the largest unit it's a whole number of, such as 10G or 1536M
+func (b ByteSize) String() string {
+	if b != 0 {
+		for _, u := range byteSizeUnits {
+			if b%u.size == 0 {
+				return fmt.Sprintf("%d%s", b/u.size, u.suffix)
+			}
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+// MarshalJSON formats the size as a string of bytes
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(b), 10))
+}
+
+// UnmarshalJSON parses a size from a JSON number of bytes, or a string in any of the notations ParseByteSize accepts
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	size, err := unmarshalByteSize(data, Byte)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// ByteSizeGB is a size that's sent to the API as a whole number of GB, such as the storage of
+// database and MySQL service instances. A number without a unit is parsed as a number of GB.
+type ByteSizeGB ByteSize
+
+// ParseByteSizeGB parses a size such as 15, 15G or 1T. A number without a unit is a number of GB.
+func ParseByteSizeGB(s string) (ByteSizeGB, error) {
+	size, err := parseByteSize(s, GB)
+	return ByteSizeGB(size), err
+}
+
+// String formats the size in the largest unit it's a whole number of, such as 15G or 1T
+func (b ByteSizeGB) String() string {
+	return ByteSize(b).String()
+}
+
+// MarshalJSON formats the size as a string of GB, and fails if it isn't a whole number of GB
+func (b ByteSizeGB) MarshalJSON() ([]byte, error) {
+	if ByteSize(b)%GB != 0 {
+		return nil, fmt.Errorf("Size %s isn't a whole number of GB", ByteSize(b))
+	}
+	return json.Marshal(strconv.FormatInt(ByteSize(b).GB(), 10))
+}
+
+// UnmarshalJSON parses a size from a JSON number of GB, or a string in any of the notations ParseByteSizeGB accepts
+func (b *ByteSizeGB) UnmarshalJSON(data []byte) error {
+	size, err := unmarshalByteSize(data, GB)
+	if err != nil {
+		return err
+	}
+	*b = ByteSizeGB(size)
+	return nil
+}
+
+func unmarshalByteSize(data []byte, unit ByteSize) (ByteSize, error) {
+	value := strings.TrimSpace(string(data))
+	if value == "null" {
+		return 0, nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(value) == "" {
+			return 0, nil
+		}
+	}
+	return parseByteSize(value, unit)
+}
@@ -0,0 +1,145 @@
+package opc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	valid := map[string]ByteSize{
+		"0":           0,
+		"10737418240": 10 * GB,
+		"512B":        512,
+		"10G":         10 * GB,
+		"10g":         10 * GB,
+		"10GB":        10 * GB,
+		"10GiB":       10 * GB,
+		"2048M":       2 * GB,
+		" 64k ":       64 * KB,
+		"1T":          TB,
+	}
+	for s, expected := range valid {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %s", s, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("Expected %q to parse as %d bytes, got %d", s, expected, size)
+		}
+	}
+
+	invalid := []string{"", "G", "10X", "-1G", "1.5G", "10iB", "99999999999T"}
+	for _, s := range invalid {
+		if _, err := ParseByteSize(s); err == nil {
+			t.Errorf("Expected %q not to parse", s)
+		}
+	}
+}
+
+func TestParseByteSizeGB(t *testing.T) {
+	valid := map[string]ByteSizeGB{
+		"15":    ByteSizeGB(15 * GB),
+		"15G":   ByteSizeGB(15 * GB),
+		"1T":    ByteSizeGB(TB),
+		"1024M": ByteSizeGB(GB),
+	}
+	for s, expected := range valid {
+		size, err := ParseByteSizeGB(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %s", s, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("Expected %q to parse as %s, got %s", s, expected, size)
+		}
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	expected := map[ByteSize]string{
+		0:           "0B",
+		1023:        "1023B",
+		64 * KB:     "64K",
+		1536 * MB:   "1536M",
+		10 * GB:     "10G",
+		2 * TB:      "2T",
+		TB + 1*GB:   "1025G",
+		GB + 512*KB: "1049088K",
+	}
+	for size, s := range expected {
+		if size.String() != s {
+			t.Errorf("Expected %d bytes to format as %s, got %s", size, s, size.String())
+		}
+		if parsed, err := ParseByteSize(s); err != nil || parsed != size {
+			t.Errorf("Expected %s to parse back as %d bytes, got %d (%v)", s, size, parsed, err)
+		}
+	}
+}
+
+func TestByteSize_JSON(t *testing.T) {
+	var body struct {
+		Size       ByteSize   `json:"size"`
+		Memory     ByteSize   `json:"memory"`
+		RootDisk   ByteSize   `json:"root_disk"`
+		Storage    ByteSizeGB `json:"storage"`
+		Additional ByteSizeGB `json:"additional,omitempty"`
+	}
+	data := `{"size": "21474836480", "memory": "2G", "root_disk": 10737418240, "storage": "25"}`
+	if err := json.Unmarshal([]byte(data), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Size != 20*GB || body.Memory != 2*GB || body.RootDisk != 10*GB || body.Storage != ByteSizeGB(25*GB) {
+		t.Fatalf("Unexpected sizes %+v", body)
+	}
+
+	marshaled, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"size":"21474836480","memory":"2147483648","root_disk":"10737418240","storage":"25"}`
+	if string(marshaled) != expected {
+		t.Errorf("Expected %s, got %s", expected, marshaled)
+	}
+
+	body.Additional = ByteSizeGB(512 * MB)
+	if _, err := json.Marshal(body); err == nil {
+		t.Errorf("Expected an error marshaling a size that isn't a whole number of GB")
+	}
+
+	if err := json.Unmarshal([]byte(`{"size": "lots"}`), &body); err == nil {
+		t.Errorf("Expected an error unmarshaling an invalid size")
+	}
+}
+
+func TestDecodeHook_ByteSize(t *testing.T) {
+	decoded, err := DecodeHook(reflect.TypeOf(""), reflect.TypeOf(ByteSize(0)), "10G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != 10*GB {
+		t.Errorf("Expected 10G to decode to %d, got %v", 10*GB, decoded)
+	}
+
+	decoded, err = DecodeHook(reflect.TypeOf(""), reflect.TypeOf(ByteSizeGB(0)), "25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != ByteSizeGB(25*GB) {
+		t.Errorf("Expected 25 to decode to %d, got %v", 25*GB, decoded)
+	}
+
+	// Values of other types are left as they are
+	decoded, err = DecodeHook(reflect.TypeOf(""), reflect.TypeOf(""), "10G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != "10G" {
+		t.Errorf("Expected a string to stay a string, got %v", decoded)
+	}
+
+	if _, err := DecodeHook(reflect.TypeOf(""), reflect.TypeOf(ByteSize(0)), "lots"); err == nil {
+		t.Errorf("Expected an error decoding an invalid size")
+	}
+}
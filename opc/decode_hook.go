@@ -0,0 +1,30 @@
+package opc
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+var opcPkgPath = reflect.TypeOf(Time{}).PkgPath()
+
+// DecodeHook is a mapstructure decode hook for the types of this package, such as Time and ByteSize.
+// The resource clients decode responses with mapstructure, which doesn't use the UnmarshalJSON of
+// a type, so the hook converts the decoded JSON value back to JSON and unmarshals it.
+func DecodeHook(_, to reflect.Type, data interface{}) (interface{}, error) {
+	if to.PkgPath() != opcPkgPath {
+		return data, nil
+	}
+	target := reflect.New(to)
+	unmarshaler, ok := target.Interface().(json.Unmarshaler)
+	if !ok {
+		return data, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := unmarshaler.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return target.Elem().Interface(), nil
+}
@@ -0,0 +1,91 @@
+package opc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time is a timestamp returned by one of Oracle's APIs. It decodes any of the formats the APIs emit,
+// and keeps the original string, so the timestamp marshals back exactly as it was received.
+type Time struct {
+	time.Time
+	// The timestamp as it was received
+	Raw string
+}
+
+// The layouts of the timestamps Oracle's APIs emit. Timestamps without a zone are in UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+	"2006-01-02",
+}
+
+// ParseTime parses a timestamp in ISO 8601 with or without a zone, seconds since the epoch such as
+// 1525112424.12345, or RFC 1123. The returned Time keeps the original string, even if it can't be parsed.
+func ParseTime(s string) (Time, error) {
+	t := Time{Raw: s}
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return t, nil
+	}
+
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		seconds, fraction := math.Modf(epoch)
+		t.Time = time.Unix(int64(seconds), int64(math.Round(fraction*1e9))).UTC()
+		return t, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("Unrecognized timestamp %q", s)
+}
+
+// String returns the original timestamp, or the time in RFC 3339 if it wasn't parsed from a string
+func (t Time) String() string {
+	if t.Raw != "" {
+		return t.Raw
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// MarshalJSON formats the timestamp as a string, as it was received
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes a timestamp from a string or a number of seconds since the epoch.
+// Timestamps in an unrecognized format are kept as Raw, with a zero time, rather than failing
+// the whole response.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	value := strings.TrimSpace(string(data))
+	if value == "null" {
+		*t = Time{}
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	*t, _ = ParseTime(value)
+	return nil
+}
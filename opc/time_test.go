@@ -0,0 +1,107 @@
+package opc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	expected := map[string]time.Time{
+		"2018-05-01T21:10:42Z":          time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC),
+		"2018-05-01T21:10:42.837Z":      time.Date(2018, 5, 1, 21, 10, 42, 837000000, time.UTC),
+		"2018-05-01T23:10:42+02:00":     time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC),
+		"2018-05-01T21:10:42.837+0000":  time.Date(2018, 5, 1, 21, 10, 42, 837000000, time.UTC),
+		"2018-05-01T21:10:42":           time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC),
+		"2018-05-01 21:10:42.123456":    time.Date(2018, 5, 1, 21, 10, 42, 123456000, time.UTC),
+		"1525209042":                    time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC),
+		"1525209042.25000":              time.Date(2018, 5, 1, 21, 10, 42, 250000000, time.UTC),
+		"Tue, 01 May 2018 21:10:42 GMT": time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC),
+		"2018-05-01":                    time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for s, want := range expected {
+		parsed, err := ParseTime(s)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %s", s, err)
+			continue
+		}
+		if !parsed.Equal(want) {
+			t.Errorf("Expected %q to parse as %s, got %s", s, want, parsed.Time)
+		}
+		if parsed.String() != s {
+			t.Errorf("Expected %q to be kept, got %q", s, parsed.String())
+		}
+	}
+
+	parsed, err := ParseTime("last tuesday")
+	if err == nil {
+		t.Errorf("Expected an error parsing an unrecognized timestamp")
+	}
+	if parsed.Raw != "last tuesday" || !parsed.IsZero() {
+		t.Errorf("Expected the unrecognized timestamp to be kept with a zero time, got %+v", parsed)
+	}
+}
+
+func TestTime_JSON(t *testing.T) {
+	var body struct {
+		Created  Time `json:"created"`
+		Modified Time `json:"modified"`
+		Audited  Time `json:"audited"`
+		Unknown  Time `json:"unknown"`
+	}
+	data := `{"created":"2018-05-01T21:10:42.837+0000","modified":1525209042.25,"audited":null,"unknown":"soon"}`
+	if err := json.Unmarshal([]byte(data), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.Created.Equal(time.Date(2018, 5, 1, 21, 10, 42, 837000000, time.UTC)) {
+		t.Errorf("Unexpected created time %s", body.Created.Time)
+	}
+	if !body.Modified.Equal(time.Date(2018, 5, 1, 21, 10, 42, 250000000, time.UTC)) {
+		t.Errorf("Unexpected modified time %s", body.Modified.Time)
+	}
+	if !body.Audited.IsZero() || body.Unknown.Raw != "soon" {
+		t.Errorf("Unexpected times %+v", body)
+	}
+
+	marshaled, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"created":"2018-05-01T21:10:42.837+0000","modified":"1525209042.25","audited":"","unknown":"soon"}`
+	if string(marshaled) != expected {
+		t.Errorf("Expected %s, got %s", expected, marshaled)
+	}
+
+	constructed, err := json.Marshal(Time{Time: time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(constructed) != `"2018-05-01T21:10:42Z"` {
+		t.Errorf("Expected a time without a raw string to marshal as RFC 3339, got %s", constructed)
+	}
+}
+
+func TestDecodeHook(t *testing.T) {
+	timeType := reflect.TypeOf(Time{})
+	decoded, err := DecodeHook(reflect.TypeOf(""), timeType, "2018-05-01T21:10:42Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm, ok := decoded.(Time); !ok || !tm.Equal(time.Date(2018, 5, 1, 21, 10, 42, 0, time.UTC)) {
+		t.Errorf("Expected the string to be decoded as a Time, got %#v", decoded)
+	}
+
+	decoded, err = DecodeHook(reflect.TypeOf(""), reflect.TypeOf(ByteSize(0)), "2G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != 2*GB {
+		t.Errorf("Expected the string to be decoded as 2G, got %#v", decoded)
+	}
+
+	decoded, err = DecodeHook(reflect.TypeOf(""), reflect.TypeOf(""), "2G")
+	if err != nil || decoded != "2G" {
+		t.Errorf("Expected types of other packages to be left alone, got %#v (%v)", decoded, err)
+	}
+}
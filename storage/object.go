@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/hashicorp/go-oracle-terraform/opc"
 )
 
 // ObjectClient details the parameters needed for a storage object client
@@ -79,7 +81,7 @@ type ObjectInfo struct {
 	// for each segment of the manifest. Enclosed in double-quote characters
 	Etag string
 	// Date and time when the object was created/modified. ISO 8601.
-	LastModified string
+	LastModified opc.Time
 	// Optional: Date+Time in EPOCH that the object will be deleted.
 	DeleteAt int
 	// Optional: The dynamic large object manifest object.
@@ -88,7 +90,7 @@ type ObjectInfo struct {
 	ObjectMetadata map[string]string
 	// Date and time in UNIX EPOCH when the account, container, _or_ object
 	// was initially created as a current version.
-	Timestamp string
+	Timestamp opc.Time
 	// Transaction ID of the request - Used for bug reports to service providers
 	TransactionID string
 }
@@ -124,6 +126,10 @@ type CreateObjectInput struct {
 	DeleteAt int
 	// Specify the map of object metadata name values pairs for X-Object-Meta-{name}
 	ObjectMetadata map[string]string
+	// Specify the `container/prefix` of the segments, to create the manifest object of a
+	// dynamic large object. The body of a manifest object must be empty.
+	// Optional
+	ObjectManifest string
 	// MD5 checksum value of the request body. Unquoted
 	// Strongly recommended, not required.
 	ETag string
@@ -163,6 +169,9 @@ func (c *ObjectClient) CreateObject(input *CreateObjectInput) (*ObjectInfo, erro
 	if input.DeleteAt != 0 {
 		headers[hDeleteAt] = fmt.Sprintf("%d", input.DeleteAt)
 	}
+	if input.ObjectManifest != "" {
+		headers[hObjectManifest] = input.ObjectManifest
+	}
 	if len(input.ObjectMetadata) > 0 {
 		// add a header entry for each metadata item
 		// X-Object-Meta-{name}: value
@@ -288,9 +297,9 @@ func (c *ObjectClient) success(resp *http.Response, object *ObjectInfo) (*Object
 	object.ContentType = resp.Header.Get(hContentType)
 	object.Date = resp.Header.Get(hDate)
 	object.Etag = resp.Header.Get(hETag)
-	object.LastModified = resp.Header.Get(hLastModified)
+	object.LastModified, _ = opc.ParseTime(resp.Header.Get(hLastModified))
 	object.ObjectManifest = resp.Header.Get(hObjectManifest)
-	object.Timestamp = resp.Header.Get(hTimestamp)
+	object.Timestamp, _ = opc.ParseTime(resp.Header.Get(hTimestamp))
 	object.TransactionID = resp.Header.Get(hTransactionID)
 
 	if v := resp.Header.Get(hContentLength); v != "" {
@@ -218,20 +218,20 @@ func testAssertions(result, expected *ObjectInfo) error {
 	}
 	result.Date = ""
 
-	if result.Timestamp == "" {
+	if result.Timestamp.IsZero() {
 		return fmt.Errorf("Timestamp Expected, got nil")
 	}
-	result.Timestamp = ""
+	result.Timestamp = opc.Time{}
 
 	if result.Etag == "" {
 		return fmt.Errorf("ETag expected, got nil")
 	}
 	result.Etag = ""
 
-	if result.LastModified == "" {
+	if result.LastModified.IsZero() {
 		return fmt.Errorf("Last modified expected, got nil")
 	}
-	result.LastModified = ""
+	result.LastModified = opc.Time{}
 
 	if result.TransactionID == "" {
 		return fmt.Errorf("Transaction ID expected, got nil")